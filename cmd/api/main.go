@@ -38,8 +38,18 @@ func main() {
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	timeout := time.Duration(conf.Server.ShutdownTimeout) * time.Second
+	if timeout == 0 {
+		timeout = server.DefaultShutdownTimeout
+	}
+	logger.Info().Dur("timeout", timeout).Msg("draining; signal again to stop immediately")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
 
 	if err := srv.Stop(ctx); err != nil {
 		logger.Error().Err(err).Msg("graceful shutdown failed")
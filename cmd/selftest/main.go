@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/itstheanurag/executioner/internal/sandbox"
+	"github.com/rs/zerolog"
+)
+
+// selftest runs every registered language's self-test program through the
+// Docker sandbox and exits non-zero if any of them fail. Pass language IDs
+// as arguments to check a subset.
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+
+	registry := languages.NewRegistry()
+	sb, err := sandbox.NewDockerSandbox(&logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create sandbox")
+	}
+	exec := executor.NewExecutor(registry, sb)
+
+	var langs []languages.Language
+	if len(os.Args) > 1 {
+		for _, id := range os.Args[1:] {
+			lang, err := registry.Get(id)
+			if err != nil {
+				logger.Fatal().Err(err).Str("language", id).Msg("unknown language")
+			}
+			langs = append(langs, lang)
+		}
+	} else {
+		langs = registry.List()
+		sort.Slice(langs, func(i, j int) bool { return langs[i].ID < langs[j].ID })
+	}
+
+	failed := 0
+	for _, lang := range langs {
+		if !runSelfTest(exec, sb, lang, &logger) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		logger.Error().Int("failed", failed).Int("total", len(langs)).Msg("self-test failed")
+		os.Exit(1)
+	}
+	logger.Info().Int("total", len(langs)).Msg("all self-tests passed")
+}
+
+func runSelfTest(exec *executor.Executor, sb sandbox.Sandbox, lang languages.Language, logger *zerolog.Logger) bool {
+	log := logger.With().Str("language", lang.ID).Logger()
+
+	if err := sb.EnsureImage(context.Background(), lang.Config.Image); err != nil {
+		log.Error().Err(err).Msg("image unavailable")
+		return false
+	}
+
+	opts, err := exec.ResolveLimits(executor.ExecuteOptions{
+		LanguageID: lang.ID,
+		SourceCode: lang.SelfTest.SourceCode,
+		Stdin:      lang.SelfTest.Stdin,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("invalid default limits")
+		return false
+	}
+
+	timeout := time.Duration(opts.CompileTimeLimitMs+opts.TimeLimitMs)*time.Millisecond + 5*time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res, err := exec.Execute(ctx, opts)
+	if err != nil {
+		log.Error().Err(err).Msg("execution failed")
+		return false
+	}
+
+	if res.Status != "success" || strings.TrimSpace(res.Stdout) != strings.TrimSpace(lang.SelfTest.ExpectedOutput) {
+		log.Error().
+			Str("status", res.Status).
+			Str("error_type", res.ErrorType).
+			Str("stdout", res.Stdout).
+			Str("stderr", res.Stderr).
+			Msg("unexpected result")
+		return false
+	}
+
+	log.Info().Int64("time_ms", res.TimeMs).Msg("ok")
+	return true
+}
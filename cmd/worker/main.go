@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/itstheanurag/executioner/internal/remote"
+	"github.com/itstheanurag/executioner/internal/sandbox"
+	"github.com/itstheanurag/executioner/internal/workerpb"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	version = "dev"
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// worker is a remote worker node: it connects to an API server's worker
+// listener, offers the languages it has images for, and runs the jobs it
+// is sent in its local Docker sandbox. Flags default to EXECUTIONER_NODE_*
+// environment variables.
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+
+	hostname, _ := os.Hostname()
+	server := flag.String("server", env("EXECUTIONER_NODE_SERVER", "localhost:9090"), "address of the API server's worker listener")
+	token := flag.String("token", env("EXECUTIONER_NODE_TOKEN", ""), "token shared with the API server")
+	name := flag.String("name", env("EXECUTIONER_NODE_NAME", hostname), "name reported to the API server")
+	capacity := flag.Int("capacity", envInt("EXECUTIONER_NODE_CAPACITY", runtime.NumCPU()), "number of jobs to run at once")
+	langList := flag.String("languages", env("EXECUTIONER_NODE_LANGUAGES", ""), "comma-separated language IDs to offer (default all)")
+	useTLS := flag.Bool("tls", env("EXECUTIONER_NODE_TLS", "") == "true", "connect over TLS")
+	caFile := flag.String("ca", env("EXECUTIONER_NODE_CA", ""), "CA certificate to verify the server with (default system roots)")
+	reapInterval := flag.Duration("reap-interval", time.Minute, "how often to remove sandbox containers left behind by crashed processes")
+	flag.Parse()
+
+	if *token == "" {
+		logger.Fatal().Msg("a node token is required")
+	}
+	if *capacity < 1 {
+		logger.Fatal().Int("capacity", *capacity).Msg("capacity must be at least 1")
+	}
+
+	registry := languages.NewRegistry()
+	sb, err := sandbox.NewDockerSandbox(&logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create sandbox")
+	}
+	exec := executor.NewExecutor(registry, sb)
+
+	if n, err := sb.Reap(context.Background()); err != nil {
+		logger.Warn().Err(err).Msg("failed to reap orphaned sandbox containers")
+	} else if n > 0 {
+		logger.Info().Int("containers", n).Msg("reaped orphaned sandbox containers")
+	}
+	sb.StartReaper(*reapInterval)
+
+	var langs []languages.Language
+	if *langList != "" {
+		for _, id := range strings.Split(*langList, ",") {
+			lang, err := registry.Get(strings.TrimSpace(id))
+			if err != nil {
+				logger.Fatal().Err(err).Str("language", id).Msg("unknown language")
+			}
+			langs = append(langs, lang)
+		}
+	} else {
+		langs = registry.List()
+		sort.Slice(langs, func(i, j int) bool { return langs[i].ID < langs[j].ID })
+	}
+
+	ids := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		if err := sb.EnsureImage(context.Background(), lang.Config.Image); err != nil {
+			logger.Fatal().Err(err).Str("language", lang.ID).Msg("failed to ensure docker image")
+		}
+		ids = append(ids, lang.ID)
+	}
+
+	creds := insecure.NewCredentials()
+	if *useTLS {
+		creds = credentials.NewTLS(nil)
+		if *caFile != "" {
+			creds, err = credentials.NewClientTLSFromFile(*caFile, "")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("failed to load CA certificate")
+			}
+		}
+	}
+	conn, err := grpc.NewClient(*server,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(remote.TokenCredentials{Token: *token, Insecure: !*useTLS}),
+	)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create client")
+	}
+	defer conn.Close()
+
+	node := remote.NewNode(workerpb.NewWorkerServiceClient(conn), exec, remote.NodeOptions{
+		Name:      *name,
+		Languages: ids,
+		Capacity:  *capacity,
+		Version:   version,
+	}, &logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info().Str("server", *server).Strs("languages", ids).Int("capacity", *capacity).Msg("starting worker node")
+
+	// Reconnect until stopped, backing off while the server is unreachable
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := node.Run(ctx)
+		if ctx.Err() != nil {
+			logger.Info().Msg("worker node stopped")
+			return
+		}
+		if time.Since(started) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+		logger.Error().Err(err).Dur("retry_in", delay).Msg("disconnected from server")
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func env(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/itstheanurag/executioner/internal/controls"
+	"github.com/itstheanurag/executioner/internal/deadletter"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/itstheanurag/executioner/internal/limiter"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/remote"
+	"github.com/itstheanurag/executioner/internal/tenant"
+	"github.com/itstheanurag/executioner/internal/worker"
+)
+
+// defaultDeadLetterLimit is how many entries GET /admin/dead-letters
+// returns without a limit parameter.
+const defaultDeadLetterLimit = 50
+
+// AdminHandler serves operator endpoints under /admin. They are disabled
+// unless an admin token is configured, and require it as a bearer token.
+// It is built on the API handler, sharing its queue and stores, and on
+// the components that run jobs. nodes is nil unless remote workers are
+// enabled.
+type AdminHandler struct {
+	handler      *Handler
+	queueManager queue.Queue
+	registry     *languages.Registry
+	controls     *controls.Store
+	workers      *worker.Pool
+	nodes        *remote.Dispatcher
+	rateLimiter  *limiter.RateLimiter
+	deadLetters  *deadletter.Store
+	tenants      *tenant.Store
+	token        string
+}
+
+func NewAdminHandler(
+	handler *Handler,
+	registry *languages.Registry,
+	switches *controls.Store,
+	workers *worker.Pool,
+	nodes *remote.Dispatcher,
+	rl *limiter.RateLimiter,
+	deadLetters *deadletter.Store,
+	token string,
+) *AdminHandler {
+	return &AdminHandler{
+		handler:      handler,
+		queueManager: handler.queueManager,
+		registry:     registry,
+		controls:     switches,
+		workers:      workers,
+		nodes:        nodes,
+		rateLimiter:  rl,
+		deadLetters:  deadLetters,
+		tenants:      handler.tenants,
+		token:        token,
+	}
+}
+
+// Authorize rejects requests without the admin token.
+func (h *AdminHandler) Authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.token == "" {
+			http.NotFound(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// DeadLetters lists dead-lettered jobs, newest first.
+func (h *AdminHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultDeadLetterLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.deadLetters.List(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// DeadLetter shows one dead-lettered job with its failures (GET) or
+// discards it (DELETE).
+func (h *AdminHandler) DeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	switch r.Method {
+	case http.MethodGet:
+		entry, err := h.deadLetters.Get(r.Context(), id)
+		if err != nil {
+			writeDeadLetterError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, entry)
+	case http.MethodDelete:
+		if err := h.deadLetters.Delete(r.Context(), id); err != nil {
+			writeDeadLetterError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// RequeueDeadLetter submits a dead-lettered job again under a new ID, with
+// a fresh retry budget. Nobody waits for its result; with the postgres
+// queue it is recorded in the jobs table.
+func (h *AdminHandler) RequeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	entry, err := h.deadLetters.Get(r.Context(), id)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+
+	job := entry.Job(queue.NewJobID())
+	if err := h.queueManager.Submit(r.Context(), job); err != nil {
+		var fullErr *queue.FullError
+		switch {
+		case errors.As(err, &fullErr):
+			writeQueueFull(w, r, fullErr)
+		case errors.Is(err, queue.ErrShuttingDown):
+			writeShuttingDown(w, r)
+		case errors.Is(err, queue.ErrPaused):
+			writePaused(w, r)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := h.deadLetters.Delete(r.Context(), id); err != nil && !errors.Is(err, deadletter.ErrNotFound) {
+		http.Error(w, "Requeued as "+job.ID+", but failed to remove dead letter: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"job_id": job.ID})
+}
+
+func writeDeadLetterError(w http.ResponseWriter, err error) {
+	if errors.Is(err, deadletter.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
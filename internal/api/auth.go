@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/itstheanurag/executioner/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// APIKeyHeader carries a client's API key.
+	APIKeyHeader = "X-API-Key"
+	// judge0KeyHeader is Judge0's name for it, accepted as well so that
+	// Judge0 clients can authenticate unchanged.
+	judge0KeyHeader = "X-Auth-Token"
+)
+
+// Authenticator identifies clients by their API key. Requests with an
+// unknown key are rejected. Requests without a key are rejected too when
+// keys are required, and otherwise served as anonymous clients, whose
+// usage is not metered.
+type Authenticator struct {
+	tenants  *tenant.Store
+	required bool
+}
+
+func NewAuthenticator(tenants *tenant.Store, required bool) *Authenticator {
+	return &Authenticator{
+		tenants:  tenants,
+		required: required,
+	}
+}
+
+// authenticate returns ctx with the tenant of key, if there is one.
+func (a *Authenticator) authenticate(ctx context.Context, key string) (context.Context, error) {
+	if key == "" {
+		if a.required {
+			return ctx, tenant.ErrInvalidKey
+		}
+		return ctx, nil
+	}
+	t, err := a.tenants.Authenticate(ctx, key)
+	if err != nil {
+		return ctx, err
+	}
+	return tenant.NewContext(ctx, t), nil
+}
+
+// Middleware authenticates HTTP requests.
+func (a *Authenticator) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			key = r.Header.Get(judge0KeyHeader)
+		}
+		ctx, err := a.authenticate(r.Context(), key)
+		if errors.Is(err, tenant.ErrInvalidKey) {
+			writeUnauthorized(w, r, key)
+			return
+		}
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+			return
+		}
+		next(w, r.WithContext(ctx))
+	}
+}
+
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, key string) {
+	message := "Invalid API key"
+	if key == "" {
+		message = "Missing API key; send it in the " + APIKeyHeader + " header"
+	}
+	writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// grpcKey returns the API key in a gRPC call's x-api-key metadata.
+func grpcKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+func grpcAuthError(err error) error {
+	if errors.Is(err, tenant.ErrInvalidKey) {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// UnaryInterceptor authenticates unary gRPC calls by their x-api-key
+// metadata.
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx, grpcKey(ctx))
+		if err != nil {
+			return nil, grpcAuthError(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticatedStream overrides the context of a server stream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor authenticates streaming gRPC calls as
+// UnaryInterceptor does unary ones.
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), grpcKey(ss.Context()))
+		if err != nil {
+			return grpcAuthError(err)
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// UsageResponse is a tenant's quotas and its usage of them.
+type UsageResponse struct {
+	Tenant string        `json:"tenant"`
+	Quotas tenant.Quotas `json:"quotas"`
+	Usage  *tenant.Usage `json:"usage"`
+}
+
+// Usage handles GET /usage: the quotas and usage of the caller's tenant.
+func (h *Handler) Usage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	t, ok := tenant.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r, "")
+		return
+	}
+	usage, err := h.tenants.Usage(r.Context(), t.ID)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UsageResponse{Tenant: t.ID, Quotas: t.Quotas, Usage: usage})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/idempotency"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/submission"
+)
+
+// maxBatchSize bounds the submissions in one batch. The queue must also
+// have room for all of them at once.
+const maxBatchSize = 1000
+
+// BatchAccepted answers a batch submission. SubmissionIDs are in request
+// order.
+type BatchAccepted struct {
+	BatchID       string   `json:"batch_id"`
+	SubmissionIDs []string `json:"submission_ids"`
+}
+
+// SubmitBatch handles POST /submissions/batch. The body is an array of
+// execution requests, which are queued together or not at all and run
+// asynchronously like requests with a callback_url. It responds with the
+// batch ID and the submissions' IDs, in request order.
+func (h *Handler) SubmitBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var reqs []ExecutionRequest
+	if err := decode(w, r, &reqs, h.limits.MaxBatchBody); err != nil {
+		writeDecodeError(w, r, "Invalid request body: expected an array of execution requests", err)
+		return
+	}
+	if len(reqs) == 0 {
+		writeError(w, r, http.StatusBadRequest, CodeValidationFailed, "Batch is empty")
+		return
+	}
+	if len(reqs) > maxBatchSize {
+		writeError(w, r, http.StatusRequestEntityTooLarge, CodeTooLarge, fmt.Sprintf("Batch has %d submissions, more than the maximum of %d", len(reqs), maxBatchSize))
+		return
+	}
+
+	// Every job may wait for all the others to run first, one at a time,
+	// so each gets the whole batch's time
+	var timeout time.Duration
+	opts := make([]executor.ExecuteOptions, len(reqs))
+	for i, req := range reqs {
+		if req.Priority == "" {
+			reqs[i].Priority = queue.PriorityBatch
+		}
+		var err error
+		if opts[i], err = h.check(r, reqs[i], executor.ModeRun); err != nil {
+			err = prefixFields(err, fmt.Sprintf("[%d].", i))
+			writeValidationError(w, r, http.StatusBadRequest, fmt.Errorf("submissions[%d]: %w", i, err))
+			return
+		}
+		timeout += h.retry.Deadline(opts[i].Timeout())
+	}
+
+	batchID := submission.NewBatchID()
+	tenant := tenantOf(r)
+	items := make([]submission.Item, len(reqs))
+	ids := make([]string, len(reqs))
+	for i, req := range reqs {
+		ids[i] = queue.NewJobID()
+		items[i] = submission.Item{ID: ids[i], CallbackURL: req.CallbackURL}
+	}
+	if err := h.submissions.CreateBatch(r.Context(), batchID, tenant, items, time.Now().Add(timeout)); err != nil {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	// Requests seen before are answered from the dedupe cache, the rest
+	// are queued
+	type pending struct {
+		ctx         context.Context
+		cancel      context.CancelFunc
+		fingerprint string
+	}
+	var (
+		jobs   []*queue.Job
+		queued []pending
+		cached = make(map[string]*executor.ExecutionResult)
+	)
+	for i, req := range reqs {
+		fingerprint := idempotency.Fingerprint(opts[i])
+		if rec, err := h.results.Lookup(r.Context(), tenant, fingerprint); err == nil && rec != nil {
+			cached[ids[i]] = rec.Result
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		jobs = append(jobs, &queue.Job{
+			ID:      ids[i],
+			Options: opts[i],
+			Result:  make(chan *executor.ExecutionResult, 1),
+			Err:     make(chan error, 1),
+			Ctx:     ctx,
+
+			Priority: req.Priority,
+			Tenant:   tenant,
+			Tracked:  true,
+		})
+		queued = append(queued, pending{ctx, cancel, fingerprint})
+	}
+
+	if len(jobs) > 0 {
+		release, err := h.reserve(r.Context(), len(jobs))
+		if err == nil {
+			if err = h.queueManager.SubmitBatch(r.Context(), jobs); err != nil {
+				release()
+			}
+		}
+		if err != nil {
+			for _, p := range queued {
+				p.cancel()
+			}
+			cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cleanupCancel()
+			_ = h.submissions.DeleteBatch(cleanupCtx, batchID)
+			if errors.Is(err, queue.ErrBatchTooLarge) {
+				writeError(w, r, http.StatusRequestEntityTooLarge, CodeTooLarge, err.Error())
+				return
+			}
+			writeSubmitError(w, r, err)
+			return
+		}
+		for i, job := range jobs {
+			go h.remember(queued[i].ctx, queued[i].cancel, job, tenant, queued[i].fingerprint)
+		}
+	}
+
+	for id, result := range cached {
+		if _, err := h.submissions.Finish(r.Context(), id, result, nil); err != nil {
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", pathPrefix(r)+"/submissions/batch/"+batchID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(BatchAccepted{BatchID: batchID, SubmissionIDs: ids})
+}
+
+// GetBatch handles GET /submissions/batch/{id}: the batch's progress and
+// each submission's status and result.
+func (h *Handler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	batch, err := h.submissions.GetBatch(r.Context(), r.PathValue("id"), tenantOf(r))
+	if errors.Is(err, submission.ErrBatchNotFound) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(present(r, batch))
+}
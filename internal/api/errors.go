@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Error codes of the v1 error envelope. Clients should branch on the code
+// rather than the message, which may change.
+const (
+	CodeInvalidJSON          = "invalid_json"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeTooLarge             = "payload_too_large"
+	CodeRateLimited          = "rate_limited"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeQueueFull            = "queue_full"
+	CodeShuttingDown         = "shutting_down"
+	CodePaused               = "intake_paused"
+	CodeTimeout              = "timeout"
+	CodeInternal             = "internal_error"
+)
+
+// ErrorResponse is the body of every v1 error response.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes what went wrong. Details list the invalid fields of
+// a request that failed validation.
+type APIError struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail is a problem with one request field. Field is a path into
+// the request body, such as "time_limit" or "[2].language" in a batch.
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldError is a validation error of one request field. Its message is
+// the underlying error's, so that unversioned responses are unchanged.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldErrors flattens the FieldErrors in err, which may be wrapped or
+// joined.
+func fieldErrors(err error) []ErrorDetail {
+	switch e := err.(type) {
+	case *FieldError:
+		return []ErrorDetail{{Field: e.Field, Message: e.Err.Error()}}
+	case interface{ Unwrap() []error }:
+		var details []ErrorDetail
+		for _, inner := range e.Unwrap() {
+			details = append(details, fieldErrors(inner)...)
+		}
+		return details
+	}
+	if inner := errors.Unwrap(err); inner != nil {
+		return fieldErrors(inner)
+	}
+	return nil
+}
+
+// prefixFields prefixes the field paths of the FieldErrors in err, e.g.
+// with an item's index in a batch.
+func prefixFields(err error, prefix string) error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		prefixed := make([]error, len(errs))
+		for i, e := range errs {
+			prefixed[i] = prefixFields(e, prefix)
+		}
+		return errors.Join(prefixed...)
+	}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return &FieldError{Field: prefix + fieldErr.Field, Err: fieldErr.Err}
+	}
+	return &FieldError{Field: strings.TrimSuffix(prefix, "."), Err: err}
+}
+
+// writeError answers a request with an error: the v1 envelope for v1
+// requests, and plain text otherwise.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, details ...ErrorDetail) {
+	if !isV1(r) {
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{APIError{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}})
+}
+
+// writeValidationError reports an invalid request, listing the invalid
+// fields in the v1 envelope.
+func writeValidationError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	message := err.Error()
+	if details := fieldErrors(err); isV1(r) && len(details) > 0 {
+		message = "Request is invalid"
+		if len(details) == 1 {
+			message = details[0].Message
+		}
+		writeError(w, r, status, CodeValidationFailed, message, details...)
+		return
+	}
+	writeError(w, r, status, CodeValidationFailed, message)
+}
+
+// writeDecodeError reports a request body that is not the JSON expected,
+// naming the offending field where the decoder can.
+func writeDecodeError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeError(w, r, http.StatusRequestEntityTooLarge, CodeTooLarge, fmt.Sprintf("Request body is larger than the maximum of %d bytes", maxErr.Limit))
+		return
+	}
+	if field, ok := unknownField(err); ok {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidJSON, fmt.Sprintf("Unknown field %q", field), ErrorDetail{
+			Field:   field,
+			Message: "unknown field",
+		})
+		return
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidJSON, message, ErrorDetail{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be %s, not %s", jsonTypeName(typeErr.Type.Kind()), typeErr.Value),
+		})
+		return
+	}
+	writeError(w, r, http.StatusBadRequest, CodeInvalidJSON, message)
+}
+
+// unknownField returns the field named by the error a decoder rejecting
+// unknown fields returns, which has no type of its own.
+func unknownField(err error) (string, bool) {
+	quoted, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+	field, err := strconv.Unquote(quoted)
+	return field, err == nil
+}
+
+// jsonTypeName names a Go kind as its JSON type.
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	default:
+		return "a number"
+	}
+}
+
+// TooManyRequests answers a request the rate limiter rejected.
+func TooManyRequests(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusTooManyRequests, CodeRateLimited, "Too many requests")
+}
+
+// writeMethodNotAllowed answers a request with an unsupported method.
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+}
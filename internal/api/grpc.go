@@ -0,0 +1,398 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/apipb"
+	"github.com/itstheanurag/executioner/internal/clientip"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/idempotency"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/itstheanurag/executioner/internal/output"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/submission"
+	"github.com/itstheanurag/executioner/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// streamPollInterval is how often StreamSubmission checks whether a
+	// submission run outside this process has finished.
+	streamPollInterval = 500 * time.Millisecond
+	// outputChunkSize bounds the output in one stream event.
+	outputChunkSize = 32 * 1024
+)
+
+// RateLimitedMethods are the gRPC methods that queue jobs, and share the
+// rate limiter of /execute.
+var RateLimitedMethods = []string{
+	apipb.ExecutionService_Execute_FullMethodName,
+	apipb.ExecutionService_Submit_FullMethodName,
+}
+
+// GRPCService serves the gRPC API on top of Handler: requests are
+// validated, queued and tracked exactly as over HTTP.
+type GRPCService struct {
+	apipb.UnimplementedExecutionServiceServer
+
+	handler  *Handler
+	registry *languages.Registry
+	outputs  *output.Hub
+}
+
+func NewGRPCService(handler *Handler, registry *languages.Registry, outputs *output.Hub) *GRPCService {
+	return &GRPCService{
+		handler:  handler,
+		registry: registry,
+		outputs:  outputs,
+	}
+}
+
+// Register adds the service to a gRPC server.
+func (s *GRPCService) Register(server *grpc.Server) {
+	apipb.RegisterExecutionServiceServer(server, s)
+}
+
+// ClientAddr identifies a gRPC caller's address as tenantOf does an HTTP
+// client's: as the clientip interceptors resolved it, or else by the
+// peer's address.
+func ClientAddr(ctx context.Context) string {
+	if addr := clientip.FromContext(ctx); addr != "" {
+		return addr
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// callerOf identifies a gRPC caller as tenantOf does an HTTP client: by
+// its tenant, or else its address.
+func callerOf(ctx context.Context) string {
+	if t, ok := tenant.FromContext(ctx); ok {
+		return t.ID
+	}
+	return ClientAddr(ctx)
+}
+
+// prepare validates a request and picks its job ID.
+func (s *GRPCService) prepare(ctx context.Context, req *apipb.ExecuteRequest) (ExecutionRequest, executor.ExecuteOptions, string, error) {
+	r := ExecutionRequest{
+		Language:             req.GetLanguage(),
+		SourceCode:           string(req.GetSourceCode()),
+		Stdin:                string(req.GetStdin()),
+		TimeLimit:            int(req.GetTimeLimit()),
+		MemoryLimit:          int(req.GetMemoryLimit()),
+		CompileTimeLimit:     int(req.GetCompileTimeLimit()),
+		CompileMemoryLimit:   int(req.GetCompileMemoryLimit()),
+		CompilerOptions:      req.GetCompilerOptions(),
+		CommandLineArguments: req.GetCommandLineArguments(),
+		Priority:             req.GetPriority(),
+		CallbackURL:          req.GetCallbackUrl(),
+	}
+	opts, err := s.handler.validate(ctx, r, executor.ModeRun)
+	if err != nil {
+		return r, opts, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	jobID := req.GetSubmissionId()
+	if jobID == "" {
+		jobID = queue.NewJobID()
+	} else if !submissionIDPattern.MatchString(jobID) {
+		return r, opts, "", status.Error(codes.InvalidArgument, "invalid submission_id: must be 1-64 letters, digits, '-' or '_'")
+	}
+	return r, opts, jobID, nil
+}
+
+// Execute runs a request and waits for its result. The job is cancelled
+// if the call is.
+func (s *GRPCService) Execute(ctx context.Context, req *apipb.ExecuteRequest) (*apipb.ExecutionResult, error) {
+	if req.GetCallbackUrl() != "" {
+		return nil, status.Error(codes.InvalidArgument, "callback_url is only accepted by Submit")
+	}
+	r, opts, jobID, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	h := s.handler
+	caller := callerOf(ctx)
+	fingerprint := idempotency.Fingerprint(opts)
+	if rec, err := h.results.Lookup(ctx, caller, fingerprint); err == nil && rec != nil {
+		return resultToProto(rec.Result), nil
+	}
+
+	resultChan := make(chan *executor.ExecutionResult, 1)
+	errChan := make(chan error, 1)
+	jobCtx, cancel := context.WithTimeout(context.Background(), h.retry.Deadline(opts.Timeout()))
+	defer cancel()
+
+	job := &queue.Job{
+		ID:      jobID,
+		Options: opts,
+		Result:  resultChan,
+		Err:     errChan,
+		Ctx:     jobCtx,
+
+		Priority: r.Priority,
+		Tenant:   caller,
+	}
+	release, err := h.reserve(ctx, 1)
+	if err != nil {
+		return nil, submitStatus(err)
+	}
+	if err := h.queueManager.Submit(ctx, job); err != nil {
+		release()
+		return nil, submitStatus(err)
+	}
+
+	select {
+	case res := <-resultChan:
+		h.finish(caller, "", fingerprint, jobID, res)
+		return resultToProto(res), nil
+	case err := <-errChan:
+		if errors.Is(err, queue.ErrShuttingDown) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	case <-jobCtx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "execution timed out")
+	case <-ctx.Done():
+		// Nobody is waiting for the result any more
+		h.cancel(jobID)
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// Submit queues a request and returns its submission ID at once.
+func (s *GRPCService) Submit(ctx context.Context, req *apipb.ExecuteRequest) (*apipb.SubmitResponse, error) {
+	r, opts, jobID, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	h := s.handler
+	caller := callerOf(ctx)
+	fingerprint := idempotency.Fingerprint(opts)
+	a, err := h.accept(ctx, opts, r.Priority, r.CallbackURL, jobID, caller, fingerprint)
+	if err != nil {
+		return nil, submitStatus(err)
+	}
+	if a.job != nil {
+		go h.remember(a.ctx, a.cancel, a.job, caller, fingerprint)
+	}
+	return &apipb.SubmitResponse{SubmissionId: jobID, Status: submission.StatusQueued}, nil
+}
+
+// GetSubmission returns a submission of the caller's.
+func (s *GRPCService) GetSubmission(ctx context.Context, req *apipb.GetSubmissionRequest) (*apipb.Submission, error) {
+	sub, err := s.getSubmission(ctx, req.GetSubmissionId())
+	if err != nil {
+		return nil, err
+	}
+	return submissionToProto(sub), nil
+}
+
+func (s *GRPCService) getSubmission(ctx context.Context, id string) (*submission.Submission, error) {
+	sub, err := s.handler.submissions.Get(ctx, id, callerOf(ctx))
+	if errors.Is(err, submission.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return sub, nil
+}
+
+// StreamSubmission sends a submission's status, its output and finally
+// the finished submission. The output of a job run by this server's local
+// workers is sent as the program writes it. Jobs run by remote nodes or
+// other servers are polled for, and their output is sent once they finish.
+func (s *GRPCService) StreamSubmission(req *apipb.GetSubmissionRequest, stream apipb.ExecutionService_StreamSubmissionServer) error {
+	ctx := stream.Context()
+	sub, err := s.getSubmission(ctx, req.GetSubmissionId())
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&apipb.SubmissionEvent{Event: &apipb.SubmissionEvent_Status{Status: sub.Status}}); err != nil {
+		return err
+	}
+
+	// streamed counts the bytes sent of each stream, by Output_Stream
+	var streamed [2]int
+	if sub.Status == submission.StatusQueued {
+		follower := s.outputs.Follow(sub.ID)
+		defer follower.Close()
+		poll := time.NewTicker(streamPollInterval)
+		defer poll.Stop()
+
+		for sub.Status == submission.StatusQueued {
+			check := false
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case <-follower.Wait():
+			case <-poll.C:
+				check = true
+			}
+
+			chunks, restarted, finished := follower.Read()
+			if restarted {
+				// A retry starts its output over; what the failed attempt
+				// wrote has been sent but is not part of the result
+				streamed = [2]int{}
+			}
+			for _, chunk := range chunks {
+				which := apipb.Output_STDOUT
+				if chunk.Stderr {
+					which = apipb.Output_STDERR
+				}
+				if err := sendOutput(stream, which, chunk.Data); err != nil {
+					return err
+				}
+				streamed[which] += len(chunk.Data)
+			}
+			if finished || check {
+				if sub, err = s.getSubmission(ctx, sub.ID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Whatever was not streamed, such as compiler output or the output of
+	// a job run elsewhere, is sent from the result
+	finished := submissionToProto(sub)
+	if res := finished.GetResult(); res != nil {
+		if err := sendOutput(stream, apipb.Output_STDOUT, unsent(res.Stdout, streamed[apipb.Output_STDOUT])); err != nil {
+			return err
+		}
+		if err := sendOutput(stream, apipb.Output_STDERR, unsent(res.Stderr, streamed[apipb.Output_STDERR])); err != nil {
+			return err
+		}
+		res.Stdout, res.Stderr = nil, nil
+	}
+	return stream.Send(&apipb.SubmissionEvent{Event: &apipb.SubmissionEvent_Finished{Finished: finished}})
+}
+
+// unsent returns the part of output past the n bytes already sent.
+func unsent(output []byte, n int) []byte {
+	if n >= len(output) {
+		return nil
+	}
+	return output[n:]
+}
+
+func sendOutput(stream apipb.ExecutionService_StreamSubmissionServer, which apipb.Output_Stream, output []byte) error {
+	for len(output) > 0 {
+		n := min(len(output), outputChunkSize)
+		event := &apipb.SubmissionEvent{Event: &apipb.SubmissionEvent_Output{Output: &apipb.Output{
+			Stream: which,
+			Data:   output[:n],
+		}}}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+		output = output[n:]
+	}
+	return nil
+}
+
+// ListLanguages lists the registered languages that are not disabled, by
+// ID.
+func (s *GRPCService) ListLanguages(ctx context.Context, req *apipb.ListLanguagesRequest) (*apipb.ListLanguagesResponse, error) {
+	langs := s.registry.Available()
+	slices.SortFunc(langs, func(a, b languages.Language) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	out := make([]*apipb.Language, len(langs))
+	for i, lang := range langs {
+		out[i] = &apipb.Language{
+			Id:       lang.ID,
+			Name:     lang.Name,
+			Compiled: lang.Config.Compiled(),
+		}
+	}
+	return &apipb.ListLanguagesResponse{Languages: out}, nil
+}
+
+// submitStatus maps the errors of accepting a job, as writeSubmitError
+// does for HTTP.
+func submitStatus(err error) error {
+	var (
+		fullErr  *queue.FullError
+		quotaErr *tenant.QuotaError
+	)
+	switch {
+	case errors.As(err, &fullErr), errors.As(err, &quotaErr):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, queue.ErrShuttingDown), errors.Is(err, queue.ErrPaused):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, queue.ErrDuplicateJob), errors.Is(err, submission.ErrDuplicate):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func resultToProto(res *executor.ExecutionResult) *apipb.ExecutionResult {
+	diags := make([]*apipb.Diagnostic, len(res.Diagnostics))
+	for i, d := range res.Diagnostics {
+		diags[i] = &apipb.Diagnostic{
+			File:     d.File,
+			Line:     int32(d.Line),
+			Column:   int32(d.Column),
+			Severity: d.Severity,
+			Message:  d.Message,
+		}
+	}
+	return &apipb.ExecutionResult{
+		Status:         res.Status,
+		Stdout:         []byte(res.Stdout),
+		Stderr:         []byte(res.Stderr),
+		ExitCode:       int32(res.ExitCode),
+		TimeMs:         res.TimeMs,
+		MemoryKb:       res.MemoryKb,
+		ErrorType:      res.ErrorType,
+		Diagnostics:    diags,
+		CompileCommand: res.CompileCommand,
+		RunCommand:     res.RunCommand,
+	}
+}
+
+func submissionToProto(sub *submission.Submission) *apipb.Submission {
+	out := &apipb.Submission{
+		SubmissionId: sub.ID,
+		BatchId:      sub.BatchID,
+		Status:       sub.Status,
+		Error:        sub.Error,
+		CreatedAtMs:  sub.CreatedAt.UnixMilli(),
+	}
+	if sub.Result != nil {
+		out.Result = resultToProto(sub.Result)
+	}
+	if sub.FinishedAt != nil {
+		out.FinishedAtMs = sub.FinishedAt.UnixMilli()
+	}
+	if sub.Callback != nil {
+		out.CallbackUrl = sub.Callback.URL
+		out.CallbackState = sub.Callback.State
+	}
+	return out
+}
@@ -2,12 +2,24 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"time"
 
+	"github.com/itstheanurag/executioner/internal/clientip"
 	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/idempotency"
 	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/submission"
+	"github.com/itstheanurag/executioner/internal/tenant"
 )
 
 type ExecutionRequest struct {
@@ -16,69 +28,666 @@ type ExecutionRequest struct {
 	Stdin       string `json:"stdin"`
 	TimeLimit   int    `json:"time_limit"`   // in seconds
 	MemoryLimit int    `json:"memory_limit"` // in MB
+
+	CompileTimeLimit   int `json:"compile_time_limit"`   // in seconds
+	CompileMemoryLimit int `json:"compile_memory_limit"` // in MB
+
+	CompilerOptions      []string `json:"compiler_options"`
+	CommandLineArguments []string `json:"command_line_arguments"`
+
+	// Priority is "interactive", "submission" (default) or "batch". Lower
+	// classes get a smaller share of the workers while the queue is busy.
+	Priority string `json:"priority"`
+
+	// CallbackURL makes the submission asynchronous: the request returns
+	// at once and the result is POSTed here when the job finishes.
+	CallbackURL string `json:"callback_url"`
+}
+
+// CompileRequest is accepted by /compile. Stdin, run limits and program
+// arguments are ignored; SyntaxOnly selects the language's syntax check
+// instead of a full compile.
+type CompileRequest struct {
+	ExecutionRequest
+	SyntaxOnly bool `json:"syntax_only"`
+}
+
+// Accepted answers a submission made with a callback.
+type Accepted struct {
+	SubmissionID string `json:"submission_id"`
+	Status       string `json:"status"`
+}
+
+// Cancelled answers a cancellation.
+type Cancelled struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// QueueStatus is the queue's estimated wait for a job submitted now.
+type QueueStatus struct {
+	EstimatedWaitMs int64 `json:"estimated_wait_ms"`
 }
 
+// SubmissionIDHeader carries a job's ID. Clients may set it on a request to
+// choose the ID up front, so a synchronous call can be cancelled from
+// another connection; it is always set on the response.
+const SubmissionIDHeader = "X-Submission-ID"
+
+var submissionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// maxCallbackURLLength bounds callback_url.
+const maxCallbackURLLength = 2048
+
 type Handler struct {
-	queueManager *queue.Manager
+	queueManager queue.Queue
+	executor     *executor.Executor
+	results      *idempotency.Store
+	submissions  *submission.Store
+	tenants      *tenant.Store
+	retry        queue.RetryPolicy
+	limits       Limits
+	// callbacks is false while no webhook secret is configured
+	callbacks bool
 }
 
-func NewHandler(manager *queue.Manager) *Handler {
+func NewHandler(manager queue.Queue, exec *executor.Executor, results *idempotency.Store, submissions *submission.Store, tenants *tenant.Store, retry queue.RetryPolicy, limits Limits, callbacks bool) *Handler {
 	return &Handler{
 		queueManager: manager,
+		executor:     exec,
+		results:      results,
+		submissions:  submissions,
+		tenants:      tenants,
+		retry:        retry,
+		limits:       limits,
+		callbacks:    callbacks,
 	}
 }
 
 func (h *Handler) Execute(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	var req ExecutionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decode(w, r, &req, h.limits.MaxBody); err != nil {
+		writeDecodeError(w, r, "Invalid request body", err)
 		return
 	}
 
-	// Default limits
-	if req.TimeLimit == 0 {
-		req.TimeLimit = 2
+	h.run(w, r, req, executor.ModeRun)
+}
+
+// Compile runs only the compile phase, or a syntax check, and returns the
+// compiler output without executing the program.
+func (h *Handler) Compile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
 	}
-	if req.MemoryLimit == 0 {
-		req.MemoryLimit = 256
+
+	var req CompileRequest
+	if err := decode(w, r, &req, h.limits.MaxBody); err != nil {
+		writeDecodeError(w, r, "Invalid request body", err)
+		return
+	}
+
+	mode := executor.ModeCompile
+	if req.SyntaxOnly {
+		mode = executor.ModeCheck
+	}
+	req.Stdin = ""
+	req.CommandLineArguments = nil
+	req.TimeLimit = 0
+	req.MemoryLimit = 0
+
+	h.run(w, r, req.ExecutionRequest, mode)
+}
+
+func (h *Handler) run(w http.ResponseWriter, r *http.Request, req ExecutionRequest, mode string) {
+	opts, err := h.check(r, req, mode)
+	if err != nil {
+		writeValidationError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if req.CallbackURL != "" && r.Header.Get(idempotency.Header) != "" {
+		writeError(w, r, http.StatusBadRequest, CodeValidationFailed, idempotency.Header+" cannot be combined with callback_url; set "+SubmissionIDHeader+" to make the submission safe to retry")
+		return
+	}
+
+	jobID := r.Header.Get(SubmissionIDHeader)
+	if jobID == "" {
+		jobID = queue.NewJobID()
+	} else if !submissionIDPattern.MatchString(jobID) {
+		writeError(w, r, http.StatusBadRequest, CodeValidationFailed, "Invalid "+SubmissionIDHeader+": must be 1-64 letters, digits, '-' or '_'")
+		return
+	}
+	w.Header().Set(SubmissionIDHeader, jobID)
+
+	tenant := tenantOf(r)
+	fingerprint := idempotency.Fingerprint(opts)
+	if req.CallbackURL != "" {
+		h.submitAsync(w, r, req, opts, jobID, tenant, fingerprint)
+		return
+	}
+
+	key := r.Header.Get(idempotency.Header)
+	if key != "" {
+		done, release := h.claim(w, r, tenant, key, fingerprint, jobID, h.retry.Deadline(opts.Timeout()))
+		if done {
+			return
+		}
+		defer release()
+	}
+	if rec, err := h.results.Lookup(r.Context(), tenant, fingerprint); err == nil && rec != nil {
+		h.finish(tenant, key, "", "", rec.Result)
+		writeReplay(w, r, rec)
+		return
 	}
 
-	jobID := "job-" + time.Now().Format("150405.000000")
 	resultChan := make(chan *executor.ExecutionResult, 1)
 	errChan := make(chan error, 1)
 
-	// Create context with timeout for the job
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(req.TimeLimit+1)*time.Second)
+	// Create context with timeout for the job, covering both phases of
+	// every attempt the retry policy allows
+	ctx, cancel := context.WithTimeout(context.Background(), h.retry.Deadline(opts.Timeout()))
 	defer cancel()
 
 	job := &queue.Job{
-		ID: jobID,
-		Options: executor.ExecuteOptions{
-			LanguageID:    req.Language,
-			SourceCode:    req.SourceCode,
-			Stdin:         req.Stdin,
-			TimeLimitMs:   req.TimeLimit * 1000,
-			MemoryLimitKb: req.MemoryLimit * 1024,
-		},
-		Result: resultChan,
-		Err:    errChan,
-		Ctx:    ctx,
+		ID:      jobID,
+		Options: opts,
+		Result:  resultChan,
+		Err:     errChan,
+		Ctx:     ctx,
+
+		Priority: req.Priority,
+		Tenant:   tenant,
 	}
 
-	h.queueManager.Submit(job)
+	// Submission gives up when the client does; the job itself is bounded
+	// by ctx alone
+	release, err := h.reserve(r.Context(), 1)
+	if err != nil {
+		writeSubmitError(w, r, err)
+		return
+	}
+	if err := h.queueManager.Submit(r.Context(), job); err != nil {
+		release()
+		writeSubmitError(w, r, err)
+		return
+	}
 
 	select {
 	case res := <-resultChan:
+		h.finish(tenant, key, fingerprint, jobID, res)
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(res)
+		json.NewEncoder(w).Encode(present(r, res))
 	case err := <-errChan:
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, queue.ErrShuttingDown) {
+			writeShuttingDown(w, r)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+	case <-ctx.Done():
+		writeError(w, r, http.StatusGatewayTimeout, CodeTimeout, "Execution timed out")
+	case <-r.Context().Done():
+		// Nobody is waiting for the result any more
+		h.cancel(jobID)
+	}
+}
+
+// validate resolves a request's limits and checks its options. Errors are
+// the client's, to be reported as 400 Bad Request.
+func (h *Handler) validate(ctx context.Context, req ExecutionRequest, mode string) (executor.ExecuteOptions, error) {
+	opts, err := h.resolve(ctx, executor.ExecuteOptions{
+		LanguageID:           req.Language,
+		SourceCode:           req.SourceCode,
+		Stdin:                req.Stdin,
+		TimeLimitMs:          req.TimeLimit * 1000,
+		MemoryLimitKb:        req.MemoryLimit * 1024,
+		CompileTimeLimitMs:   req.CompileTimeLimit * 1000,
+		CompileMemoryLimitKb: req.CompileMemoryLimit * 1024,
+		CompilerOptions:      req.CompilerOptions,
+		Args:                 req.CommandLineArguments,
+		Mode:                 mode,
+	})
+	if err != nil {
+		return opts, requestFieldError(err)
+	}
+
+	if err := queue.ValidPriority(req.Priority); err != nil {
+		return opts, &FieldError{Field: "priority", Err: err}
+	}
+
+	if req.CallbackURL != "" {
+		if !h.callbacks {
+			return opts, &FieldError{Field: "callback_url", Err: errors.New("callbacks are not enabled on this server")}
+		}
+		if err := validCallbackURL(req.CallbackURL); err != nil {
+			return opts, &FieldError{Field: "callback_url", Err: fmt.Errorf("invalid callback_url: %w", err)}
+		}
+	}
+	return opts, nil
+}
+
+// check validates a request as the API version it was made to expects.
+// v1 requests must also have source code, which is reported together with
+// a missing or unknown language; the unversioned API reports missing
+// source code in the execution result. With base64_encoded=true, the
+// source code and stdin are decoded first.
+func (h *Handler) check(r *http.Request, req ExecutionRequest, mode string) (executor.ExecuteOptions, error) {
+	if isV1(r) {
+		var errs []error
+		if base64Encoded(r) {
+			fields := []struct {
+				name  string
+				value *string
+			}{
+				{"source_code", &req.SourceCode},
+				{"stdin", &req.Stdin},
+			}
+			for _, field := range fields {
+				decoded, err := base64.StdEncoding.DecodeString(*field.value)
+				if err != nil {
+					errs = append(errs, &FieldError{Field: field.name, Err: fmt.Errorf("%s is not valid base64", field.name)})
+					continue
+				}
+				*field.value = string(decoded)
+			}
+		}
+		if req.Language == "" {
+			errs = append(errs, &FieldError{Field: "language", Err: errors.New("language is required")})
+		} else if !h.executor.Supports(req.Language) {
+			errs = append(errs, &FieldError{Field: "language", Err: fmt.Errorf("unknown language %q", req.Language)})
+		}
+		if req.SourceCode == "" {
+			errs = append(errs, &FieldError{Field: "source_code", Err: errors.New("source_code is required")})
+		}
+		if len(errs) > 0 {
+			return executor.ExecuteOptions{}, errors.Join(errs...)
+		}
+	}
+	return h.validate(r.Context(), req, mode)
+}
+
+// limitFields maps the executor's limit names to request fields.
+var limitFields = map[string]string{
+	"time_limit_ms":           "time_limit",
+	"memory_limit_kb":         "memory_limit",
+	"compile_time_limit_ms":   "compile_time_limit",
+	"compile_memory_limit_kb": "compile_memory_limit",
+}
+
+// argumentFields maps the executor's option names to request fields. The
+// mode is rejected for languages without a compile step.
+var argumentFields = map[string]string{
+	"compiler_options": "compiler_options",
+	"args":             "command_line_arguments",
+	"mode":             "language",
+}
+
+// requestFieldError tags an error from resolve with the request field it
+// is about.
+func requestFieldError(err error) error {
+	var limitErr *executor.LimitError
+	if errors.As(err, &limitErr) {
+		return &FieldError{Field: limitFields[limitErr.Field], Err: err}
+	}
+	var argErr *executor.ArgumentError
+	if errors.As(err, &argErr) {
+		return &FieldError{Field: argumentFields[argErr.Field], Err: err}
+	}
+	var deniedErr *tenant.DeniedError
+	if errors.As(err, &deniedErr) {
+		field, ok := limitFields[deniedErr.Field]
+		if !ok {
+			field = deniedErr.Field
+		}
+		return &FieldError{Field: field, Err: err}
+	}
+	return err
+}
+
+// resolve fills in the default limits of opts and checks its limits,
+// compiler flags and arguments against the language, and against the
+// per-request quotas of the tenant in ctx. Unknown languages, languages
+// disabled by an operator, and source code and stdin over the size limits
+// are refused.
+func (h *Handler) resolve(ctx context.Context, opts executor.ExecuteOptions) (executor.ExecuteOptions, error) {
+	switch {
+	case opts.LanguageID == "":
+		return opts, &FieldError{Field: "language", Err: errors.New("language is required")}
+	case !h.executor.Supports(opts.LanguageID):
+		return opts, &FieldError{Field: "language", Err: fmt.Errorf("unknown language %q", opts.LanguageID)}
+	case h.executor.Disabled(opts.LanguageID):
+		return opts, &FieldError{Field: "language", Err: fmt.Errorf("language %q is disabled", opts.LanguageID)}
+	}
+	if err := checkSize("source_code", opts.SourceCode, h.limits.MaxSource); err != nil {
+		return opts, err
+	}
+	if err := checkSize("stdin", opts.Stdin, h.limits.MaxStdin); err != nil {
+		return opts, err
+	}
+
+	t, metered := tenant.FromContext(ctx)
+	if metered {
+		if err := t.Quotas.Permit(opts); err != nil {
+			return opts, err
+		}
+	}
+
+	// Zero limits are filled from the language defaults
+	opts, err := h.executor.ResolveLimits(opts)
+	var limitErr *executor.LimitError
+	if errors.As(err, &limitErr) {
+		return opts, limitErr
+	}
+	if metered {
+		t.Quotas.Clamp(&opts)
+	}
+
+	var argErr *executor.ArgumentError
+	if _, _, err := h.executor.Commands(opts); errors.As(err, &argErr) {
+		return opts, argErr
+	}
+	return opts, nil
+}
+
+// submitAsync queues a submission with a callback and responds with its
+// ID straight away. The worker that finishes the job records its outcome,
+// and the webhook sender delivers it.
+func (h *Handler) submitAsync(w http.ResponseWriter, r *http.Request, req ExecutionRequest, opts executor.ExecuteOptions, jobID, tenant, fingerprint string) {
+	a, err := h.accept(r.Context(), opts, req.Priority, req.CallbackURL, jobID, tenant, fingerprint)
+	if err != nil {
+		writeSubmitError(w, r, err)
+		return
+	}
+	if a.job != nil {
+		go h.remember(a.ctx, a.cancel, a.job, tenant, fingerprint)
+	}
+	writeAccepted(w, r, jobID)
+}
+
+// accepted is an asynchronous submission that has been recorded, and
+// either queued as job or finished with a cached result.
+type accepted struct {
+	job    *queue.Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	cached *executor.ExecutionResult
+}
+
+// accept records an asynchronous submission and queues its job, unless
+// the dedupe cache has its result. A caller that does not wait for the
+// job itself hands it to remember. Errors are reported with
+// writeSubmitError.
+func (h *Handler) accept(ctx context.Context, opts executor.ExecuteOptions, priority, callbackURL, jobID, tenant, fingerprint string) (*accepted, error) {
+	timeout := h.retry.Deadline(opts.Timeout())
+	if err := h.submissions.Create(ctx, jobID, tenant, callbackURL, time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	if rec, err := h.results.Lookup(ctx, tenant, fingerprint); err == nil && rec != nil {
+		if _, err := h.submissions.Finish(ctx, jobID, rec.Result, nil); err != nil {
+			return nil, err
+		}
+		return &accepted{cached: rec.Result}, nil
+	}
+
+	resultChan := make(chan *executor.ExecutionResult, 1)
+	errChan := make(chan error, 1)
+	jobCtx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	job := &queue.Job{
+		ID:      jobID,
+		Options: opts,
+		Result:  resultChan,
+		Err:     errChan,
+		Ctx:     jobCtx,
+
+		Priority: priority,
+		Tenant:   tenant,
+		Tracked:  true,
+	}
+
+	release, err := h.reserve(ctx, 1)
+	if err == nil {
+		if err = h.queueManager.Submit(ctx, job); err != nil {
+			release()
+		}
+	}
+	if err != nil {
+		cancel()
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cleanupCancel()
+		_ = h.submissions.Delete(cleanupCtx, jobID)
+		return nil, err
+	}
+	return &accepted{job: job, ctx: jobCtx, cancel: cancel}, nil
+}
+
+// reserve counts n executions against the quota of the tenant in ctx, if
+// any, before their jobs are queued. release gives them back, for jobs
+// that could not be.
+func (h *Handler) reserve(ctx context.Context, n int) (release func(), err error) {
+	t, ok := tenant.FromContext(ctx)
+	if !ok {
+		return func() {}, nil
+	}
+	reservation, err := h.tenants.Reserve(ctx, t, n)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = h.tenants.Release(releaseCtx, reservation)
+	}, nil
+}
+
+// remember waits for a job nobody else waits on, to put its result in the
+// dedupe cache, and then releases its context.
+func (h *Handler) remember(ctx context.Context, cancel context.CancelFunc, job *queue.Job, tenant, fingerprint string) {
+	defer cancel()
+	select {
+	case res := <-job.Result:
+		h.finish(tenant, "", fingerprint, job.ID, res)
+	case <-job.Err:
 	case <-ctx.Done():
-		http.Error(w, "Execution timed out", http.StatusGatewayTimeout)
 	}
 }
+
+// GetSubmission handles GET /submissions/{id} for submissions made with a
+// callback: their status, result once finished, and callback delivery
+// attempts.
+func (h *Handler) GetSubmission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	sub, err := h.submissions.Get(r.Context(), r.PathValue("id"), tenantOf(r))
+	if errors.Is(err, submission.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(present(r, sub))
+}
+
+// Submission routes /submissions/{id} by method.
+func (h *Handler) Submission(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetSubmission(w, r)
+		return
+	}
+	h.CancelSubmission(w, r)
+}
+
+// CancelSubmission handles DELETE /submissions/{id}. A queued job is
+// dropped; a running one has its container killed. Either way the job
+// finishes with status "cancelled", and so does its submission if it was
+// made with a callback. Jobs of other tenants are reported as not found.
+func (h *Handler) CancelSubmission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	id := r.PathValue("id")
+	err := h.cancelJob(r.Context(), id, tenantOf(r))
+	if errors.Is(err, queue.ErrJobNotFound) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Submission not found or already finished")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Cancelled{ID: id, Status: executor.StatusCancelled})
+}
+
+// cancelJob cancels a queued or running job submitted by tenant, or by
+// anyone if tenant is empty, and finishes its submission as cancelled if
+// it has one.
+func (h *Handler) cancelJob(ctx context.Context, id, tenant string) error {
+	if err := h.queueManager.Cancel(ctx, id, tenant); err != nil {
+		return err
+	}
+
+	// A running job's worker may get there first; this covers queued jobs,
+	// which never reach a worker
+	_, err := h.submissions.Finish(ctx, id, queue.CancelledResult(), nil)
+	return err
+}
+
+func (h *Handler) cancel(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = h.queueManager.Cancel(ctx, jobID, "")
+}
+
+// QueueStatus reports the queue's estimated wait so clients can back off
+// before submitting.
+func (h *Handler) QueueStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(QueueStatus{EstimatedWaitMs: h.queueManager.EstimatedWait().Milliseconds()})
+}
+
+// validCallbackURL accepts absolute http and https URLs.
+func validCallbackURL(raw string) error {
+	if len(raw) > maxCallbackURLLength {
+		return errors.New("too long")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return errors.New("must not contain credentials")
+	}
+	return nil
+}
+
+// tenantOf identifies the submitter for fair scheduling and submission
+// ownership: by its tenant if it sent an API key, or else by its client
+// address as the clientip middleware resolved it.
+func tenantOf(r *http.Request) string {
+	if t, ok := tenant.FromContext(r.Context()); ok {
+		return t.ID
+	}
+	if addr := clientip.FromContext(r.Context()); addr != "" {
+		return addr
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeQueueFull(w http.ResponseWriter, r *http.Request, err *queue.FullError) {
+	retryAfter := int(math.Ceil(err.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	if isV1(r) {
+		writeError(w, r, http.StatusServiceUnavailable, CodeQueueFull, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error":             err.Error(),
+		"estimated_wait_ms": err.RetryAfter.Milliseconds(),
+	})
+}
+
+// writeSubmitError reports a job the queue did not accept.
+func writeSubmitError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		fullErr  *queue.FullError
+		quotaErr *tenant.QuotaError
+	)
+	switch {
+	case errors.As(err, &fullErr):
+		writeQueueFull(w, r, fullErr)
+	case errors.As(err, &quotaErr):
+		writeQuotaExceeded(w, r, quotaErr)
+	case errors.Is(err, queue.ErrShuttingDown):
+		writeShuttingDown(w, r)
+	case errors.Is(err, queue.ErrPaused):
+		writePaused(w, r)
+	case errors.Is(err, queue.ErrDuplicateJob), errors.Is(err, submission.ErrDuplicate):
+		writeError(w, r, http.StatusConflict, CodeConflict, err.Error())
+	case r.Context().Err() != nil:
+		// Client went away; there is no one to respond to
+	default:
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+	}
+}
+
+// writeAccepted answers a submission made with a callback.
+func writeAccepted(w http.ResponseWriter, r *http.Request, id string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", pathPrefix(r)+"/submissions/"+id)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(Accepted{SubmissionID: id, Status: submission.StatusQueued})
+}
+
+// writeShuttingDown tells the client to resubmit, so that a load balancer
+// can route the retry to another instance.
+func writeShuttingDown(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", "1")
+	writeError(w, r, http.StatusServiceUnavailable, CodeShuttingDown, queue.ErrShuttingDown.Error())
+}
+
+// writePaused turns jobs away while an operator has paused intake. How
+// long that lasts is unknown, so the client is asked to retry in a while.
+func writePaused(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", "30")
+	writeError(w, r, http.StatusServiceUnavailable, CodePaused, "Job intake is paused; retry later")
+}
+
+// writeQuotaExceeded tells the client when its quota resets.
+func writeQuotaExceeded(w http.ResponseWriter, r *http.Request, err *tenant.QuotaError) {
+	retryAfter := int(math.Ceil(time.Until(err.ResetsAt).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	writeError(w, r, http.StatusTooManyRequests, CodeQuotaExceeded, err.Error())
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/idempotency"
+)
+
+// ReplayedHeader is set on responses served from an earlier job's result.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header.
+const maxIdempotencyKeyLength = 255
+
+// claim reserves an idempotency key for a new job. If done is true the
+// response has already been written, either with the earlier result or an
+// error. Otherwise the caller runs the job and must call release when it
+// returns; release frees the key unless finish stored a result for it.
+func (h *Handler) claim(w http.ResponseWriter, r *http.Request, tenant, key, fingerprint, jobID string, timeout time.Duration) (done bool, release func()) {
+	if len(key) > maxIdempotencyKeyLength {
+		writeError(w, r, http.StatusBadRequest, CodeValidationFailed, "Invalid "+idempotency.Header+": too long")
+		return true, nil
+	}
+
+	rec, err := h.results.Claim(r.Context(), tenant, key, fingerprint, jobID, timeout)
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused):
+		writeError(w, r, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused, err.Error())
+		return true, nil
+	case errors.Is(err, idempotency.ErrInProgress):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, http.StatusConflict, CodeConflict, err.Error())
+		return true, nil
+	case err != nil:
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, err.Error())
+		return true, nil
+	case rec != nil:
+		writeReplay(w, r, rec)
+		return true, nil
+	}
+
+	return false, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = h.results.Release(ctx, tenant, key)
+	}
+}
+
+// finish records a job's result against its idempotency key, if any, and
+// in the dedupe cache. A cancelled job is not recorded, so a retry with the
+// same key runs it again. Failures only cost a rerun later, so they are
+// ignored.
+func (h *Handler) finish(tenant, key, fingerprint, jobID string, res *executor.ExecutionResult) {
+	if res.Status == executor.StatusCancelled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if key != "" {
+		_ = h.results.Complete(ctx, tenant, key, res)
+	}
+	if fingerprint != "" {
+		_ = h.results.Remember(ctx, tenant, fingerprint, jobID, res)
+	}
+}
+
+func writeReplay(w http.ResponseWriter, r *http.Request, rec *idempotency.Record) {
+	w.Header().Set(SubmissionIDHeader, rec.JobID)
+	w.Header().Set(ReplayedHeader, "true")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(present(r, rec.Result))
+}
@@ -0,0 +1,391 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/idempotency"
+	"github.com/itstheanurag/executioner/internal/judge0"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/submission"
+)
+
+// Judge0Request is a Judge0 submission request. Limits are in seconds and
+// kilobytes, as in Judge0, and options and arguments are single strings
+// split on whitespace.
+type Judge0Request struct {
+	SourceCode           string  `json:"source_code"`
+	LanguageID           int     `json:"language_id"`
+	CompilerOptions      string  `json:"compiler_options"`
+	CommandLineArguments string  `json:"command_line_arguments"`
+	Stdin                string  `json:"stdin"`
+	ExpectedOutput       *string `json:"expected_output"`
+	CPUTimeLimit         float64 `json:"cpu_time_limit"`
+	WallTimeLimit        float64 `json:"wall_time_limit"`
+	MemoryLimit          float64 `json:"memory_limit"`
+	CallbackURL          string  `json:"callback_url"`
+}
+
+// Judge0Handler serves the core of the Judge0 CE REST API on top of
+// Handler, so clients written for Judge0 can use executioner unchanged.
+// A Judge0 submission is an asynchronous submission; its token is the
+// submission ID.
+type Judge0Handler struct {
+	handler  *Handler
+	registry *languages.Registry
+	requests *judge0.Store
+}
+
+func NewJudge0Handler(handler *Handler, registry *languages.Registry, requests *judge0.Store) *Judge0Handler {
+	return &Judge0Handler{
+		handler:  handler,
+		registry: registry,
+		requests: requests,
+	}
+}
+
+// Submissions handles POST /judge0/submissions. It responds with the token
+// alone, or with wait=true, with the finished submission. base64_encoded
+// and fields apply as in Judge0. A callback_url is sent the signed
+// submission.finished event of the webhook sender, whose submission_id is
+// the token.
+func (j *Judge0Handler) Submissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Unknown fields are ignored rather than rejected: Judge0 clients send
+	// many that executioner does not implement
+	var req Judge0Request
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, j.handler.limits.MaxBody)).Decode(&req)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, fmt.Sprintf("Request body is larger than the maximum of %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	encoded := r.URL.Query().Get("base64_encoded") == "true"
+	if encoded {
+		if err := decodeJudge0Request(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	opts, err := j.resolve(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	h := j.handler
+	token := queue.NewJobID()
+	tenant := tenantOf(r)
+	fingerprint := idempotency.Fingerprint(opts)
+	saved := judge0.Request{
+		Token:          token,
+		LanguageID:     req.LanguageID,
+		SourceCode:     req.SourceCode,
+		Stdin:          req.Stdin,
+		ExpectedOutput: req.ExpectedOutput,
+	}
+	if err := j.requests.Save(r.Context(), saved); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// A client that waits is served like a synchronous request
+	wait := r.URL.Query().Get("wait") == "true"
+	priority := queue.PrioritySubmission
+	if wait {
+		priority = queue.PriorityInteractive
+	}
+
+	createdAt := time.Now()
+	a, err := h.accept(r.Context(), opts, priority, req.CallbackURL, token, tenant, fingerprint)
+	if err != nil {
+		// The saved request is left for cleanup
+		writeSubmitError(w, r, err)
+		return
+	}
+
+	if !wait {
+		if a.job != nil {
+			go h.remember(a.ctx, a.cancel, a.job, tenant, fingerprint)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"token": token})
+		return
+	}
+
+	sub := &submission.Submission{ID: token, Status: submission.StatusQueued, CreatedAt: createdAt}
+	result := a.cached
+	if a.job != nil {
+		select {
+		case result = <-a.job.Result:
+			h.finish(tenant, "", fingerprint, token, result)
+		case err := <-a.job.Err:
+			now := time.Now()
+			sub.Status, sub.Error, sub.FinishedAt = submission.StatusFailed, err.Error(), &now
+		case <-a.ctx.Done():
+		case <-r.Context().Done():
+			// The submission goes on; the client can poll its token
+			go h.remember(a.ctx, a.cancel, a.job, tenant, fingerprint)
+			return
+		}
+		a.cancel()
+	}
+	if result != nil {
+		now := time.Now()
+		sub.Status, sub.Result, sub.FinishedAt = submission.StatusCompleted, result, &now
+	}
+
+	writeJudge0Submission(w, r, http.StatusCreated, judge0.FromSubmission(saved, sub), encoded)
+}
+
+// resolve converts a Judge0 request to execute options and checks them.
+// Errors are the client's, to be reported as 422 Unprocessable Entity as
+// Judge0 does.
+func (j *Judge0Handler) resolve(ctx context.Context, req Judge0Request) (executor.ExecuteOptions, error) {
+	var opts executor.ExecuteOptions
+	if req.SourceCode == "" {
+		return opts, errors.New("source_code can't be blank")
+	}
+	language, ok := judge0.LanguageIDs[req.LanguageID]
+	if !ok {
+		return opts, fmt.Errorf("language with id %d doesn't exist", req.LanguageID)
+	}
+	if req.CallbackURL != "" {
+		if !j.handler.callbacks {
+			return opts, errors.New("callback_url: callbacks are not enabled on this server")
+		}
+		if err := validCallbackURL(req.CallbackURL); err != nil {
+			return opts, fmt.Errorf("callback_url: %w", err)
+		}
+	}
+	if req.ExpectedOutput != nil {
+		if err := checkSize("expected_output", *req.ExpectedOutput, j.handler.limits.MaxExpectedOutput); err != nil {
+			return opts, err
+		}
+	}
+
+	// Executioner enforces a single, wall-clock time limit
+	timeLimit := req.CPUTimeLimit
+	if timeLimit == 0 {
+		timeLimit = req.WallTimeLimit
+	}
+	if timeLimit < 0 || req.MemoryLimit < 0 {
+		return opts, errors.New("limits must not be negative")
+	}
+
+	return j.handler.resolve(ctx, executor.ExecuteOptions{
+		LanguageID:      language,
+		SourceCode:      req.SourceCode,
+		Stdin:           req.Stdin,
+		TimeLimitMs:     int(math.Ceil(timeLimit * 1000)),
+		MemoryLimitKb:   int(math.Ceil(req.MemoryLimit)),
+		CompilerOptions: strings.Fields(req.CompilerOptions),
+		Args:            strings.Fields(req.CommandLineArguments),
+		Mode:            executor.ModeRun,
+	})
+}
+
+// decodeJudge0Request decodes the fields sent base64-encoded.
+func decodeJudge0Request(req *Judge0Request) error {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"source_code", &req.SourceCode},
+		{"stdin", &req.Stdin},
+		{"expected_output", req.ExpectedOutput},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(*field.value)
+		if err != nil {
+			return fmt.Errorf("%s is not valid base64", field.name)
+		}
+		*field.value = string(decoded)
+	}
+	return nil
+}
+
+// Submission handles GET /judge0/submissions/{token}.
+func (j *Judge0Handler) Submission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.PathValue("token")
+	sub, err := j.handler.submissions.Get(r.Context(), token, tenantOf(r))
+	if errors.Is(err, submission.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req, err := j.requests.Get(r.Context(), token)
+	if errors.Is(err, judge0.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encoded := r.URL.Query().Get("base64_encoded") == "true"
+	writeJudge0Submission(w, r, http.StatusOK, judge0.FromSubmission(req, sub), encoded)
+}
+
+// writeJudge0Submission writes the fields of sub the request asks for.
+// Like Judge0, it refuses to show output that is not valid UTF-8 unless
+// base64-encoded.
+func writeJudge0Submission(w http.ResponseWriter, r *http.Request, code int, sub *judge0.Submission, encoded bool) {
+	if encoded {
+		sub.Encode()
+	} else if sub.Binary() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "some attributes for this submission cannot be converted to UTF-8, use base64_encoded=true",
+		})
+		return
+	}
+	body, err := json.Marshal(sub)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(body, &all); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	fields := judge0.DefaultFields
+	if param := r.URL.Query().Get("fields"); param == "*" {
+		fields = nil
+	} else if param != "" {
+		fields = strings.Split(param, ",")
+	}
+	out := all
+	if fields != nil {
+		out = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			field = strings.TrimSpace(field)
+			if value, ok := all[field]; ok {
+				out[field] = value
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(out)
+}
+
+// judge0Language is a language as GET /judge0/languages/{id} shows it.
+type judge0Language struct {
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	IsArchived bool    `json:"is_archived"`
+	SourceFile string  `json:"source_file"`
+	CompileCmd *string `json:"compile_cmd"`
+	RunCmd     string  `json:"run_cmd"`
+}
+
+// Languages handles GET /judge0/languages: the Judge0 IDs and names of
+// the registered languages that are not disabled.
+func (j *Judge0Handler) Languages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ids := make([]int, 0, len(judge0.LanguageIDs))
+	for id := range judge0.LanguageIDs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	type entry struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	langs := []entry{}
+	for _, id := range ids {
+		if lang, err := j.registry.Get(judge0.LanguageIDs[id]); err == nil && !j.registry.Disabled(lang.ID) {
+			langs = append(langs, entry{ID: id, Name: lang.Name})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(langs)
+}
+
+// Language handles GET /judge0/languages/{id}.
+func (j *Judge0Handler) Language(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Language not found", http.StatusNotFound)
+		return
+	}
+	lang, err := j.registry.Get(judge0.LanguageIDs[id])
+	if err != nil {
+		http.Error(w, "Language not found", http.StatusNotFound)
+		return
+	}
+
+	out := judge0Language{
+		ID:         id,
+		Name:       lang.Name,
+		SourceFile: lang.Config.SourceFile,
+		RunCmd:     strings.Join(lang.Config.RunCommand, " "),
+	}
+	if lang.Config.Compiled() {
+		compile := strings.Join(lang.Config.CompileCommand, " ")
+		out.CompileCmd = &compile
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// Statuses handles GET /judge0/statuses.
+func (j *Judge0Handler) Statuses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(judge0.Statuses)
+}
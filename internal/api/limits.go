@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Limits bounds the size of requests, in bytes. Source code, stdin and
+// expected output are measured after base64 decoding.
+type Limits struct {
+	// MaxBody applies to single requests, MaxBatchBody to batches.
+	MaxBody      int64
+	MaxBatchBody int64
+
+	MaxSource int
+	MaxStdin  int
+	// MaxExpectedOutput bounds the test case Judge0 submissions are
+	// judged against.
+	MaxExpectedOutput int
+}
+
+// DefaultLimits leave room for a base64-encoded request with source code,
+// stdin and expected output at their maximum sizes.
+var DefaultLimits = Limits{
+	MaxBody:           4 << 20,
+	MaxBatchBody:      32 << 20,
+	MaxSource:         256 << 10,
+	MaxStdin:          1 << 20,
+	MaxExpectedOutput: 1 << 20,
+}
+
+// checkSize rejects a field value longer than max bytes.
+func checkSize(field, value string, max int) error {
+	if len(value) > max {
+		return &FieldError{Field: field, Err: fmt.Errorf("%s is %d bytes, more than the maximum of %d", field, len(value), max)}
+	}
+	return nil
+}
+
+// decode reads a JSON request body of at most limit bytes into v,
+// rejecting fields v does not have. Errors are reported with
+// writeDecodeError.
+func decode(w http.ResponseWriter, r *http.Request, v any, limit int64) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
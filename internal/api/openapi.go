@@ -0,0 +1,331 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/idempotency"
+)
+
+// operation documents a v1 endpoint. Bodies are Go values whose types
+// the schemas are generated from.
+type operation struct {
+	method      string
+	path        string
+	summary     string
+	headers     []string
+	base64      bool // takes the base64_encoded query parameter
+	request     any
+	responses   map[int]response
+	description string
+}
+
+type response struct {
+	description string
+	body        any
+}
+
+// v1Operations lists the v1 endpoints for the OpenAPI document. Every
+// operation may also answer with an ErrorResponse.
+var v1Operations = []operation{
+	{
+		method:  http.MethodPost,
+		path:    "/v1/execute",
+		summary: "Run code",
+		description: "Runs code and responds with the result. With a callback_url the job is queued instead, " +
+			"and the result is POSTed there when it finishes.",
+		headers: []string{idempotency.Header, SubmissionIDHeader},
+		base64:  true,
+		request: ExecutionRequest{},
+		responses: map[int]response{
+			http.StatusOK:       {"The execution result", Result{}},
+			http.StatusAccepted: {"Queued; the result will be delivered to callback_url", Accepted{}},
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/compile",
+		summary: "Compile code or check its syntax",
+		headers: []string{idempotency.Header, SubmissionIDHeader},
+		base64:  true,
+		request: CompileRequest{},
+		responses: map[int]response{
+			http.StatusOK: {"The compiler's result", Result{}},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/languages",
+		summary: "List languages",
+		responses: map[int]response{
+			http.StatusOK: {"The registered languages", []Language{}},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/queue",
+		summary: "Estimate the queue wait",
+		responses: map[int]response{
+			http.StatusOK: {"The estimated wait", QueueStatus{}},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/usage",
+		summary: "Get the caller's quotas and usage",
+		responses: map[int]response{
+			http.StatusOK: {"The tenant's quotas and usage this day and month", UsageResponse{}},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/submissions/{id}",
+		summary: "Get an asynchronous submission",
+		base64:  true,
+		responses: map[int]response{
+			http.StatusOK: {"The submission, with its result once finished", Submission{}},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/submissions/{id}",
+		summary: "Cancel a submission",
+		responses: map[int]response{
+			http.StatusOK: {"The job was cancelled", Cancelled{}},
+		},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/v1/submissions/batch",
+		summary:     "Submit a batch",
+		description: "Queues up to 1000 execution requests together, or none of them.",
+		base64:      true,
+		request:     []ExecutionRequest{},
+		responses: map[int]response{
+			http.StatusAccepted: {"The batch was queued", BatchAccepted{}},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/submissions/batch/{id}",
+		summary: "Get a batch",
+		base64:  true,
+		responses: map[int]response{
+			http.StatusOK: {"The batch's progress and submissions", Batch{}},
+		},
+	},
+}
+
+// requiredFields lists the fields v1 requires of request types. Fields of
+// response types are required unless omitempty.
+var requiredFields = map[reflect.Type][]string{
+	reflect.TypeFor[ExecutionRequest](): {"language", "source_code"},
+	reflect.TypeFor[CompileRequest]():   {"language", "source_code"},
+}
+
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  []byte
+)
+
+// OpenAPI serves GET /openapi.json, the OpenAPI document of the v1 API
+// generated from its request and response types.
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	openAPIOnce.Do(func() {
+		openAPIDoc, _ = json.MarshalIndent(openAPIDocument(), "", "  ")
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDoc)
+}
+
+func openAPIDocument() map[string]any {
+	s := &schemas{components: make(map[string]any)}
+	errorSchema := s.of(reflect.TypeFor[ErrorResponse]())
+
+	paths := make(map[string]map[string]any)
+	for _, op := range v1Operations {
+		var params []any
+		for _, m := range pathParam.FindAllStringSubmatch(op.path, -1) {
+			params = append(params, map[string]any{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		if op.base64 {
+			params = append(params, map[string]any{
+				"name": "base64_encoded", "in": "query",
+				"description": "source_code and stdin are base64-encoded in the request, and stdout and stderr are to be in the response",
+				"schema":      map[string]any{"type": "boolean"},
+			})
+		}
+		for _, header := range op.headers {
+			params = append(params, map[string]any{
+				"name": header, "in": "header",
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+
+		responses := map[string]any{
+			"default": map[string]any{
+				"description": "An error",
+				"content":     jsonContent(errorSchema),
+			},
+		}
+		for code, resp := range op.responses {
+			responses[strconv.Itoa(code)] = map[string]any{
+				"description": resp.description,
+				"content":     jsonContent(s.of(reflect.TypeOf(resp.body))),
+			}
+		}
+
+		doc := map[string]any{
+			"summary":   op.summary,
+			"responses": responses,
+		}
+		if op.description != "" {
+			doc["description"] = op.description
+		}
+		if params != nil {
+			doc["parameters"] = params
+		}
+		if op.request != nil {
+			doc["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(s.of(reflect.TypeOf(op.request))),
+			}
+		}
+
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]any)
+		}
+		paths[op.path][strings.ToLower(op.method)] = doc
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Executioner API",
+			"version": "1",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": s.components,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": APIKeyHeader},
+			},
+		},
+		// keys are optional unless the server requires them
+		"security": []any{map[string]any{}, map[string]any{"apiKey": []string{}}},
+	}
+}
+
+func jsonContent(schema any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// schemas generates JSON schemas from Go types, as encoding/json would
+// encode them. Named struct types become components.
+type schemas struct {
+	components map[string]any
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (s *schemas) of(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := s.components[t.Name()]; !ok {
+			// Placeholder first, for recursive types
+			s.components[t.Name()] = nil
+			s.components[t.Name()] = s.object(t)
+		}
+		return ref
+	default:
+		return map[string]any{}
+	}
+}
+
+// object generates the schema of a struct type. Fields of embedded
+// structs are promoted, and shadowed by fields of the same name outside.
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	s.fields(t, properties, &required, make(map[string]bool))
+
+	if fields, ok := requiredFields[t]; ok {
+		required = fields
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *schemas) fields(t reflect.Type, properties map[string]any, required *[]string, seen map[string]bool) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded = append(embedded, field.Type)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		properties[name] = s.of(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+	for _, e := range embedded {
+		s.fields(e, properties, required, seen)
+	}
+}
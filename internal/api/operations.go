@@ -0,0 +1,226 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/remote"
+	"github.com/itstheanurag/executioner/internal/tenant"
+	"github.com/itstheanurag/executioner/internal/worker"
+)
+
+// defaultQueueListLimit is how many jobs GET /admin/queue lists without a
+// limit parameter.
+const defaultQueueListLimit = 100
+
+// QueueDetails is the queue as operators see it. InFlight counts the
+// requests admitted by the rate limiter that have not finished.
+type QueueDetails struct {
+	Paused          bool            `json:"paused"`
+	Depth           int             `json:"depth"`
+	EstimatedWaitMs int64           `json:"estimated_wait_ms"`
+	InFlight        int             `json:"in_flight_requests"`
+	Jobs            []queue.JobInfo `json:"jobs"`
+}
+
+// Intake reports whether job intake is paused.
+type Intake struct {
+	Paused bool `json:"paused"`
+}
+
+// Workers lists the local workers and the connected remote nodes, with
+// the jobs they are running.
+type Workers struct {
+	Local []worker.Status     `json:"local"`
+	Nodes []remote.NodeStatus `json:"nodes"`
+}
+
+// AdminLanguage is a registered language and whether it is disabled.
+type AdminLanguage struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled"`
+}
+
+// TenantUsage is a tenant with its quotas and its usage of them.
+type TenantUsage struct {
+	*tenant.Tenant
+	Usage *tenant.Usage `json:"usage"`
+}
+
+// Queue shows the queue's state and its queued and running jobs.
+func (h *AdminHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultQueueListLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := h.queueManager.Jobs(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if jobs == nil {
+		jobs = []queue.JobInfo{}
+	}
+	writeJSON(w, http.StatusOK, QueueDetails{
+		Paused:          h.queueManager.Paused(),
+		Depth:           h.queueManager.Depth(),
+		EstimatedWaitMs: h.queueManager.EstimatedWait().Milliseconds(),
+		InFlight:        h.rateLimiter.InFlight(),
+		Jobs:            jobs,
+	})
+}
+
+// PauseQueue stops the queue from accepting jobs, on every instance.
+// Queued and running jobs still run.
+func (h *AdminHandler) PauseQueue(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+// ResumeQueue lets the queue accept jobs again.
+func (h *AdminHandler) ResumeQueue(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+func (h *AdminHandler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.controls.SetPaused(r.Context(), paused); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, Intake{Paused: paused})
+}
+
+// Workers lists the workers and the job each is running.
+func (h *AdminHandler) Workers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	workers := Workers{Local: h.workers.Workers(), Nodes: []remote.NodeStatus{}}
+	if h.nodes != nil {
+		workers.Nodes = h.nodes.Nodes()
+	}
+	writeJSON(w, http.StatusOK, workers)
+}
+
+// KillJob cancels a queued job or kills a running one, whoever submitted
+// it.
+func (h *AdminHandler) KillJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	err := h.handler.cancelJob(r.Context(), id, "")
+	if errors.Is(err, queue.ErrJobNotFound) {
+		http.Error(w, "Job not found or already finished", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, Cancelled{ID: id, Status: executor.StatusCancelled})
+}
+
+// Languages lists every registered language, disabled or not.
+func (h *AdminHandler) Languages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	langs := h.registry.List()
+	slices.SortFunc(langs, func(a, b languages.Language) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	out := make([]AdminLanguage, len(langs))
+	for i, lang := range langs {
+		out[i] = AdminLanguage{ID: lang.ID, Name: lang.Name, Disabled: h.registry.Disabled(lang.ID)}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// DisableLanguage refuses new requests for a language, on every instance.
+// Jobs already queued for it still run.
+func (h *AdminHandler) DisableLanguage(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, true)
+}
+
+// EnableLanguage accepts requests for a disabled language again.
+func (h *AdminHandler) EnableLanguage(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, false)
+}
+
+func (h *AdminHandler) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	err := h.controls.SetDisabled(r.Context(), id, disabled)
+	if errors.Is(err, languages.ErrLanguageNotFound) {
+		http.Error(w, "Language not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lang, _ := h.registry.Get(id)
+	writeJSON(w, http.StatusOK, AdminLanguage{ID: lang.ID, Name: lang.Name, Disabled: disabled})
+}
+
+// Usage lists every tenant's quotas and usage this day and month.
+func (h *AdminHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	usage, err := h.tenants.AllUsage(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tenants, err := h.tenants.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]TenantUsage, 0, len(tenants))
+	for _, t := range tenants {
+		// Tenants created since AllUsage ran have used nothing
+		u, ok := usage[t.ID]
+		if !ok {
+			u = &tenant.Usage{}
+		}
+		out = append(out, TenantUsage{Tenant: t, Usage: u})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
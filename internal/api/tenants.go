@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/itstheanurag/executioner/internal/tenant"
+)
+
+// TenantRequest creates or updates a tenant. ID is ignored by updates.
+type TenantRequest struct {
+	ID     string        `json:"id"`
+	Name   string        `json:"name"`
+	Quotas tenant.Quotas `json:"quotas"`
+}
+
+// TenantDetails is a tenant with its usage and keys.
+type TenantDetails struct {
+	*tenant.Tenant
+	Usage *tenant.Usage `json:"usage"`
+	Keys  []tenant.Key  `json:"keys"`
+}
+
+// CreatedKey answers the creation of an API key. Secret, the key itself,
+// is not shown again.
+type CreatedKey struct {
+	*tenant.Key
+	Secret string `json:"key"`
+}
+
+func validQuotas(q tenant.Quotas) error {
+	if q.ExecutionsPerDay < 0 || q.CPUSecondsPerMonth < 0 || q.MaxTimeLimitMs < 0 || q.MaxMemoryLimitKb < 0 {
+		return errors.New("quotas must not be negative")
+	}
+	return nil
+}
+
+// Tenants lists tenants (GET) or creates one (POST).
+func (h *AdminHandler) Tenants(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tenants, err := h.tenants.List(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, tenants)
+	case http.MethodPost:
+		var req TenantRequest
+		if !h.decodeTenant(w, r, &req) {
+			return
+		}
+		if !tenant.IDPattern.MatchString(req.ID) {
+			http.Error(w, "Invalid id: must be 1-63 lowercase letters, digits, '-' or '_', starting with a letter", http.StatusBadRequest)
+			return
+		}
+		if err := validQuotas(req.Quotas); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.tenants.Create(r.Context(), req.ID, req.Name, req.Quotas)
+		if errors.Is(err, tenant.ErrExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, t)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Tenant shows a tenant with its usage and keys (GET), replaces its name
+// and quotas (PUT), or deletes it with its keys (DELETE).
+func (h *AdminHandler) Tenant(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	switch r.Method {
+	case http.MethodGet:
+		t, err := h.tenants.Get(r.Context(), id)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		usage, err := h.tenants.Usage(r.Context(), id)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		keys, err := h.tenants.ListKeys(r.Context(), id)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, TenantDetails{Tenant: t, Usage: usage, Keys: keys})
+	case http.MethodPut:
+		var req TenantRequest
+		if !h.decodeTenant(w, r, &req) {
+			return
+		}
+		if err := validQuotas(req.Quotas); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		t, err := h.tenants.Update(r.Context(), id, req.Name, req.Quotas)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	case http.MethodDelete:
+		if err := h.tenants.Delete(r.Context(), id); err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// TenantKeys lists a tenant's API keys (GET) or issues a new one (POST).
+func (h *AdminHandler) TenantKeys(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	switch r.Method {
+	case http.MethodGet:
+		if _, err := h.tenants.Get(r.Context(), id); err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		keys, err := h.tenants.ListKeys(r.Context(), id)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, keys)
+	case http.MethodPost:
+		k, key, err := h.tenants.CreateKey(r.Context(), id)
+		if err != nil {
+			writeTenantError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, CreatedKey{Key: k, Secret: key})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// TenantKey revokes one of a tenant's API keys.
+func (h *AdminHandler) TenantKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.tenants.DeleteKey(r.Context(), r.PathValue("id"), r.PathValue("key")); err != nil {
+		writeTenantError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTenantError(w http.ResponseWriter, err error) {
+	if errors.Is(err, tenant.ErrNotFound) || errors.Is(err, tenant.ErrKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// decodeTenant reads a tenant request body, within the API's body limit
+// and without unknown fields, so a misspelled quota is not silently
+// dropped. It reports whether it succeeded.
+func (h *AdminHandler) decodeTenant(w http.ResponseWriter, r *http.Request, req *TenantRequest) bool {
+	err := decode(w, r, req, h.handler.limits.MaxBody)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, fmt.Sprintf("Request body is larger than the maximum of %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
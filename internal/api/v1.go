@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/itstheanurag/executioner/internal/diagnostics"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/itstheanurag/executioner/internal/submission"
+)
+
+type v1Key struct{}
+
+// V1 marks requests to the /v1 API. The handlers are shared with the
+// unversioned routes; for v1 requests they answer errors with the JSON
+// envelope, validate required fields, and show results with snake_case
+// field names.
+func V1(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(context.WithValue(r.Context(), v1Key{}, true)))
+	}
+}
+
+func isV1(r *http.Request) bool {
+	v1, _ := r.Context().Value(v1Key{}).(bool)
+	return v1
+}
+
+// pathPrefix is the prefix of the routes of the request's API version,
+// for links in responses.
+func pathPrefix(r *http.Request) string {
+	if isV1(r) {
+		return "/v1"
+	}
+	return ""
+}
+
+// base64Encoded reports whether a v1 request asked for base64_encoded=true:
+// source_code and stdin are base64-encoded in its body, and stdout and
+// stderr are to be in its response.
+func base64Encoded(r *http.Request) bool {
+	return isV1(r) && r.URL.Query().Get("base64_encoded") == "true"
+}
+
+// Result is an execution result as the v1 API shows it. The unversioned
+// API shows executor.ExecutionResult with its Go field names.
+type Result struct {
+	// Status is "success", "compilation_error", "runtime_error", "error"
+	// or "cancelled".
+	Status    string `json:"status"`
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ExitCode  int    `json:"exit_code"`
+	TimeMs    int64  `json:"time_ms"`
+	MemoryKb  int64  `json:"memory_kb"`
+	ErrorType string `json:"error_type,omitempty"`
+
+	// Encoding is "base64" when stdout and stderr are base64-encoded: when
+	// the request asked for base64_encoded=true, or when the output is not
+	// valid UTF-8, which BinaryOutput then reports.
+	Encoding     string `json:"encoding,omitempty"`
+	BinaryOutput bool   `json:"binary_output,omitempty"`
+
+	Diagnostics    []diagnostics.Diagnostic `json:"diagnostics,omitempty"`
+	CompileCommand []string                 `json:"compile_command,omitempty"`
+	RunCommand     []string                 `json:"run_command,omitempty"`
+}
+
+func newResult(res *executor.ExecutionResult, encoded bool) *Result {
+	if res == nil {
+		return nil
+	}
+	out := &Result{
+		Status:         res.Status,
+		Stdout:         res.Stdout,
+		Stderr:         res.Stderr,
+		ExitCode:       res.ExitCode,
+		TimeMs:         res.TimeMs,
+		MemoryKb:       res.MemoryKb,
+		ErrorType:      res.ErrorType,
+		Diagnostics:    res.Diagnostics,
+		CompileCommand: res.CompileCommand,
+		RunCommand:     res.RunCommand,
+	}
+	out.BinaryOutput = executor.Binary(res.Stdout, res.Stderr)
+	if encoded || out.BinaryOutput {
+		out.Encoding = executor.EncodingBase64
+		out.Stdout = base64.StdEncoding.EncodeToString([]byte(res.Stdout))
+		out.Stderr = base64.StdEncoding.EncodeToString([]byte(res.Stderr))
+	}
+	return out
+}
+
+// Submission is an asynchronous submission as the v1 API shows it.
+type Submission struct {
+	submission.Submission
+	Result *Result `json:"result,omitempty"`
+}
+
+func newSubmission(sub *submission.Submission, encoded bool) *Submission {
+	return &Submission{Submission: *sub, Result: newResult(sub.Result, encoded)}
+}
+
+// Batch is a batch as the v1 API shows it.
+type Batch struct {
+	submission.Batch
+	Submissions []*Submission `json:"submissions"`
+}
+
+func newBatch(batch *submission.Batch, encoded bool) *Batch {
+	subs := make([]*Submission, len(batch.Submissions))
+	for i := range batch.Submissions {
+		subs[i] = newSubmission(&batch.Submissions[i], encoded)
+	}
+	return &Batch{Batch: *batch, Submissions: subs}
+}
+
+// present converts a response body to the version of the API the request
+// was made to.
+func present(r *http.Request, body any) any {
+	if !isV1(r) {
+		return body
+	}
+	switch body := body.(type) {
+	case *executor.ExecutionResult:
+		return newResult(body, base64Encoded(r))
+	case *submission.Submission:
+		return newSubmission(body, base64Encoded(r))
+	case *submission.Batch:
+		return newBatch(body, base64Encoded(r))
+	}
+	return body
+}
+
+// Language is a language code can be run in.
+type Language struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Compiled bool   `json:"compiled"`
+}
+
+// LanguagesHandler serves GET /v1/languages.
+func LanguagesHandler(registry *languages.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w, r)
+			return
+		}
+
+		langs := registry.Available()
+		slices.SortFunc(langs, func(a, b languages.Language) int {
+			return strings.Compare(a.ID, b.ID)
+		})
+		out := make([]Language, len(langs))
+		for i, lang := range langs {
+			out[i] = Language{ID: lang.ID, Name: lang.Name, Compiled: lang.Config.Compiled()}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	}
+}
@@ -0,0 +1,1067 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: executioner.proto
+
+package apipb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Output_Stream int32
+
+const (
+	Output_STDOUT Output_Stream = 0
+	Output_STDERR Output_Stream = 1
+)
+
+// Enum value maps for Output_Stream.
+var (
+	Output_Stream_name = map[int32]string{
+		0: "STDOUT",
+		1: "STDERR",
+	}
+	Output_Stream_value = map[string]int32{
+		"STDOUT": 0,
+		"STDERR": 1,
+	}
+)
+
+func (x Output_Stream) Enum() *Output_Stream {
+	p := new(Output_Stream)
+	*p = x
+	return p
+}
+
+func (x Output_Stream) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Output_Stream) Descriptor() protoreflect.EnumDescriptor {
+	return file_executioner_proto_enumTypes[0].Descriptor()
+}
+
+func (Output_Stream) Type() protoreflect.EnumType {
+	return &file_executioner_proto_enumTypes[0]
+}
+
+func (x Output_Stream) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Output_Stream.Descriptor instead.
+func (Output_Stream) EnumDescriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{7, 0}
+}
+
+// ExecuteRequest has the fields of an HTTP execution request.
+type ExecuteRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Language string                 `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`
+	// Source, stdin and output are bytes: they need not be UTF-8.
+	SourceCode []byte `protobuf:"bytes,2,opt,name=source_code,json=sourceCode,proto3" json:"source_code,omitempty"`
+	Stdin      []byte `protobuf:"bytes,3,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	// Limits in seconds and megabytes; zero selects the language default.
+	TimeLimit            int32    `protobuf:"varint,4,opt,name=time_limit,json=timeLimit,proto3" json:"time_limit,omitempty"`
+	MemoryLimit          int32    `protobuf:"varint,5,opt,name=memory_limit,json=memoryLimit,proto3" json:"memory_limit,omitempty"`
+	CompileTimeLimit     int32    `protobuf:"varint,6,opt,name=compile_time_limit,json=compileTimeLimit,proto3" json:"compile_time_limit,omitempty"`
+	CompileMemoryLimit   int32    `protobuf:"varint,7,opt,name=compile_memory_limit,json=compileMemoryLimit,proto3" json:"compile_memory_limit,omitempty"`
+	CompilerOptions      []string `protobuf:"bytes,8,rep,name=compiler_options,json=compilerOptions,proto3" json:"compiler_options,omitempty"`
+	CommandLineArguments []string `protobuf:"bytes,9,rep,name=command_line_arguments,json=commandLineArguments,proto3" json:"command_line_arguments,omitempty"`
+	// "interactive", "submission" (default) or "batch".
+	Priority string `protobuf:"bytes,10,opt,name=priority,proto3" json:"priority,omitempty"`
+	// Submit only: where to POST the result.
+	CallbackUrl string `protobuf:"bytes,11,opt,name=callback_url,json=callbackUrl,proto3" json:"callback_url,omitempty"`
+	// Optional ID for the job, as the X-Submission-ID header sets it.
+	SubmissionId  string `protobuf:"bytes,12,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteRequest) Reset() {
+	*x = ExecuteRequest{}
+	mi := &file_executioner_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteRequest) ProtoMessage() {}
+
+func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteRequest) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExecuteRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetSourceCode() []byte {
+	if x != nil {
+		return x.SourceCode
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetTimeLimit() int32 {
+	if x != nil {
+		return x.TimeLimit
+	}
+	return 0
+}
+
+func (x *ExecuteRequest) GetMemoryLimit() int32 {
+	if x != nil {
+		return x.MemoryLimit
+	}
+	return 0
+}
+
+func (x *ExecuteRequest) GetCompileTimeLimit() int32 {
+	if x != nil {
+		return x.CompileTimeLimit
+	}
+	return 0
+}
+
+func (x *ExecuteRequest) GetCompileMemoryLimit() int32 {
+	if x != nil {
+		return x.CompileMemoryLimit
+	}
+	return 0
+}
+
+func (x *ExecuteRequest) GetCompilerOptions() []string {
+	if x != nil {
+		return x.CompilerOptions
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetCommandLineArguments() []string {
+	if x != nil {
+		return x.CommandLineArguments
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetCallbackUrl() string {
+	if x != nil {
+		return x.CallbackUrl
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+type ExecutionResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Status         string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Stdout         []byte                 `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr         []byte                 `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	ExitCode       int32                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	TimeMs         int64                  `protobuf:"varint,5,opt,name=time_ms,json=timeMs,proto3" json:"time_ms,omitempty"`
+	MemoryKb       int64                  `protobuf:"varint,6,opt,name=memory_kb,json=memoryKb,proto3" json:"memory_kb,omitempty"`
+	ErrorType      string                 `protobuf:"bytes,7,opt,name=error_type,json=errorType,proto3" json:"error_type,omitempty"`
+	Diagnostics    []*Diagnostic          `protobuf:"bytes,8,rep,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	CompileCommand []string               `protobuf:"bytes,9,rep,name=compile_command,json=compileCommand,proto3" json:"compile_command,omitempty"`
+	RunCommand     []string               `protobuf:"bytes,10,rep,name=run_command,json=runCommand,proto3" json:"run_command,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ExecutionResult) Reset() {
+	*x = ExecutionResult{}
+	mi := &file_executioner_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionResult) ProtoMessage() {}
+
+func (x *ExecutionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionResult.ProtoReflect.Descriptor instead.
+func (*ExecutionResult) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExecutionResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetStderr() []byte {
+	if x != nil {
+		return x.Stderr
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetTimeMs() int64 {
+	if x != nil {
+		return x.TimeMs
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetMemoryKb() int64 {
+	if x != nil {
+		return x.MemoryKb
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetErrorType() string {
+	if x != nil {
+		return x.ErrorType
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetDiagnostics() []*Diagnostic {
+	if x != nil {
+		return x.Diagnostics
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetCompileCommand() []string {
+	if x != nil {
+		return x.CompileCommand
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetRunCommand() []string {
+	if x != nil {
+		return x.RunCommand
+	}
+	return nil
+}
+
+type Diagnostic struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Line          int32                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Column        int32                  `protobuf:"varint,3,opt,name=column,proto3" json:"column,omitempty"`
+	Severity      string                 `protobuf:"bytes,4,opt,name=severity,proto3" json:"severity,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Diagnostic) Reset() {
+	*x = Diagnostic{}
+	mi := &file_executioner_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Diagnostic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Diagnostic) ProtoMessage() {}
+
+func (x *Diagnostic) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Diagnostic.ProtoReflect.Descriptor instead.
+func (*Diagnostic) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Diagnostic) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *Diagnostic) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
+func (x *Diagnostic) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SubmitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId  string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitResponse) Reset() {
+	*x = SubmitResponse{}
+	mi := &file_executioner_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitResponse) ProtoMessage() {}
+
+func (x *SubmitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitResponse.ProtoReflect.Descriptor instead.
+func (*SubmitResponse) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SubmitResponse) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *SubmitResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type GetSubmissionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId  string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSubmissionRequest) Reset() {
+	*x = GetSubmissionRequest{}
+	mi := &file_executioner_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSubmissionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSubmissionRequest) ProtoMessage() {}
+
+func (x *GetSubmissionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSubmissionRequest.ProtoReflect.Descriptor instead.
+func (*GetSubmissionRequest) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetSubmissionRequest) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+type Submission struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	BatchId      string                 `protobuf:"bytes,2,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	// "queued", "completed" or "failed".
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Set once completed.
+	Result *ExecutionResult `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`
+	// Set once failed.
+	Error       string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAtMs int64  `protobuf:"varint,6,opt,name=created_at_ms,json=createdAtMs,proto3" json:"created_at_ms,omitempty"`
+	// Zero until finished.
+	FinishedAtMs  int64  `protobuf:"varint,7,opt,name=finished_at_ms,json=finishedAtMs,proto3" json:"finished_at_ms,omitempty"`
+	CallbackUrl   string `protobuf:"bytes,8,opt,name=callback_url,json=callbackUrl,proto3" json:"callback_url,omitempty"`
+	CallbackState string `protobuf:"bytes,9,opt,name=callback_state,json=callbackState,proto3" json:"callback_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Submission) Reset() {
+	*x = Submission{}
+	mi := &file_executioner_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Submission) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Submission) ProtoMessage() {}
+
+func (x *Submission) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Submission.ProtoReflect.Descriptor instead.
+func (*Submission) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Submission) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *Submission) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *Submission) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Submission) GetResult() *ExecutionResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Submission) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Submission) GetCreatedAtMs() int64 {
+	if x != nil {
+		return x.CreatedAtMs
+	}
+	return 0
+}
+
+func (x *Submission) GetFinishedAtMs() int64 {
+	if x != nil {
+		return x.FinishedAtMs
+	}
+	return 0
+}
+
+func (x *Submission) GetCallbackUrl() string {
+	if x != nil {
+		return x.CallbackUrl
+	}
+	return ""
+}
+
+func (x *Submission) GetCallbackState() string {
+	if x != nil {
+		return x.CallbackState
+	}
+	return ""
+}
+
+type SubmissionEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*SubmissionEvent_Status
+	//	*SubmissionEvent_Output
+	//	*SubmissionEvent_Finished
+	Event         isSubmissionEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmissionEvent) Reset() {
+	*x = SubmissionEvent{}
+	mi := &file_executioner_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmissionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmissionEvent) ProtoMessage() {}
+
+func (x *SubmissionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmissionEvent.ProtoReflect.Descriptor instead.
+func (*SubmissionEvent) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SubmissionEvent) GetEvent() isSubmissionEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *SubmissionEvent) GetStatus() string {
+	if x != nil {
+		if x, ok := x.Event.(*SubmissionEvent_Status); ok {
+			return x.Status
+		}
+	}
+	return ""
+}
+
+func (x *SubmissionEvent) GetOutput() *Output {
+	if x != nil {
+		if x, ok := x.Event.(*SubmissionEvent_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *SubmissionEvent) GetFinished() *Submission {
+	if x != nil {
+		if x, ok := x.Event.(*SubmissionEvent_Finished); ok {
+			return x.Finished
+		}
+	}
+	return nil
+}
+
+type isSubmissionEvent_Event interface {
+	isSubmissionEvent_Event()
+}
+
+type SubmissionEvent_Status struct {
+	// The submission's status when the stream starts.
+	Status string `protobuf:"bytes,1,opt,name=status,proto3,oneof"`
+}
+
+type SubmissionEvent_Output struct {
+	Output *Output `protobuf:"bytes,2,opt,name=output,proto3,oneof"`
+}
+
+type SubmissionEvent_Finished struct {
+	// The finished submission, with stdout and stderr left out of its
+	// result since they were streamed.
+	Finished *Submission `protobuf:"bytes,3,opt,name=finished,proto3,oneof"`
+}
+
+func (*SubmissionEvent_Status) isSubmissionEvent_Event() {}
+
+func (*SubmissionEvent_Output) isSubmissionEvent_Event() {}
+
+func (*SubmissionEvent_Finished) isSubmissionEvent_Event() {}
+
+type Output struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        Output_Stream          `protobuf:"varint,1,opt,name=stream,proto3,enum=executioner.v1.Output_Stream" json:"stream,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Output) Reset() {
+	*x = Output{}
+	mi := &file_executioner_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Output) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Output) ProtoMessage() {}
+
+func (x *Output) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Output.ProtoReflect.Descriptor instead.
+func (*Output) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Output) GetStream() Output_Stream {
+	if x != nil {
+		return x.Stream
+	}
+	return Output_STDOUT
+}
+
+func (x *Output) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ListLanguagesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLanguagesRequest) Reset() {
+	*x = ListLanguagesRequest{}
+	mi := &file_executioner_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLanguagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLanguagesRequest) ProtoMessage() {}
+
+func (x *ListLanguagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLanguagesRequest.ProtoReflect.Descriptor instead.
+func (*ListLanguagesRequest) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{8}
+}
+
+type ListLanguagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Languages     []*Language            `protobuf:"bytes,1,rep,name=languages,proto3" json:"languages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLanguagesResponse) Reset() {
+	*x = ListLanguagesResponse{}
+	mi := &file_executioner_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLanguagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLanguagesResponse) ProtoMessage() {}
+
+func (x *ListLanguagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLanguagesResponse.ProtoReflect.Descriptor instead.
+func (*ListLanguagesResponse) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListLanguagesResponse) GetLanguages() []*Language {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+type Language struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Whether the language has a compile phase.
+	Compiled      bool `protobuf:"varint,3,opt,name=compiled,proto3" json:"compiled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Language) Reset() {
+	*x = Language{}
+	mi := &file_executioner_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Language) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Language) ProtoMessage() {}
+
+func (x *Language) ProtoReflect() protoreflect.Message {
+	mi := &file_executioner_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Language.ProtoReflect.Descriptor instead.
+func (*Language) Descriptor() ([]byte, []int) {
+	return file_executioner_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Language) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Language) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Language) GetCompiled() bool {
+	if x != nil {
+		return x.Compiled
+	}
+	return false
+}
+
+var File_executioner_proto protoreflect.FileDescriptor
+
+const file_executioner_proto_rawDesc = "" +
+	"\n" +
+	"\x11executioner.proto\x12\x0eexecutioner.v1\"\xca\x03\n" +
+	"\x0eExecuteRequest\x12\x1a\n" +
+	"\blanguage\x18\x01 \x01(\tR\blanguage\x12\x1f\n" +
+	"\vsource_code\x18\x02 \x01(\fR\n" +
+	"sourceCode\x12\x14\n" +
+	"\x05stdin\x18\x03 \x01(\fR\x05stdin\x12\x1d\n" +
+	"\n" +
+	"time_limit\x18\x04 \x01(\x05R\ttimeLimit\x12!\n" +
+	"\fmemory_limit\x18\x05 \x01(\x05R\vmemoryLimit\x12,\n" +
+	"\x12compile_time_limit\x18\x06 \x01(\x05R\x10compileTimeLimit\x120\n" +
+	"\x14compile_memory_limit\x18\a \x01(\x05R\x12compileMemoryLimit\x12)\n" +
+	"\x10compiler_options\x18\b \x03(\tR\x0fcompilerOptions\x124\n" +
+	"\x16command_line_arguments\x18\t \x03(\tR\x14commandLineArguments\x12\x1a\n" +
+	"\bpriority\x18\n" +
+	" \x01(\tR\bpriority\x12!\n" +
+	"\fcallback_url\x18\v \x01(\tR\vcallbackUrl\x12#\n" +
+	"\rsubmission_id\x18\f \x01(\tR\fsubmissionId\"\xd3\x02\n" +
+	"\x0fExecutionResult\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
+	"\x06stdout\x18\x02 \x01(\fR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x03 \x01(\fR\x06stderr\x12\x1b\n" +
+	"\texit_code\x18\x04 \x01(\x05R\bexitCode\x12\x17\n" +
+	"\atime_ms\x18\x05 \x01(\x03R\x06timeMs\x12\x1b\n" +
+	"\tmemory_kb\x18\x06 \x01(\x03R\bmemoryKb\x12\x1d\n" +
+	"\n" +
+	"error_type\x18\a \x01(\tR\terrorType\x12<\n" +
+	"\vdiagnostics\x18\b \x03(\v2\x1a.executioner.v1.DiagnosticR\vdiagnostics\x12'\n" +
+	"\x0fcompile_command\x18\t \x03(\tR\x0ecompileCommand\x12\x1f\n" +
+	"\vrun_command\x18\n" +
+	" \x03(\tR\n" +
+	"runCommand\"\x82\x01\n" +
+	"\n" +
+	"Diagnostic\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\x03 \x01(\x05R\x06column\x12\x1a\n" +
+	"\bseverity\x18\x04 \x01(\tR\bseverity\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\"M\n" +
+	"\x0eSubmitResponse\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\";\n" +
+	"\x14GetSubmissionRequest\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\"\xc7\x02\n" +
+	"\n" +
+	"Submission\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x19\n" +
+	"\bbatch_id\x18\x02 \x01(\tR\abatchId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x127\n" +
+	"\x06result\x18\x04 \x01(\v2\x1f.executioner.v1.ExecutionResultR\x06result\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\"\n" +
+	"\rcreated_at_ms\x18\x06 \x01(\x03R\vcreatedAtMs\x12$\n" +
+	"\x0efinished_at_ms\x18\a \x01(\x03R\ffinishedAtMs\x12!\n" +
+	"\fcallback_url\x18\b \x01(\tR\vcallbackUrl\x12%\n" +
+	"\x0ecallback_state\x18\t \x01(\tR\rcallbackState\"\xa0\x01\n" +
+	"\x0fSubmissionEvent\x12\x18\n" +
+	"\x06status\x18\x01 \x01(\tH\x00R\x06status\x120\n" +
+	"\x06output\x18\x02 \x01(\v2\x16.executioner.v1.OutputH\x00R\x06output\x128\n" +
+	"\bfinished\x18\x03 \x01(\v2\x1a.executioner.v1.SubmissionH\x00R\bfinishedB\a\n" +
+	"\x05event\"u\n" +
+	"\x06Output\x125\n" +
+	"\x06stream\x18\x01 \x01(\x0e2\x1d.executioner.v1.Output.StreamR\x06stream\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\" \n" +
+	"\x06Stream\x12\n" +
+	"\n" +
+	"\x06STDOUT\x10\x00\x12\n" +
+	"\n" +
+	"\x06STDERR\x10\x01\"\x16\n" +
+	"\x14ListLanguagesRequest\"O\n" +
+	"\x15ListLanguagesResponse\x126\n" +
+	"\tlanguages\x18\x01 \x03(\v2\x18.executioner.v1.LanguageR\tlanguages\"J\n" +
+	"\bLanguage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bcompiled\x18\x03 \x01(\bR\bcompiled2\xb6\x03\n" +
+	"\x10ExecutionService\x12J\n" +
+	"\aExecute\x12\x1e.executioner.v1.ExecuteRequest\x1a\x1f.executioner.v1.ExecutionResult\x12H\n" +
+	"\x06Submit\x12\x1e.executioner.v1.ExecuteRequest\x1a\x1e.executioner.v1.SubmitResponse\x12Q\n" +
+	"\rGetSubmission\x12$.executioner.v1.GetSubmissionRequest\x1a\x1a.executioner.v1.Submission\x12[\n" +
+	"\x10StreamSubmission\x12$.executioner.v1.GetSubmissionRequest\x1a\x1f.executioner.v1.SubmissionEvent0\x01\x12\\\n" +
+	"\rListLanguages\x12$.executioner.v1.ListLanguagesRequest\x1a%.executioner.v1.ListLanguagesResponseB4Z2github.com/itstheanurag/executioner/internal/apipbb\x06proto3"
+
+var (
+	file_executioner_proto_rawDescOnce sync.Once
+	file_executioner_proto_rawDescData []byte
+)
+
+func file_executioner_proto_rawDescGZIP() []byte {
+	file_executioner_proto_rawDescOnce.Do(func() {
+		file_executioner_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_executioner_proto_rawDesc), len(file_executioner_proto_rawDesc)))
+	})
+	return file_executioner_proto_rawDescData
+}
+
+var file_executioner_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_executioner_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_executioner_proto_goTypes = []any{
+	(Output_Stream)(0),            // 0: executioner.v1.Output.Stream
+	(*ExecuteRequest)(nil),        // 1: executioner.v1.ExecuteRequest
+	(*ExecutionResult)(nil),       // 2: executioner.v1.ExecutionResult
+	(*Diagnostic)(nil),            // 3: executioner.v1.Diagnostic
+	(*SubmitResponse)(nil),        // 4: executioner.v1.SubmitResponse
+	(*GetSubmissionRequest)(nil),  // 5: executioner.v1.GetSubmissionRequest
+	(*Submission)(nil),            // 6: executioner.v1.Submission
+	(*SubmissionEvent)(nil),       // 7: executioner.v1.SubmissionEvent
+	(*Output)(nil),                // 8: executioner.v1.Output
+	(*ListLanguagesRequest)(nil),  // 9: executioner.v1.ListLanguagesRequest
+	(*ListLanguagesResponse)(nil), // 10: executioner.v1.ListLanguagesResponse
+	(*Language)(nil),              // 11: executioner.v1.Language
+}
+var file_executioner_proto_depIdxs = []int32{
+	3,  // 0: executioner.v1.ExecutionResult.diagnostics:type_name -> executioner.v1.Diagnostic
+	2,  // 1: executioner.v1.Submission.result:type_name -> executioner.v1.ExecutionResult
+	8,  // 2: executioner.v1.SubmissionEvent.output:type_name -> executioner.v1.Output
+	6,  // 3: executioner.v1.SubmissionEvent.finished:type_name -> executioner.v1.Submission
+	0,  // 4: executioner.v1.Output.stream:type_name -> executioner.v1.Output.Stream
+	11, // 5: executioner.v1.ListLanguagesResponse.languages:type_name -> executioner.v1.Language
+	1,  // 6: executioner.v1.ExecutionService.Execute:input_type -> executioner.v1.ExecuteRequest
+	1,  // 7: executioner.v1.ExecutionService.Submit:input_type -> executioner.v1.ExecuteRequest
+	5,  // 8: executioner.v1.ExecutionService.GetSubmission:input_type -> executioner.v1.GetSubmissionRequest
+	5,  // 9: executioner.v1.ExecutionService.StreamSubmission:input_type -> executioner.v1.GetSubmissionRequest
+	9,  // 10: executioner.v1.ExecutionService.ListLanguages:input_type -> executioner.v1.ListLanguagesRequest
+	2,  // 11: executioner.v1.ExecutionService.Execute:output_type -> executioner.v1.ExecutionResult
+	4,  // 12: executioner.v1.ExecutionService.Submit:output_type -> executioner.v1.SubmitResponse
+	6,  // 13: executioner.v1.ExecutionService.GetSubmission:output_type -> executioner.v1.Submission
+	7,  // 14: executioner.v1.ExecutionService.StreamSubmission:output_type -> executioner.v1.SubmissionEvent
+	10, // 15: executioner.v1.ExecutionService.ListLanguages:output_type -> executioner.v1.ListLanguagesResponse
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_executioner_proto_init() }
+func file_executioner_proto_init() {
+	if File_executioner_proto != nil {
+		return
+	}
+	file_executioner_proto_msgTypes[6].OneofWrappers = []any{
+		(*SubmissionEvent_Status)(nil),
+		(*SubmissionEvent_Output)(nil),
+		(*SubmissionEvent_Finished)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_executioner_proto_rawDesc), len(file_executioner_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_executioner_proto_goTypes,
+		DependencyIndexes: file_executioner_proto_depIdxs,
+		EnumInfos:         file_executioner_proto_enumTypes,
+		MessageInfos:      file_executioner_proto_msgTypes,
+	}.Build()
+	File_executioner_proto = out.File
+	file_executioner_proto_goTypes = nil
+	file_executioner_proto_depIdxs = nil
+}
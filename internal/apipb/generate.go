@@ -0,0 +1,5 @@
+// Package apipb holds the public gRPC API, which mirrors the HTTP API for
+// services that prefer gRPC.
+package apipb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative executioner.proto
@@ -0,0 +1,151 @@
+// Package clientip finds the address of the client behind a request.
+// X-Forwarded-For is only believed when it was added by a trusted proxy,
+// since any client can send the header.
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Resolver finds client addresses, trusting forwarding headers from the
+// proxies it was given.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// NewResolver returns a Resolver trusting proxies, given as addresses or
+// CIDR ranges. Without proxies, forwarding headers are ignored and every
+// client is identified by its connection's address.
+func NewResolver(proxies []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+			}
+			addr = addr.Unmap()
+			r.trusted = append(r.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+func (r *Resolver) trusts(addr netip.Addr) bool {
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client address for a connection from remote that
+// carried the X-Forwarded-For values forwarded. The list is walked from
+// the right, through trusted proxies, to the first address that is not
+// one; a malformed entry stops the walk at the last proxy.
+func (r *Resolver) Resolve(remote string, forwarded []string) string {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !r.trusts(addr.Unmap()) {
+		return host
+	}
+	addr = addr.Unmap()
+
+	var hops []string
+	for _, value := range forwarded {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !r.trusts(addr) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying a client's address.
+func NewContext(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, contextKey{}, addr)
+}
+
+// FromContext returns the client address stored by the Resolver's
+// middleware or interceptors, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	addr, _ := ctx.Value(contextKey{}).(string)
+	return addr
+}
+
+// Middleware records each request's client address in its context.
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		addr := r.Resolve(req.RemoteAddr, req.Header.Values("X-Forwarded-For"))
+		next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), addr)))
+	})
+}
+
+// grpcAddr resolves a gRPC caller's address from its peer and its
+// x-forwarded-for metadata.
+func (r *Resolver) grpcAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return r.Resolve(p.Addr.String(), md.Get("x-forwarded-for"))
+}
+
+// UnaryInterceptor records unary gRPC callers' addresses as Middleware
+// does for HTTP requests.
+func (r *Resolver) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(NewContext(ctx, r.grpcAddr(ctx)), req)
+	}
+}
+
+// resolvedStream overrides the context of a server stream.
+type resolvedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *resolvedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor records streaming gRPC callers' addresses.
+func (r *Resolver) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := NewContext(ss.Context(), r.grpcAddr(ss.Context()))
+		return handler(srv, &resolvedStream{ServerStream: ss, ctx: ctx})
+	}
+}
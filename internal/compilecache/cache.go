@@ -0,0 +1,235 @@
+package compilecache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/rs/zerolog"
+)
+
+// DefaultMaxSizeMb bounds the cache when no size is configured.
+const DefaultMaxSizeMb = 1024
+
+// tempPrefix starts the names of the files Put writes before renaming
+// them into place.
+const tempPrefix = "tmp-"
+
+// Cache is a size-bounded, least-recently-used store of compiled artifacts
+// on the local disk. Entries are opaque blobs named by their key, so the
+// index can be rebuilt from the directory after a restart.
+type Cache struct {
+	dir      string
+	maxBytes int64
+	logger   *zerolog.Logger
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key  string
+	size int64
+}
+
+func New(dir string, maxBytes int64, logger *zerolog.Logger) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		logger:   logger,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Key hashes the inputs that determine a compiled artifact. Parts are
+// length-prefixed so that different splits never collide.
+func Key(parts ...string) string {
+	h := sha256.New()
+	var n [8]byte
+	for _, p := range parts {
+		binary.BigEndian.PutUint64(n[:], uint64(len(p)))
+		h.Write(n[:])
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isKey reports whether name could have been returned by Key.
+func isKey(name string) bool {
+	b, err := hex.DecodeString(name)
+	return err == nil && len(b) == sha256.Size
+}
+
+// Get returns the artifact stored under key and marks it as recently used.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if ok {
+		c.order.MoveToFront(el)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		metrics.CompileCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.logger.Warn().Err(err).Str("key", key).Msg("dropping unreadable compile cache entry")
+		c.remove(key)
+		metrics.CompileCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	// Persist the access time so LRU order survives a restart
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+
+	metrics.CompileCacheRequests.WithLabelValues("hit").Inc()
+	return data, true
+}
+
+// Put stores data under key, evicting least recently used entries until
+// the cache fits within its size bound.
+func (c *Cache) Put(key string, data []byte) error {
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(c.dir, tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.size -= el.Value.(*entry).size
+		c.order.Remove(el)
+	}
+	c.entries[key] = c.order.PushFront(&entry{key: key, size: size})
+	c.size += size
+	c.evictLocked()
+
+	return nil
+}
+
+func (c *Cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.size -= el.Value.(*entry).size
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+	_ = os.Remove(c.path(key))
+	metrics.CompileCacheBytes.Set(float64(c.size))
+}
+
+func (c *Cache) evictLocked() {
+	for c.size > c.maxBytes {
+		el := c.order.Back()
+		if el == nil {
+			break
+		}
+		e := el.Value.(*entry)
+		c.order.Remove(el)
+		delete(c.entries, e.key)
+		c.size -= e.size
+		if err := os.Remove(c.path(e.key)); err != nil && !os.IsNotExist(err) {
+			c.logger.Warn().Err(err).Str("key", e.key).Msg("failed to remove evicted compile cache entry")
+		}
+		metrics.CompileCacheEvictions.Inc()
+	}
+	metrics.CompileCacheBytes.Set(float64(c.size))
+}
+
+// load rebuilds the index from the cache directory, ordering entries by
+// modification time. Only files the cache wrote are touched, so pointing
+// it at a directory that holds other files is safe.
+func (c *Cache) load() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	type file struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	files := make([]file, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		if !de.Type().IsRegular() {
+			continue
+		}
+		if strings.HasPrefix(de.Name(), tempPrefix) {
+			// Leftover temp files from an interrupted Put
+			_ = os.Remove(filepath.Join(c.dir, de.Name()))
+			continue
+		}
+		key, ok := strings.CutSuffix(de.Name(), ".tar")
+		if !ok || !isKey(key) {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{key: key, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range files {
+		c.entries[f.key] = c.order.PushBack(&entry{key: f.key, size: f.size})
+		c.size += f.size
+	}
+	c.evictLocked()
+
+	c.logger.Info().Int("entries", len(c.entries)).Int64("bytes", c.size).Msg("compile cache loaded")
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".tar")
+}
@@ -16,6 +16,18 @@ type Config struct {
 	Primary Primary        `koanf:"primary" validate:"required"`
 	Server  ServerConfig   `koanf:"server" validate:"required"`
 	Db      DatabaseConfig `koanf:"db" validate:"required"`
+	Cache   CacheConfig    `koanf:"cache"`
+	Queue   QueueConfig    `koanf:"queue"`
+	Sandbox SandboxConfig  `koanf:"sandbox"`
+	Limits  LimitsConfig   `koanf:"limits"`
+
+	Idempotency IdempotencyConfig `koanf:"idempotency"`
+	Admin       AdminConfig       `koanf:"admin"`
+	Auth        AuthConfig        `koanf:"auth"`
+	Workers     WorkersConfig     `koanf:"workers"`
+	Remote      RemoteConfig      `koanf:"remote"`
+	GRPC        GRPCConfig        `koanf:"grpc"`
+	Webhooks    WebhooksConfig    `koanf:"webhooks"`
 }
 
 type Primary struct {
@@ -28,6 +40,12 @@ type ServerConfig struct {
 	WriteTimeout       int      `koanf:"write_timeout" validate:"required"`
 	IdleTimeout        int      `koanf:"idle_timeout" validate:"required"`
 	CorsAllowedOrigins []string `koanf:"cors_allowed_origins" validate:"required"`
+	// ShutdownTimeout is how long running jobs get to finish on shutdown
+	ShutdownTimeout int `koanf:"shutdown_timeout" validate:"omitempty,min=1"` // in seconds
+	// TrustedProxies lists the addresses or CIDR ranges of the proxies
+	// whose X-Forwarded-For headers identify clients. Other connections
+	// are identified by their own address.
+	TrustedProxies []string `koanf:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -43,6 +61,128 @@ type DatabaseConfig struct {
 	ConnMaxIdleTime int    `koanf:"conn_max_idle_time" validate:"required"`
 }
 
+// CacheConfig configures the on-disk compile cache. The cache is disabled
+// when Dir is empty.
+type CacheConfig struct {
+	Dir       string `koanf:"dir"`
+	MaxSizeMb int    `koanf:"max_size_mb" validate:"omitempty,min=1"`
+}
+
+// SandboxConfig sets how often sandbox containers left behind by crashed
+// processes are looked for (default every minute).
+type SandboxConfig struct {
+	ReapInterval int `koanf:"reap_interval" validate:"omitempty,min=1"` // in seconds
+}
+
+// LimitsConfig bounds request sizes, in kilobytes. MaxBodyKb applies to
+// single requests and MaxBatchBodyKb to batches. Source code, stdin and
+// the expected output of Judge0 submissions are measured after base64
+// decoding. Zero fields keep their defaults.
+type LimitsConfig struct {
+	MaxBodyKb           int `koanf:"max_body_kb" validate:"omitempty,min=1"`
+	MaxBatchBodyKb      int `koanf:"max_batch_body_kb" validate:"omitempty,min=1"`
+	MaxSourceKb         int `koanf:"max_source_kb" validate:"omitempty,min=1"`
+	MaxStdinKb          int `koanf:"max_stdin_kb" validate:"omitempty,min=1"`
+	MaxExpectedOutputKb int `koanf:"max_expected_output_kb" validate:"omitempty,min=1"`
+}
+
+// QueueConfig selects the job queue. The default "memory" backend loses
+// queued jobs on restart; "postgres" persists them in the jobs table.
+type QueueConfig struct {
+	Backend        string `koanf:"backend" validate:"omitempty,oneof=memory postgres"`
+	Capacity       int    `koanf:"capacity" validate:"omitempty,min=1"`    // memory backend only
+	LeaseGrace     int    `koanf:"lease_grace" validate:"omitempty,min=1"` // in seconds
+	MaxAttempts    int    `koanf:"max_attempts" validate:"omitempty,min=1"`
+	PollIntervalMs int    `koanf:"poll_interval_ms" validate:"omitempty,min=1"`
+
+	// SubmitWaitMs bounds how long a request waits for room in a full
+	// memory queue; MaxDepth caps the postgres queue.
+	SubmitWaitMs int `koanf:"submit_wait_ms" validate:"omitempty,min=1"`
+	MaxDepth     int `koanf:"max_depth" validate:"omitempty,min=1"`
+
+	// Jobs hit by infrastructure errors are retried up to RetryMax times,
+	// waiting RetryBaseDelayMs, doubling up to RetryMaxDelayMs. RetryMax
+	// is a pointer so that 0, which turns retries off, differs from unset.
+	RetryMax         *int `koanf:"retry_max" validate:"omitempty,min=0"`
+	RetryBaseDelayMs int  `koanf:"retry_base_delay_ms" validate:"omitempty,min=1"`
+	RetryMaxDelayMs  int  `koanf:"retry_max_delay_ms" validate:"omitempty,min=1"`
+}
+
+// IdempotencyConfig controls result reuse. KeyTTL is how long a completed
+// Idempotency-Key replays its result (default 24h). DedupeTTL enables
+// reusing results for identical requests without a key; it is off when 0,
+// since it is only safe for deterministic programs.
+type IdempotencyConfig struct {
+	KeyTTL    int `koanf:"key_ttl" validate:"omitempty,min=1"`    // in seconds
+	DedupeTTL int `koanf:"dedupe_ttl" validate:"omitempty,min=0"` // in seconds
+}
+
+// WorkersConfig sizes the worker pool. Count workers run by default; with
+// Autoscale the pool starts at Count and is resized between Min and Max
+// from queue depth and host CPU and memory usage.
+type WorkersConfig struct {
+	Count           int     `koanf:"count" validate:"omitempty,min=1"`
+	Autoscale       bool    `koanf:"autoscale"`
+	Min             int     `koanf:"min" validate:"omitempty,min=1"`
+	Max             int     `koanf:"max" validate:"omitempty,min=1,gtefield=Min"`
+	ScaleIntervalMs int     `koanf:"scale_interval_ms" validate:"omitempty,min=100"`
+	MaxCPULoad      float64 `koanf:"max_cpu_load" validate:"omitempty,gt=0"`           // load average per core
+	MaxMemoryUsage  float64 `koanf:"max_memory_usage" validate:"omitempty,gt=0,lte=1"` // fraction in use
+
+	// DisableLocal runs no local workers, leaving all jobs to remote nodes.
+	DisableLocal bool `koanf:"disable_local"`
+}
+
+// RemoteConfig enables the listener remote worker nodes connect to. Nodes
+// authenticate with Token; TLSCert and TLSKey serve the listener over TLS.
+// A node silent for HeartbeatTimeoutMs is considered dead and its jobs
+// are reassigned.
+type RemoteConfig struct {
+	ListenAddr          string `koanf:"listen_addr"`
+	Token               string `koanf:"token" validate:"required_with=ListenAddr"`
+	TLSCert             string `koanf:"tls_cert" validate:"required_with=TLSKey"`
+	TLSKey              string `koanf:"tls_key" validate:"required_with=TLSCert"`
+	HeartbeatIntervalMs int    `koanf:"heartbeat_interval_ms" validate:"omitempty,min=100"`
+	HeartbeatTimeoutMs  int    `koanf:"heartbeat_timeout_ms" validate:"omitempty,min=100"`
+}
+
+// GRPCConfig enables the gRPC API on ListenAddr. TLSCert and TLSKey serve
+// it over TLS.
+type GRPCConfig struct {
+	ListenAddr string `koanf:"listen_addr"`
+	TLSCert    string `koanf:"tls_cert" validate:"required_with=TLSKey"`
+	TLSKey     string `koanf:"tls_key" validate:"required_with=TLSCert"`
+}
+
+// WebhooksConfig controls submission callbacks, which are refused while
+// Secret is empty. A failed callback is retried up to MaxRetries times,
+// waiting BaseDelayMs, doubling up to MaxDelayMs. Callbacks to private
+// and loopback addresses are refused unless AllowPrivateNetworks is set.
+// Finished submissions are kept for Retention.
+type WebhooksConfig struct {
+	Secret               string `koanf:"secret"`
+	MaxRetries           int    `koanf:"max_retries" validate:"omitempty,min=0"`
+	BaseDelayMs          int    `koanf:"base_delay_ms" validate:"omitempty,min=1"`
+	MaxDelayMs           int    `koanf:"max_delay_ms" validate:"omitempty,min=1"`
+	TimeoutMs            int    `koanf:"timeout_ms" validate:"omitempty,min=1"`
+	AllowPrivateNetworks bool   `koanf:"allow_private_networks"`
+	Retention            int    `koanf:"retention" validate:"omitempty,min=1"` // in seconds
+}
+
+// AdminConfig protects the /admin endpoints, which are disabled while
+// Token is empty.
+type AdminConfig struct {
+	Token string `koanf:"token"`
+}
+
+// AuthConfig controls API keys. Clients that send a key are identified by
+// its tenant and held to the tenant's quotas. Clients without one are
+// turned away unless AllowAnonymous is set, in which case they are served
+// without quotas.
+type AuthConfig struct {
+	AllowAnonymous bool `koanf:"allow_anonymous"`
+}
+
 func LoadConfig() (*Config, error) {
 	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
 
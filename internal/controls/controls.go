@@ -0,0 +1,117 @@
+// Package controls keeps the switches operators flip through the admin
+// API, pausing intake and disabling languages, in Postgres, so they apply
+// to every instance and survive restarts.
+package controls
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/itstheanurag/executioner/internal/languages"
+	"github.com/rs/zerolog"
+)
+
+// Pauser is the part of the queue that intake pausing switches.
+type Pauser interface {
+	SetPaused(paused bool)
+}
+
+// Store persists the switches and applies them to this instance's queue
+// and language registry. Changes made through another instance apply here
+// at the next Sync.
+type Store struct {
+	db       *database.Database
+	queue    Pauser
+	registry *languages.Registry
+	logger   *zerolog.Logger
+}
+
+func New(db *database.Database, q Pauser, registry *languages.Registry, logger *zerolog.Logger) *Store {
+	return &Store{
+		db:       db,
+		queue:    q,
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// SetPaused pauses or resumes intake on every instance.
+func (s *Store) SetPaused(ctx context.Context, paused bool) error {
+	if _, err := s.db.Pool.Exec(ctx, `UPDATE intake SET paused = $1`, paused); err != nil {
+		return fmt.Errorf("failed to set intake: %w", err)
+	}
+	s.queue.SetPaused(paused)
+	return nil
+}
+
+// SetDisabled disables or re-enables a language on every instance. It
+// returns languages.ErrLanguageNotFound for languages this instance does
+// not know.
+func (s *Store) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	if _, err := s.registry.Get(id); err != nil {
+		return err
+	}
+	var err error
+	if disabled {
+		_, err = s.db.Pool.Exec(ctx, `
+			INSERT INTO disabled_languages (language_id) VALUES ($1)
+			ON CONFLICT (language_id) DO NOTHING`, id)
+	} else {
+		_, err = s.db.Pool.Exec(ctx, `DELETE FROM disabled_languages WHERE language_id = $1`, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set language state: %w", err)
+	}
+	return s.registry.SetDisabled(id, disabled)
+}
+
+// Sync applies the stored switches to this instance.
+func (s *Store) Sync(ctx context.Context) error {
+	var paused bool
+	if err := s.db.Pool.QueryRow(ctx, `SELECT paused FROM intake`).Scan(&paused); err != nil {
+		return fmt.Errorf("failed to load intake: %w", err)
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `SELECT language_id FROM disabled_languages`)
+	if err != nil {
+		return fmt.Errorf("failed to load disabled languages: %w", err)
+	}
+	disabled := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to load disabled languages: %w", err)
+		}
+		disabled[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load disabled languages: %w", err)
+	}
+
+	s.queue.SetPaused(paused)
+	// Languages other instances have but this one lacks are skipped
+	for _, lang := range s.registry.List() {
+		_ = s.registry.SetDisabled(lang.ID, disabled[lang.ID])
+	}
+	return nil
+}
+
+// Start syncs the switches every interval, so changes made through other
+// instances reach this one.
+func (s *Store) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := s.Sync(ctx); err != nil {
+				s.logger.Error().Err(err).Msg("failed to sync operator controls")
+			}
+			cancel()
+		}
+	}()
+}
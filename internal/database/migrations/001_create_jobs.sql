@@ -0,0 +1,18 @@
+CREATE TABLE jobs (
+    id TEXT PRIMARY KEY,
+    state TEXT NOT NULL DEFAULT 'queued',
+    options JSONB NOT NULL,
+    timeout_ms INTEGER NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    leased_until TIMESTAMPTZ,
+    result JSONB,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX jobs_pending_idx ON jobs (created_at) WHERE state IN ('queued', 'running');
+
+---- create above / drop below ----
+
+DROP TABLE jobs;
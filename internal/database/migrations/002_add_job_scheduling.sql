@@ -0,0 +1,19 @@
+ALTER TABLE jobs
+    ADD COLUMN priority TEXT NOT NULL DEFAULT 'submission',
+    ADD COLUMN tenant TEXT NOT NULL DEFAULT '',
+    ADD COLUMN weight INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN vtime DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE INDEX jobs_vtime_idx ON jobs (vtime) WHERE state IN ('queued', 'running');
+CREATE INDEX jobs_stream_idx ON jobs (tenant, priority, vtime) WHERE state = 'queued';
+
+---- create above / drop below ----
+
+DROP INDEX jobs_stream_idx;
+DROP INDEX jobs_vtime_idx;
+
+ALTER TABLE jobs
+    DROP COLUMN vtime,
+    DROP COLUMN weight,
+    DROP COLUMN tenant,
+    DROP COLUMN priority;
@@ -0,0 +1,21 @@
+ALTER TABLE jobs
+    ADD COLUMN run_after TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN failures JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE dead_letters (
+    job_id TEXT PRIMARY KEY,
+    options JSONB NOT NULL,
+    priority TEXT NOT NULL,
+    tenant TEXT NOT NULL,
+    weight INTEGER NOT NULL,
+    failures JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+---- create above / drop below ----
+
+DROP TABLE dead_letters;
+
+ALTER TABLE jobs
+    DROP COLUMN failures,
+    DROP COLUMN run_after;
@@ -0,0 +1,41 @@
+ALTER TABLE jobs
+    ADD COLUMN tracked BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE submissions (
+    id TEXT PRIMARY KEY,
+    tenant TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued',
+    result JSONB,
+    error TEXT,
+    deadline TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    callback_url TEXT,
+    callback_state TEXT,
+    callback_attempts INTEGER NOT NULL DEFAULT 0,
+    next_callback_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX submissions_unfinished_idx ON submissions (deadline) WHERE status = 'queued';
+CREATE INDEX submissions_callbacks_due_idx ON submissions (next_callback_at) WHERE callback_state = 'pending';
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    submission_id TEXT NOT NULL REFERENCES submissions (id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    duration_ms BIGINT NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhook_deliveries_submission_idx ON webhook_deliveries (submission_id);
+
+---- create above / drop below ----
+
+DROP TABLE webhook_deliveries;
+DROP TABLE submissions;
+
+ALTER TABLE jobs
+    DROP COLUMN tracked;
@@ -0,0 +1,20 @@
+CREATE TABLE batches (
+    id TEXT PRIMARY KEY,
+    tenant TEXT NOT NULL,
+    total INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE submissions
+    ADD COLUMN batch_id TEXT REFERENCES batches (id) ON DELETE CASCADE,
+    ADD COLUMN position INTEGER;
+
+CREATE INDEX submissions_batch_idx ON submissions (batch_id, position) WHERE batch_id IS NOT NULL;
+
+---- create above / drop below ----
+
+ALTER TABLE submissions
+    DROP COLUMN position,
+    DROP COLUMN batch_id;
+
+DROP TABLE batches;
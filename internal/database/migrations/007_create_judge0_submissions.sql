@@ -0,0 +1,12 @@
+CREATE TABLE judge0_submissions (
+    token TEXT PRIMARY KEY,
+    language_id INTEGER NOT NULL,
+    source_code BYTEA NOT NULL,
+    stdin BYTEA NOT NULL,
+    expected_output BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+---- create above / drop below ----
+
+DROP TABLE judge0_submissions;
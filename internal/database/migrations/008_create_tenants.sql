@@ -0,0 +1,34 @@
+CREATE TABLE tenants (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    executions_per_day INTEGER NOT NULL DEFAULT 0,
+    cpu_seconds_per_month BIGINT NOT NULL DEFAULT 0,
+    max_time_limit_ms INTEGER NOT NULL DEFAULT 0,
+    max_memory_limit_kb INTEGER NOT NULL DEFAULT 0,
+    allowed_languages TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    hash BYTEA NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX api_keys_tenant_idx ON api_keys (tenant_id);
+
+CREATE TABLE tenant_usage (
+    tenant_id TEXT NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    executions INTEGER NOT NULL DEFAULT 0,
+    cpu_ms BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, day)
+);
+
+---- create above / drop below ----
+
+DROP TABLE tenant_usage;
+DROP TABLE api_keys;
+DROP TABLE tenants;
@@ -0,0 +1,16 @@
+CREATE TABLE intake (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    paused BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+INSERT INTO intake DEFAULT VALUES;
+
+CREATE TABLE disabled_languages (
+    language_id TEXT PRIMARY KEY,
+    disabled_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+---- create above / drop below ----
+
+DROP TABLE disabled_languages;
+DROP TABLE intake;
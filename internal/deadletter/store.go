@@ -0,0 +1,147 @@
+// Package deadletter keeps jobs that failed every retry because of
+// infrastructure errors, so an operator can inspect and requeue them once
+// the underlying problem is fixed.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotFound is returned for unknown dead-letter entries.
+var ErrNotFound = errors.New("dead letter not found")
+
+// Entry is a dead-lettered job.
+type Entry struct {
+	JobID     string                  `json:"job_id"`
+	Options   executor.ExecuteOptions `json:"options"`
+	Priority  string                  `json:"priority"`
+	Tenant    string                  `json:"tenant"`
+	Weight    int                     `json:"weight"`
+	Failures  []queue.Failure         `json:"failures"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+// Job rebuilds a queue job from the entry under a new ID.
+func (e *Entry) Job(id string) *queue.Job {
+	return &queue.Job{
+		ID:       id,
+		Options:  e.Options,
+		Ctx:      context.Background(),
+		Priority: e.Priority,
+		Tenant:   e.Tenant,
+		Weight:   e.Weight,
+	}
+}
+
+type Store struct {
+	db *database.Database
+}
+
+func New(db *database.Database) *Store {
+	return &Store{db: db}
+}
+
+// Add records a job that ran out of retries.
+func (s *Store) Add(ctx context.Context, job *queue.Job) error {
+	options, err := json.Marshal(job.Options)
+	if err != nil {
+		return fmt.Errorf("failed to encode job options: %w", err)
+	}
+	failures, err := json.Marshal(job.Failures)
+	if err != nil {
+		return fmt.Errorf("failed to encode job failures: %w", err)
+	}
+
+	_, err = s.db.Pool.Exec(ctx, `
+		INSERT INTO dead_letters (job_id, options, priority, tenant, weight, failures)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (job_id) DO UPDATE
+		SET failures = EXCLUDED.failures, created_at = now()`,
+		job.ID, options, job.Priority, job.Tenant, job.Weight, failures,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+	metrics.DeadLetters.Inc()
+	return nil
+}
+
+// List returns up to limit entries, newest first.
+func (s *Store) List(ctx context.Context, limit int) ([]*Entry, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT job_id, options, priority, tenant, weight, failures, created_at
+		FROM dead_letters
+		ORDER BY created_at DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	entries, err := pgx.CollectRows(rows, scanEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return entries, nil
+}
+
+// Get returns the entry for a job.
+func (s *Store) Get(ctx context.Context, jobID string) (*Entry, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT job_id, options, priority, tenant, weight, failures, created_at
+		FROM dead_letters
+		WHERE job_id = $1`,
+		jobID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	entry, err := pgx.CollectExactlyOneRow(rows, scanEntry)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return entry, nil
+}
+
+// Delete removes the entry for a job, e.g. after it has been requeued.
+func (s *Store) Delete(ctx context.Context, jobID string) error {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM dead_letters WHERE job_id = $1`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanEntry(row pgx.CollectableRow) (*Entry, error) {
+	var (
+		e        Entry
+		options  []byte
+		failures []byte
+	)
+	if err := row.Scan(&e.JobID, &options, &e.Priority, &e.Tenant, &e.Weight, &failures, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &e.Options); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(failures, &e.Failures); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
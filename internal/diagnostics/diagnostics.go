@@ -0,0 +1,94 @@
+package diagnostics
+
+import (
+	"path"
+	"strings"
+)
+
+// Output formats understood by Parse.
+const (
+	// FormatGCC is "file:line[:col]: [severity:] message", used by GCC,
+	// Clang, the Go toolchain, kotlinc and Ruby.
+	FormatGCC = "gcc"
+	// FormatMSBuild is "file(line,col): severity CODE: message", used by
+	// tsc and mcs.
+	FormatMSBuild = "msbuild"
+	// FormatJava covers javac errors and JVM exception stack traces.
+	FormatJava = "java"
+	// FormatRustcJSON is rustc's --error-format=json output.
+	FormatRustcJSON = "rustc-json"
+	// FormatPython covers tracebacks, including SyntaxError reports.
+	FormatPython = "python"
+	// FormatNode covers Node.js syntax errors and uncaught exceptions.
+	FormatNode = "node"
+)
+
+// Severity values.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNote    = "note"
+)
+
+// Diagnostic is a single compiler or runtime message tied to a source
+// location. Line and Column are 1-based; zero means unknown.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Parse extracts diagnostics from output in the given format. Unknown
+// formats and unrecognised output yield no diagnostics. Messages are text
+// that must fit in jsonb, so NUL bytes and bytes that are not valid UTF-8
+// are replaced.
+func Parse(format, output string) []Diagnostic {
+	output = strings.ReplaceAll(strings.ToValidUTF8(output, "\uFFFD"), "\x00", "\uFFFD")
+	switch format {
+	case FormatGCC:
+		return parseGCC(output)
+	case FormatMSBuild:
+		return parseMSBuild(output)
+	case FormatJava:
+		return parseJava(output)
+	case FormatRustcJSON:
+		return parseRustcJSON(output)
+	case FormatPython:
+		return parsePython(output)
+	case FormatNode:
+		return parseNode(output)
+	}
+	return nil
+}
+
+// Render converts machine-readable output into the text a user would see
+// from the tool. Human-readable formats are returned unchanged.
+func Render(format, output string) string {
+	if format == FormatRustcJSON {
+		return renderRustcJSON(output)
+	}
+	return output
+}
+
+// cleanPath strips the sandbox workspace prefix so that files are reported
+// relative to the submission.
+func cleanPath(p string) string {
+	p = strings.TrimPrefix(p, "/home/sandbox/")
+	if strings.HasPrefix(p, "./") {
+		p = path.Clean(p)
+	}
+	return p
+}
+
+func normalizeSeverity(s string) string {
+	switch strings.ToLower(s) {
+	case "warning":
+		return SeverityWarning
+	case "note", "help", "info":
+		return SeverityNote
+	default:
+		return SeverityError
+	}
+}
@@ -0,0 +1,275 @@
+package diagnostics
+
+import (
+	"bufio"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var gccLine = regexp.MustCompile(`^([^\s:][^:]*\.[A-Za-z0-9]+):(\d+):(?:(\d+):)?\s*(?:(fatal error|error|warning|note):\s*)?(.+)$`)
+
+func parseGCC(output string) []Diagnostic {
+	var diags []Diagnostic
+	for _, line := range lines(output) {
+		m := gccLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			File:     cleanPath(m[1]),
+			Line:     atoi(m[2]),
+			Column:   atoi(m[3]),
+			Severity: normalizeSeverity(m[4]),
+			Message:  strings.TrimSpace(m[5]),
+		})
+	}
+	return diags
+}
+
+var msbuildLine = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\):\s*(error|warning|info)\s+([A-Z]+\d+)?:?\s*(.*)$`)
+
+func parseMSBuild(output string) []Diagnostic {
+	var diags []Diagnostic
+	for _, line := range lines(output) {
+		m := msbuildLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		msg := strings.TrimSpace(m[6])
+		if m[5] != "" {
+			msg = m[5] + ": " + msg
+		}
+		diags = append(diags, Diagnostic{
+			File:     cleanPath(m[1]),
+			Line:     atoi(m[2]),
+			Column:   atoi(m[3]),
+			Severity: normalizeSeverity(m[4]),
+			Message:  msg,
+		})
+	}
+	return diags
+}
+
+var (
+	javacLine     = regexp.MustCompile(`^(.+\.java):(\d+):\s*(error|warning):\s*(.+)$`)
+	jvmException  = regexp.MustCompile(`^Exception in thread "[^"]*"\s+(.+)$`)
+	jvmStackFrame = regexp.MustCompile(`^\s+at .+\((\w+\.(?:java|kt)):(\d+)\)$`)
+)
+
+// parseJava handles javac output, where the column is given by a caret on
+// the line after the quoted source, and JVM stack traces, where the first
+// frame in a user file locates the exception.
+func parseJava(output string) []Diagnostic {
+	var diags []Diagnostic
+	ls := lines(output)
+	for i := 0; i < len(ls); i++ {
+		if m := javacLine.FindStringSubmatch(ls[i]); m != nil {
+			d := Diagnostic{
+				File:     cleanPath(m[1]),
+				Line:     atoi(m[2]),
+				Severity: normalizeSeverity(m[3]),
+				Message:  strings.TrimSpace(m[4]),
+			}
+			if i+2 < len(ls) && strings.TrimSpace(ls[i+2]) == "^" {
+				d.Column = strings.Index(ls[i+2], "^") + 1
+			}
+			diags = append(diags, d)
+			continue
+		}
+
+		if m := jvmException.FindStringSubmatch(ls[i]); m != nil {
+			d := Diagnostic{Severity: SeverityError, Message: strings.TrimSpace(m[1])}
+			for j := i + 1; j < len(ls); j++ {
+				f := jvmStackFrame.FindStringSubmatch(ls[j])
+				if f == nil {
+					if !strings.HasPrefix(strings.TrimSpace(ls[j]), "at ") {
+						break
+					}
+					continue
+				}
+				d.File = f[1]
+				d.Line = atoi(f[2])
+				break
+			}
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
+
+type rustcMessage struct {
+	MessageType string `json:"$message_type"`
+	Message     string `json:"message"`
+	Level       string `json:"level"`
+	Code        *struct {
+		Code string `json:"code"`
+	} `json:"code"`
+	Spans []struct {
+		FileName    string `json:"file_name"`
+		LineStart   int    `json:"line_start"`
+		ColumnStart int    `json:"column_start"`
+		IsPrimary   bool   `json:"is_primary"`
+	} `json:"spans"`
+	Rendered string `json:"rendered"`
+}
+
+func rustcMessages(output string) ([]rustcMessage, []string) {
+	var msgs []rustcMessage
+	var other []string
+	for _, line := range lines(output) {
+		var msg rustcMessage
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &msg) != nil {
+			other = append(other, line)
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, other
+}
+
+func parseRustcJSON(output string) []Diagnostic {
+	msgs, _ := rustcMessages(output)
+	var diags []Diagnostic
+	for _, msg := range msgs {
+		for _, span := range msg.Spans {
+			if !span.IsPrimary {
+				continue
+			}
+			text := msg.Message
+			if msg.Code != nil && msg.Code.Code != "" {
+				text = msg.Code.Code + ": " + text
+			}
+			diags = append(diags, Diagnostic{
+				File:     cleanPath(span.FileName),
+				Line:     span.LineStart,
+				Column:   span.ColumnStart,
+				Severity: normalizeSeverity(msg.Level),
+				Message:  text,
+			})
+			break
+		}
+	}
+	return diags
+}
+
+func renderRustcJSON(output string) string {
+	msgs, other := rustcMessages(output)
+	if len(msgs) == 0 {
+		return output
+	}
+	var b strings.Builder
+	for _, msg := range msgs {
+		b.WriteString(msg.Rendered)
+	}
+	for _, line := range other {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+var (
+	pythonFrame     = regexp.MustCompile(`^\s*File "(.+)", line (\d+)`)
+	pythonException = regexp.MustCompile(`^(\w+(?:\.\w+)*(?:Error|Exception|Exit|Interrupt|Warning)|\w+Error)(?::\s*(.*))?$`)
+)
+
+// parsePython reports the innermost frame of a traceback together with the
+// exception on its final line.
+func parsePython(output string) []Diagnostic {
+	var d *Diagnostic
+	var caretCol int
+	ls := lines(output)
+	for i, line := range ls {
+		if m := pythonFrame.FindStringSubmatch(line); m != nil {
+			d = &Diagnostic{File: cleanPath(m[1]), Line: atoi(m[2]), Severity: SeverityError}
+			caretCol = 0
+			// SyntaxError reports quote the line and mark the column
+			if i+2 < len(ls) && strings.Contains(ls[i+2], "^") && strings.Trim(ls[i+2], " ^~") == "" {
+				code := ls[i+1]
+				indent := len(code) - len(strings.TrimLeft(code, " "))
+				caretCol = strings.Index(ls[i+2], "^") - indent + 1
+			}
+			continue
+		}
+		if d != nil {
+			if m := pythonException.FindStringSubmatch(line); m != nil {
+				d.Message = m[1]
+				if m[2] != "" {
+					d.Message += ": " + m[2]
+				}
+				if caretCol > 0 {
+					d.Column = caretCol
+				}
+			}
+		}
+	}
+	if d == nil || d.Message == "" {
+		return nil
+	}
+	return []Diagnostic{*d}
+}
+
+var (
+	nodeLocation = regexp.MustCompile(`^(.+\.[cm]?[jt]s):(\d+)$`)
+	nodeError    = regexp.MustCompile(`^(?:Uncaught )?(\w*Error|\w*Exception)(?::\s*(.*))?$`)
+	nodeFrame    = regexp.MustCompile(`\(?((?:/|\./)?[^\s()]+\.[cm]?[jt]s):(\d+):(\d+)\)?$`)
+)
+
+// parseNode uses the "file:line" header Node prints above an uncaught
+// error, the error line itself and the first stack frame for the column.
+func parseNode(output string) []Diagnostic {
+	var d Diagnostic
+	found := false
+	ls := lines(output)
+	for i, line := range ls {
+		if m := nodeLocation.FindStringSubmatch(line); m != nil && d.File == "" {
+			d.File = cleanPath(m[1])
+			d.Line = atoi(m[2])
+			// The quoted source and a caret follow the header
+			if i+2 < len(ls) && strings.Trim(ls[i+2], " ^") == "" && strings.Contains(ls[i+2], "^") {
+				d.Column = strings.Index(ls[i+2], "^") + 1
+			}
+			continue
+		}
+		if m := nodeError.FindStringSubmatch(line); m != nil && !found {
+			found = true
+			d.Severity = SeverityError
+			d.Message = m[1]
+			if m[2] != "" {
+				d.Message += ": " + m[2]
+			}
+			continue
+		}
+		if found && strings.HasPrefix(strings.TrimSpace(line), "at ") && !strings.Contains(line, "node:") {
+			if m := nodeFrame.FindStringSubmatch(line); m != nil {
+				if d.File == "" || d.File == cleanPath(m[1]) {
+					d.File = cleanPath(m[1])
+					d.Line = atoi(m[2])
+					d.Column = atoi(m[3])
+				}
+				break
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+	return []Diagnostic{d}
+}
+
+func lines(output string) []string {
+	var res []string
+	sc := bufio.NewScanner(strings.NewReader(output))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		res = append(res, strings.TrimRight(sc.Text(), "\r"))
+	}
+	return res
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
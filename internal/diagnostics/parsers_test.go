@@ -0,0 +1,241 @@
+package diagnostics
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		output string
+		want   []Diagnostic
+	}{
+		{
+			name:   "g++ error and warning",
+			format: FormatGCC,
+			output: `solution.cpp: In function 'int main()':
+solution.cpp:4:5: error: 'foo' was not declared in this scope
+    4 |     foo();
+      |     ^~~
+solution.cpp:3:9: warning: unused variable 'x' [-Wunused-variable]
+    3 |     int x;
+      |         ^
+`,
+			want: []Diagnostic{
+				{File: "solution.cpp", Line: 4, Column: 5, Severity: SeverityError, Message: "'foo' was not declared in this scope"},
+				{File: "solution.cpp", Line: 3, Column: 9, Severity: SeverityWarning, Message: "unused variable 'x' [-Wunused-variable]"},
+			},
+		},
+		{
+			name:   "gcc fatal error",
+			format: FormatGCC,
+			output: `solution.c:1:10: fatal error: missing.h: No such file or directory
+    1 | #include "missing.h"
+      |          ^~~~~~~~~~~
+compilation terminated.
+`,
+			want: []Diagnostic{
+				{File: "solution.c", Line: 1, Column: 10, Severity: SeverityError, Message: "missing.h: No such file or directory"},
+			},
+		},
+		{
+			name:   "go build",
+			format: FormatGCC,
+			output: `# command-line-arguments
+./solution.go:6:2: undefined: foo
+./solution.go:4:2: "os" imported and not used
+`,
+			want: []Diagnostic{
+				{File: "solution.go", Line: 6, Column: 2, Severity: SeverityError, Message: "undefined: foo"},
+				{File: "solution.go", Line: 4, Column: 2, Severity: SeverityError, Message: `"os" imported and not used`},
+			},
+		},
+		{
+			name:   "kotlinc",
+			format: FormatGCC,
+			output: `solution.kt:2:5: error: unresolved reference: foo
+    foo()
+    ^
+`,
+			want: []Diagnostic{
+				{File: "solution.kt", Line: 2, Column: 5, Severity: SeverityError, Message: "unresolved reference: foo"},
+			},
+		},
+		{
+			name:   "ruby without a column",
+			format: FormatGCC,
+			output: `solution.rb:1:in '<main>': undefined local variable or method 'foo' for main:Object (NameError)
+`,
+			want: []Diagnostic{
+				{File: "solution.rb", Line: 1, Severity: SeverityError, Message: "in '<main>': undefined local variable or method 'foo' for main:Object (NameError)"},
+			},
+		},
+		{
+			name:   "tsc",
+			format: FormatMSBuild,
+			output: `solution.ts(1,7): error TS2322: Type 'string' is not assignable to type 'number'.
+`,
+			want: []Diagnostic{
+				{File: "solution.ts", Line: 1, Column: 7, Severity: SeverityError, Message: "TS2322: Type 'string' is not assignable to type 'number'."},
+			},
+		},
+		{
+			name:   "mcs",
+			format: FormatMSBuild,
+			output: `solution.cs(5,9): error CS0103: The name ` + "`foo'" + ` does not exist in the current context
+solution.cs(4,13): warning CS0168: The variable ` + "`x'" + ` is declared but never used
+Compilation failed: 1 error(s), 1 warnings
+`,
+			want: []Diagnostic{
+				{File: "solution.cs", Line: 5, Column: 9, Severity: SeverityError, Message: "CS0103: The name `foo' does not exist in the current context"},
+				{File: "solution.cs", Line: 4, Column: 13, Severity: SeverityWarning, Message: "CS0168: The variable `x' is declared but never used"},
+			},
+		},
+		{
+			name:   "javac",
+			format: FormatJava,
+			output: `Main.java:3: error: cannot find symbol
+        foo();
+        ^
+  symbol:   method foo()
+  location: class Main
+1 error
+`,
+			want: []Diagnostic{
+				{File: "Main.java", Line: 3, Column: 9, Severity: SeverityError, Message: "cannot find symbol"},
+			},
+		},
+		{
+			name:   "jvm exception",
+			format: FormatJava,
+			output: `Exception in thread "main" java.lang.ArithmeticException: / by zero
+	at Main.divide(Main.java:8)
+	at Main.main(Main.java:4)
+`,
+			want: []Diagnostic{
+				{File: "Main.java", Line: 8, Severity: SeverityError, Message: "java.lang.ArithmeticException: / by zero"},
+			},
+		},
+		{
+			name:   "kotlin exception below library frames",
+			format: FormatJava,
+			output: `Exception in thread "main" java.lang.NullPointerException
+	at kotlin.jvm.internal.Intrinsics.checkNotNull(Intrinsics.java)
+	at SolutionKt.main(solution.kt:2)
+	at SolutionKt.main(solution.kt)
+`,
+			want: []Diagnostic{
+				{File: "solution.kt", Line: 2, Severity: SeverityError, Message: "java.lang.NullPointerException"},
+			},
+		},
+		{
+			name:   "rustc json",
+			format: FormatRustcJSON,
+			output: `{"$message_type":"diagnostic","message":"cannot find value ` + "`x`" + ` in this scope","code":{"code":"E0425","explanation":null},"level":"error","spans":[{"file_name":"solution.rs","byte_start":31,"byte_end":32,"line_start":2,"line_end":2,"column_start":20,"column_end":21,"is_primary":true}],"children":[],"rendered":"error[E0425]: cannot find value ` + "`x`" + ` in this scope\n --> solution.rs:2:20\n"}
+{"$message_type":"diagnostic","message":"unused variable: ` + "`y`" + `","code":null,"level":"warning","spans":[{"file_name":"solution.rs","byte_start":20,"byte_end":21,"line_start":1,"line_end":1,"column_start":9,"column_end":10,"is_primary":true}],"children":[],"rendered":"warning: unused variable\n"}
+{"$message_type":"diagnostic","message":"aborting due to 1 previous error","code":null,"level":"error","spans":[],"children":[],"rendered":"error: aborting due to 1 previous error\n\n"}
+`,
+			want: []Diagnostic{
+				{File: "solution.rs", Line: 2, Column: 20, Severity: SeverityError, Message: "E0425: cannot find value `x` in this scope"},
+				{File: "solution.rs", Line: 1, Column: 9, Severity: SeverityWarning, Message: "unused variable: `y`"},
+			},
+		},
+		{
+			name:   "python syntax error",
+			format: FormatPython,
+			output: `  File "solution.py", line 1
+    print("hi"
+         ^
+SyntaxError: '(' was never closed
+`,
+			want: []Diagnostic{
+				{File: "solution.py", Line: 1, Column: 6, Severity: SeverityError, Message: "SyntaxError: '(' was never closed"},
+			},
+		},
+		{
+			name:   "python traceback",
+			format: FormatPython,
+			output: `Traceback (most recent call last):
+  File "/home/sandbox/solution.py", line 4, in <module>
+    main()
+  File "/home/sandbox/solution.py", line 2, in main
+    raise ValueError("bad input")
+ValueError: bad input
+`,
+			want: []Diagnostic{
+				{File: "solution.py", Line: 2, Severity: SeverityError, Message: "ValueError: bad input"},
+			},
+		},
+		{
+			name:   "node uncaught error",
+			format: FormatNode,
+			output: `/home/sandbox/solution.js:2
+  throw new Error("boom");
+  ^
+
+Error: boom
+    at Object.<anonymous> (/home/sandbox/solution.js:2:9)
+    at Module._compile (node:internal/modules/cjs/loader:1256:14)
+    at node:internal/main/run_main_module:23:47
+
+Node.js v20.11.1
+`,
+			want: []Diagnostic{
+				{File: "solution.js", Line: 2, Column: 9, Severity: SeverityError, Message: "Error: boom"},
+			},
+		},
+		{
+			name:   "node syntax check",
+			format: FormatNode,
+			output: `/home/sandbox/solution.js:1
+console.log("hi"
+            ^^^^
+
+SyntaxError: missing ) after argument list
+    at internalCompileFunction (node:internal/vm:76:18)
+    at wrapSafe (node:internal/modules/cjs/loader:1283:20)
+
+Node.js v20.11.1
+`,
+			want: []Diagnostic{
+				{File: "solution.js", Line: 1, Column: 13, Severity: SeverityError, Message: "SyntaxError: missing ) after argument list"},
+			},
+		},
+		{
+			name:   "unrecognised output",
+			format: FormatGCC,
+			output: "collect2: error: ld returned 1 exit status\n",
+		},
+		{
+			name:   "unknown format",
+			format: "cobol",
+			output: "solution.cob:1:1: error: nope\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.format, tt.output)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderRustcJSON(t *testing.T) {
+	output := `{"$message_type":"diagnostic","message":"mismatched types","code":{"code":"E0308","explanation":null},"level":"error","spans":[],"children":[],"rendered":"error[E0308]: mismatched types\n"}
+note: some linker output
+`
+	want := "error[E0308]: mismatched types\nnote: some linker output\n"
+	if got := Render(FormatRustcJSON, output); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	// Other formats are shown as they are
+	if got := Render(FormatGCC, "a.c:1:1: error: x\n"); got != "a.c:1:1: error: x\n" {
+		t.Errorf("Render() changed gcc output: %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/itstheanurag/executioner/internal/languages"
+)
+
+const (
+	maxArgs      = 32
+	maxArgLength = 256
+)
+
+// ArgumentError reports a compiler flag or program argument that the
+// language does not permit.
+type ArgumentError struct {
+	Language string
+	// Field is the option the argument came from: "mode",
+	// "compiler_options" or "args".
+	Field  string
+	Arg    string
+	Reason string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("argument %q rejected for %s: %s", e.Arg, e.Language, e.Reason)
+}
+
+// Commands returns the compile and run commands for opts after checking
+// the requested compiler flags against the language allowlist. Commands
+// are executed without a shell, so program arguments only need to be
+// bounded in size. Outside ModeRun the run command is nil and the compile
+// command is the step the mode selects.
+func (e *Executor) Commands(opts ExecuteOptions) (compile, run []string, err error) {
+	lang, err := e.language(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch opts.Mode {
+	case ModeRun, ModeCompile, ModeCheck:
+	default:
+		return nil, nil, &ArgumentError{Language: lang.ID, Field: "mode", Arg: opts.Mode, Reason: "unknown mode"}
+	}
+
+	if len(opts.CompilerOptions) > 0 && !lang.Config.Compiled() {
+		return nil, nil, &ArgumentError{Language: lang.ID, Field: "compiler_options", Arg: opts.CompilerOptions[0], Reason: "language is not compiled"}
+	}
+	if len(opts.CompilerOptions) > maxArgs {
+		return nil, nil, &ArgumentError{Language: lang.ID, Field: "compiler_options", Arg: opts.CompilerOptions[maxArgs], Reason: fmt.Sprintf("at most %d compiler options are allowed", maxArgs)}
+	}
+	for _, flag := range opts.CompilerOptions {
+		if !lang.Config.CompileFlagAllowed(flag) {
+			return nil, nil, &ArgumentError{Language: lang.ID, Field: "compiler_options", Arg: flag, Reason: "compiler option is not allowed"}
+		}
+	}
+
+	if len(opts.Args) > maxArgs {
+		return nil, nil, &ArgumentError{Language: lang.ID, Field: "args", Arg: opts.Args[maxArgs], Reason: fmt.Sprintf("at most %d arguments are allowed", maxArgs)}
+	}
+	for _, arg := range opts.Args {
+		if len(arg) > maxArgLength {
+			return nil, nil, &ArgumentError{Language: lang.ID, Field: "args", Arg: arg[:32] + "...", Reason: fmt.Sprintf("arguments are limited to %d bytes", maxArgLength)}
+		}
+		if strings.ContainsRune(arg, 0) {
+			return nil, nil, &ArgumentError{Language: lang.ID, Field: "args", Arg: arg, Reason: "arguments must not contain NUL bytes"}
+		}
+	}
+
+	compileCmd := lang.Config.CompileCommandWith(opts.CompilerOptions)
+	checkCmd := lang.Config.SyntaxCheckCommandWith(opts.CompilerOptions)
+
+	switch opts.Mode {
+	case ModeCompile:
+		compile = compileCmd
+		if compile == nil {
+			compile = checkCmd
+		}
+	case ModeCheck:
+		compile = checkCmd
+		if compile == nil {
+			compile = compileCmd
+		}
+	default:
+		return compileCmd, lang.Config.RunCommandWith(opts.Args), nil
+	}
+
+	if compile == nil {
+		return nil, nil, &ArgumentError{Language: lang.ID, Field: "mode", Arg: opts.Mode, Reason: "language has no compile or syntax check step"}
+	}
+	return compile, nil, nil
+}
+
+// language returns the language of opts, configured for its source code.
+func (e *Executor) language(opts ExecuteOptions) (languages.Language, error) {
+	lang, err := e.registry.Get(opts.LanguageID)
+	if err != nil {
+		return lang, err
+	}
+	lang.Config = lang.Config.ForSource(opts.SourceCode)
+	return lang, nil
+}
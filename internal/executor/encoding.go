@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// EncodingBase64 marks options and results whose text fields are
+// base64-encoded in their JSON. Source code, stdin and output are
+// arbitrary bytes, which JSON strings can only carry as valid UTF-8, and
+// Postgres jsonb only without NUL; when any of them is not, they are all
+// encoded, so no byte is lost in queues, stores and responses.
+const EncodingBase64 = "base64"
+
+// Binary reports whether any of values is not valid UTF-8 or contains a
+// NUL byte.
+func Binary(values ...string) bool {
+	for _, v := range values {
+		if binary(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func binary(v string) bool {
+	return !utf8.ValidString(v) || strings.IndexByte(v, 0) >= 0
+}
+
+// encodeText base64-encodes fields if any of them is binary, and returns
+// the encoding used.
+func encodeText(fields ...*string) string {
+	for _, f := range fields {
+		if !binary(*f) {
+			continue
+		}
+		for _, f := range fields {
+			*f = base64.StdEncoding.EncodeToString([]byte(*f))
+		}
+		return EncodingBase64
+	}
+	return ""
+}
+
+// decodeText reverses encodeText.
+func decodeText(encoding string, fields ...*string) error {
+	switch encoding {
+	case "":
+		return nil
+	case EncodingBase64:
+		for _, f := range fields {
+			decoded, err := base64.StdEncoding.DecodeString(*f)
+			if err != nil {
+				return err
+			}
+			*f = string(decoded)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown encoding %q", encoding)
+}
+
+func (o ExecuteOptions) MarshalJSON() ([]byte, error) {
+	type plain ExecuteOptions
+	out := struct {
+		plain
+		Encoding string `json:",omitempty"`
+	}{plain: plain(o)}
+	out.Encoding = encodeText(&out.SourceCode, &out.Stdin)
+	return json.Marshal(out)
+}
+
+func (o *ExecuteOptions) UnmarshalJSON(data []byte) error {
+	type plain ExecuteOptions
+	var in struct {
+		plain
+		Encoding string
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := decodeText(in.Encoding, &in.SourceCode, &in.Stdin); err != nil {
+		return err
+	}
+	*o = ExecuteOptions(in.plain)
+	return nil
+}
+
+func (r ExecutionResult) MarshalJSON() ([]byte, error) {
+	type plain ExecutionResult
+	out := struct {
+		plain
+		Encoding string `json:",omitempty"`
+	}{plain: plain(r)}
+	out.Encoding = encodeText(&out.Stdout, &out.Stderr)
+	return json.Marshal(out)
+}
+
+func (r *ExecutionResult) UnmarshalJSON(data []byte) error {
+	type plain ExecutionResult
+	var in struct {
+		plain
+		Encoding string
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := decodeText(in.Encoding, &in.Stdout, &in.Stderr); err != nil {
+		return err
+	}
+	*r = ExecutionResult(in.plain)
+	return nil
+}
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"hello\n", false},
+		{"héllo ✓", false},
+		{"\xff\xfe", true},
+		{"a\x00b", true},
+		{"\x00", true},
+	}
+	for _, tt := range tests {
+		if got := Binary(tt.value); got != tt.want {
+			t.Errorf("Binary(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	if !Binary("text", "a\x00b") {
+		t.Error("Binary with one NUL-containing value = false, want true")
+	}
+}
+
+func TestOptionsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ExecuteOptions
+		encoded bool
+	}{
+		{"text", ExecuteOptions{LanguageID: "python", SourceCode: "print(input())", Stdin: "hi\n"}, false},
+		{"invalid UTF-8", ExecuteOptions{LanguageID: "c", SourceCode: "int main(){}", Stdin: "\xff\x00\x01"}, true},
+		{"NUL", ExecuteOptions{LanguageID: "c", SourceCode: "char s[] = \"a\x00b\";", Stdin: "x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Postgres jsonb rejects the \u0000 escape
+			if strings.Contains(string(data), `\u0000`) {
+				t.Errorf("JSON %s contains \\u0000", data)
+			}
+			if encoded := strings.Contains(string(data), `"Encoding":"base64"`); encoded != tt.encoded {
+				t.Errorf("JSON %s encoded = %v, want %v", data, encoded, tt.encoded)
+			}
+
+			var got ExecuteOptions
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.SourceCode != tt.opts.SourceCode || got.Stdin != tt.opts.Stdin || got.LanguageID != tt.opts.LanguageID {
+				t.Errorf("round trip = %+v, want %+v", got, tt.opts)
+			}
+		})
+	}
+}
+
+func TestResultRoundTrip(t *testing.T) {
+	res := ExecutionResult{Status: "success", Stdout: "\x00\x01binary\x00", Stderr: "warning\n", TimeMs: 12}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `\u0000`) {
+		t.Errorf("JSON %s contains \\u0000", data)
+	}
+
+	var got ExecutionResult
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Stdout != res.Stdout || got.Stderr != res.Stderr || got.Status != res.Status || got.TimeMs != res.TimeMs {
+		t.Errorf("round trip = %+v, want %+v", got, res)
+	}
+}
@@ -2,8 +2,11 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
+	"github.com/itstheanurag/executioner/internal/diagnostics"
 	"github.com/itstheanurag/executioner/internal/languages"
 	"github.com/itstheanurag/executioner/internal/sandbox"
 )
@@ -16,6 +19,33 @@ type ExecutionResult struct {
 	TimeMs    int64
 	MemoryKb  int64
 	ErrorType string
+
+	// Diagnostics are parsed from compiler output, or from Stderr when the
+	// program crashed, for languages with a known output format.
+	Diagnostics []diagnostics.Diagnostic
+
+	// The effective commands, including request flags and arguments.
+	CompileCommand []string
+	RunCommand     []string
+}
+
+// StatusCancelled is reported for jobs stopped through the queue's Cancel.
+const StatusCancelled = "cancelled"
+
+// InfrastructureError is returned by Execute when the sandbox itself fails,
+// e.g. the Docker daemon cannot create a container, or has been closed. Problems with the
+// submitted program are reported in the ExecutionResult instead, so an
+// InfrastructureError says nothing about the program and is worth retrying.
+type InfrastructureError struct {
+	Err error
+}
+
+func (e *InfrastructureError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InfrastructureError) Unwrap() error {
+	return e.Err
 }
 
 type Executor struct {
@@ -30,16 +60,59 @@ func NewExecutor(registry *languages.Registry, sb sandbox.Sandbox) *Executor {
 	}
 }
 
+// Supports reports whether languageID is a registered language.
+func (e *Executor) Supports(languageID string) bool {
+	_, err := e.registry.Get(languageID)
+	return err == nil
+}
+
+// Disabled reports whether languageID has been disabled by an operator.
+func (e *Executor) Disabled(languageID string) bool {
+	return e.registry.Disabled(languageID)
+}
+
+// Modes for ExecuteOptions.Mode.
+const (
+	// ModeRun compiles the program if needed and runs it.
+	ModeRun = ""
+	// ModeCompile only runs the compile phase. Interpreted languages fall
+	// back to their syntax check.
+	ModeCompile = "compile"
+	// ModeCheck runs the language's syntax check, falling back to the
+	// compile phase for languages without one.
+	ModeCheck = "check"
+)
+
 type ExecuteOptions struct {
 	LanguageID    string
 	SourceCode    string
 	Stdin         string
 	TimeLimitMs   int
 	MemoryLimitKb int
+
+	// Compile limits only apply to compiled languages. Zero selects the
+	// language default.
+	CompileTimeLimitMs   int
+	CompileMemoryLimitKb int
+
+	// CompilerOptions are checked against the language allowlist; Args are
+	// passed to the program.
+	CompilerOptions []string
+	Args            []string
+
+	Mode string
+
+	// JobID labels the sandbox container. It is not part of the request,
+	// so it is left out of stored and fingerprinted options.
+	JobID string `json:"-"`
+	// Stdout and Stderr, if set, also receive the program's output as it
+	// is written. Like JobID, they are left out.
+	Stdout io.Writer `json:"-"`
+	Stderr io.Writer `json:"-"`
 }
 
 func (e *Executor) Execute(ctx context.Context, opts ExecuteOptions) (*ExecutionResult, error) {
-	lang, err := e.registry.Get(opts.LanguageID)
+	lang, err := e.language(opts)
 	if err != nil {
 		return &ExecutionResult{
 			Status:    "error",
@@ -47,38 +120,122 @@ func (e *Executor) Execute(ctx context.Context, opts ExecuteOptions) (*Execution
 		}, nil
 	}
 
+	opts, err = e.ResolveLimits(opts)
+	if err != nil {
+		return &ExecutionResult{
+			Status:    "error",
+			Stderr:    err.Error(),
+			ErrorType: "Invalid Limits",
+		}, nil
+	}
+
+	compileCmd, runCmd, err := e.Commands(opts)
+	if err != nil {
+		return &ExecutionResult{
+			Status:    "error",
+			Stderr:    err.Error(),
+			ErrorType: "Invalid Arguments",
+		}, nil
+	}
+
+	// Syntax checks produce nothing worth caching
+	artifacts := lang.Config.Artifacts
+	if opts.Mode == ModeCheck {
+		artifacts = nil
+	}
+
 	res, err := e.sandbox.Run(ctx, sandbox.RunConfig{
 		Image:         lang.Config.Image,
 		SourceCode:    opts.SourceCode,
 		SourceFile:    lang.Config.SourceFile,
-		CompileCmd:    lang.Config.CompileCommand,
-		RunCmd:        lang.Config.RunCommand,
+		CompileCmd:    compileCmd,
+		RunCmd:        runCmd,
 		Stdin:         opts.Stdin,
 		TimeLimitMs:   opts.TimeLimitMs,
 		MemoryLimitKb: opts.MemoryLimitKb,
+
+		Env:                  lang.Config.Env,
+		PidsLimit:            lang.Config.PidsLimit,
+		WorkspaceSizeMb:      lang.Config.WorkspaceSizeMb,
+		CompileTimeLimitMs:   opts.CompileTimeLimitMs,
+		CompileMemoryLimitKb: opts.CompileMemoryLimitKb,
+		CompileOnly:          opts.Mode != ModeRun,
+		Artifacts:            artifacts,
+		JobID:                opts.JobID,
+		Stdout:               opts.Stdout,
+		Stderr:               opts.Stderr,
 	})
 
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
+		if ctx.Err() == context.Canceled {
+			return &ExecutionResult{
+				Status:         StatusCancelled,
+				ErrorType:      "Cancelled",
+				CompileCommand: compileCmd,
+				RunCommand:     runCmd,
+			}, nil
+		}
+		if ctx.Err() == context.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded) {
 			return &ExecutionResult{
-				Status:    "error",
-				ErrorType: "Time Limit Exceeded",
+				Status:         "error",
+				ErrorType:      "Time Limit Exceeded",
+				CompileCommand: compileCmd,
+				RunCommand:     runCmd,
 			}, nil
 		}
-		return nil, fmt.Errorf("sandbox execution failed: %w", err)
+		var daemonErr *sandbox.DaemonError
+		if errors.As(err, &daemonErr) || errors.Is(err, sandbox.ErrClosed) {
+			return nil, &InfrastructureError{Err: fmt.Errorf("sandbox execution failed: %w", err)}
+		}
+		// Anything else went wrong inside a started container, which the
+		// program can cause, e.g. by exhausting its memory. A rerun would
+		// fail the same way, so it is reported rather than retried
+		return &ExecutionResult{
+			Status:         "error",
+			Stderr:         err.Error(),
+			ErrorType:      "Sandbox Error",
+			CompileCommand: compileCmd,
+			RunCommand:     runCmd,
+		}, nil
 	}
 
 	status := "success"
-	if res.ExitCode != 0 {
+	errorType := ""
+	switch {
+	case res.TimedOut:
+		status = "compilation_error"
+		errorType = "Compile Time Limit Exceeded"
+	case res.ExitCode != 0 && res.Phase == sandbox.PhaseCompile:
+		status = "compilation_error"
+		errorType = "Compilation Error"
+	case res.ExitCode != 0:
 		status = "runtime_error"
 	}
 
+	// Compilers such as tsc report on stdout; a program's stdout is its
+	// own business, so only stderr is parsed after a crash
+	stderr := res.Stderr
+	var diags []diagnostics.Diagnostic
+	switch {
+	case res.Phase == sandbox.PhaseCompile:
+		stderr = diagnostics.Render(lang.Config.CompileDiagnostics, res.Stderr)
+		diags = diagnostics.Parse(lang.Config.CompileDiagnostics, res.Stdout+"\n"+res.Stderr)
+	case res.ExitCode != 0:
+		diags = diagnostics.Parse(lang.Config.RuntimeDiagnostics, res.Stderr)
+	}
+
 	return &ExecutionResult{
-		Status:   status,
-		Stdout:   res.Stdout,
-		Stderr:   res.Stderr,
-		ExitCode: res.ExitCode,
-		TimeMs:   res.TimeMs,
-		MemoryKb: res.MemoryKb,
+		Status:    status,
+		ErrorType: errorType,
+		Stdout:    res.Stdout,
+		Stderr:    stderr,
+		ExitCode:  res.ExitCode,
+		TimeMs:    res.TimeMs,
+		MemoryKb:  res.MemoryKb,
+
+		Diagnostics: diags,
+
+		CompileCommand: compileCmd,
+		RunCommand:     runCmd,
 	}, nil
 }
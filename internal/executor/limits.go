@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+)
+
+// LimitError reports a requested limit that is negative or above the
+// language's ceiling.
+type LimitError struct {
+	Language string
+	Field    string
+	Value    int
+	Max      int
+}
+
+func (e *LimitError) Error() string {
+	if e.Value < 0 {
+		return fmt.Sprintf("%s must not be negative", e.Field)
+	}
+	return fmt.Sprintf("%s %d exceeds the maximum of %d for %s", e.Field, e.Value, e.Max, e.Language)
+}
+
+// ResolveLimits fills unset limits in opts from the language defaults and
+// rejects values outside the language's ceilings. Compile limits are
+// cleared when an interpreted language is run.
+func (e *Executor) ResolveLimits(opts ExecuteOptions) (ExecuteOptions, error) {
+	lang, err := e.registry.Get(opts.LanguageID)
+	if err != nil {
+		return opts, err
+	}
+
+	run := lang.Config.EffectiveRunLimits()
+	if err := checkLimit(lang.ID, "time_limit_ms", &opts.TimeLimitMs, run.Default.TimeLimitMs, run.Max.TimeLimitMs); err != nil {
+		return opts, err
+	}
+	if err := checkLimit(lang.ID, "memory_limit_kb", &opts.MemoryLimitKb, run.Default.MemoryLimitKb, run.Max.MemoryLimitKb); err != nil {
+		return opts, err
+	}
+
+	if !lang.Config.Compiled() && opts.Mode == ModeRun {
+		opts.CompileTimeLimitMs = 0
+		opts.CompileMemoryLimitKb = 0
+		return opts, nil
+	}
+
+	compile := lang.Config.EffectiveCompileLimits()
+	if err := checkLimit(lang.ID, "compile_time_limit_ms", &opts.CompileTimeLimitMs, compile.Default.TimeLimitMs, compile.Max.TimeLimitMs); err != nil {
+		return opts, err
+	}
+	if err := checkLimit(lang.ID, "compile_memory_limit_kb", &opts.CompileMemoryLimitKb, compile.Default.MemoryLimitKb, compile.Max.MemoryLimitKb); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// Timeout is the overall deadline for executing resolved options: both
+// phases plus a second for container setup.
+func (o ExecuteOptions) Timeout() time.Duration {
+	return time.Duration(o.CompileTimeLimitMs+o.TimeLimitMs)*time.Millisecond + time.Second
+}
+
+func checkLimit(language, field string, value *int, def, max int) error {
+	if *value < 0 {
+		return &LimitError{Language: language, Field: field, Value: *value, Max: max}
+	}
+	if *value == 0 {
+		*value = def
+	}
+	if *value > max {
+		return &LimitError{Language: language, Field: field, Value: *value, Max: max}
+	}
+	return nil
+}
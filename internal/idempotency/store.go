@@ -0,0 +1,257 @@
+// Package idempotency remembers execution results in Postgres so that
+// retried requests do not run the same program twice. Results are found
+// either by a client-chosen Idempotency-Key or, when dedupe is enabled, by
+// a fingerprint of the request's content.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// Header is the request header carrying an idempotency key.
+const Header = "Idempotency-Key"
+
+const (
+	// DefaultKeyTTL is how long a completed key replays its result.
+	DefaultKeyTTL = 24 * time.Hour
+	// claimGrace is added to a job's timeout to bound how long a key stays
+	// in progress if the process handling it dies.
+	claimGrace = time.Minute
+)
+
+var (
+	// ErrKeyReused is returned when a key is sent again with a different
+	// request.
+	ErrKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrInProgress is returned when the original request for a key has
+	// not finished yet.
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// Options configures a Store.
+type Options struct {
+	// KeyTTL is how long a completed key replays its result.
+	KeyTTL time.Duration
+	// DedupeTTL is how long a result is reused for identical requests
+	// without a key. Zero disables content dedupe.
+	DedupeTTL time.Duration
+}
+
+// Record is a previously seen job and its result.
+type Record struct {
+	JobID  string
+	Result *executor.ExecutionResult
+}
+
+type Store struct {
+	db     *database.Database
+	opts   Options
+	logger *zerolog.Logger
+}
+
+func New(db *database.Database, opts Options, logger *zerolog.Logger) *Store {
+	if opts.KeyTTL <= 0 {
+		opts.KeyTTL = DefaultKeyTTL
+	}
+	return &Store{
+		db:     db,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Fingerprint identifies a request by everything that affects its result.
+// opts should have its limits resolved, so that omitted and explicit
+// default limits compare equal.
+func Fingerprint(opts executor.ExecuteOptions) string {
+	encoded, _ := json.Marshal(opts)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// Claim reserves key for a new job. It returns nil if the caller should run
+// the job, or the earlier job's record if the key has already completed
+// for the same request. The claim lapses after timeout plus a grace period
+// unless Complete or Release is called first.
+func (s *Store) Claim(ctx context.Context, tenant, key, fingerprint, jobID string, timeout time.Duration) (*Record, error) {
+	var claimed string
+	err := s.db.Pool.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (tenant, key, fingerprint, job_id, expires_at)
+		VALUES ($1, $2, $3, $4, now() + $5 * interval '1 millisecond')
+		ON CONFLICT (tenant, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			job_id = EXCLUDED.job_id,
+			result = NULL,
+			created_at = now(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < now()
+		RETURNING job_id`,
+		tenant, key, fingerprint, jobID, (timeout + claimGrace).Milliseconds(),
+	).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	var (
+		existing string
+		rec      Record
+		result   []byte
+	)
+	err = s.db.Pool.QueryRow(ctx, `
+		SELECT fingerprint, job_id, result FROM idempotency_keys
+		WHERE tenant = $1 AND key = $2`,
+		tenant, key,
+	).Scan(&existing, &rec.JobID, &result)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Released between the two statements; let the client retry
+		return nil, ErrInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	if existing != fingerprint {
+		return nil, ErrKeyReused
+	}
+	if result == nil {
+		return nil, ErrInProgress
+	}
+	if err := json.Unmarshal(result, &rec.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode stored result: %w", err)
+	}
+	return &rec, nil
+}
+
+// Complete stores the result for a claimed key, which then replays it for
+// KeyTTL.
+func (s *Store) Complete(ctx context.Context, tenant, key string, result *executor.ExecutionResult) error {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	_, err = s.db.Pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET result = $1, expires_at = now() + $2 * interval '1 millisecond'
+		WHERE tenant = $3 AND key = $4`,
+		encoded, s.opts.KeyTTL.Milliseconds(), tenant, key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent result: %w", err)
+	}
+	return nil
+}
+
+// Release drops a claimed key whose job did not produce a result, so the
+// client's retry runs it again.
+func (s *Store) Release(ctx context.Context, tenant, key string) error {
+	_, err := s.db.Pool.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE tenant = $1 AND key = $2 AND result IS NULL`,
+		tenant, key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DedupeEnabled reports whether Lookup and Remember do anything.
+func (s *Store) DedupeEnabled() bool {
+	return s.opts.DedupeTTL > 0
+}
+
+// Lookup returns a recent result for an identical request by the same
+// tenant, or nil. Results are not shared between tenants, whose job IDs
+// are their own.
+func (s *Store) Lookup(ctx context.Context, tenant, fingerprint string) (*Record, error) {
+	if !s.DedupeEnabled() {
+		return nil, nil
+	}
+
+	var (
+		rec    Record
+		result []byte
+	)
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT job_id, result FROM result_cache
+		WHERE tenant = $1 AND fingerprint = $2 AND expires_at > now()`,
+		tenant, fingerprint,
+	).Scan(&rec.JobID, &result)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up cached result: %w", err)
+	}
+	if err := json.Unmarshal(result, &rec.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode cached result: %w", err)
+	}
+	return &rec, nil
+}
+
+// Remember stores a result for Lookup if it is one a rerun would reproduce:
+// a finished run, not one cut short by a timeout, cancellation or
+// infrastructure error.
+func (s *Store) Remember(ctx context.Context, tenant, fingerprint, jobID string, result *executor.ExecutionResult) error {
+	if !s.DedupeEnabled() || !Reusable(result) {
+		return nil
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	_, err = s.db.Pool.Exec(ctx, `
+		INSERT INTO result_cache (tenant, fingerprint, job_id, result, expires_at)
+		VALUES ($1, $2, $3, $4, now() + $5 * interval '1 millisecond')
+		ON CONFLICT (tenant, fingerprint) DO UPDATE
+		SET job_id = EXCLUDED.job_id, result = EXCLUDED.result, expires_at = EXCLUDED.expires_at`,
+		tenant, fingerprint, jobID, encoded, s.opts.DedupeTTL.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to cache result: %w", err)
+	}
+	return nil
+}
+
+// Reusable reports whether result can stand in for a rerun of its request.
+func Reusable(result *executor.ExecutionResult) bool {
+	switch result.Status {
+	case "success", "runtime_error", "compilation_error":
+		return true
+	}
+	return false
+}
+
+// StartCleanup periodically deletes expired keys and cached results.
+func (s *Store) StartCleanup(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_, err := s.db.Pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < now()`)
+			if err == nil {
+				_, err = s.db.Pool.Exec(ctx, `DELETE FROM result_cache WHERE expires_at < now()`)
+			}
+			cancel()
+			if err != nil {
+				s.logger.Error().Err(err).Msg("failed to clean up expired idempotency records")
+			}
+		}
+	}()
+}
@@ -0,0 +1,269 @@
+// Package judge0 maps executioner's languages, results and statuses to
+// those of the Judge0 REST API, so that Judge0 clients can be pointed at
+// executioner unchanged.
+package judge0
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/submission"
+)
+
+// LanguageIDs maps Judge0 CE language IDs to executioner language IDs.
+// Each language has the ID of its main Judge0 CE version.
+var LanguageIDs = map[int]string{
+	46: "bash",
+	50: "c",
+	51: "csharp",
+	54: "cpp",
+	60: "go",
+	62: "java",
+	63: "javascript",
+	68: "php",
+	71: "python",
+	72: "ruby",
+	73: "rust",
+	74: "typescript",
+	78: "kotlin",
+}
+
+// Status is a Judge0 submission status.
+type Status struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+}
+
+// Judge0 status IDs.
+const (
+	StatusInQueue = iota + 1
+	StatusProcessing
+	StatusAccepted
+	StatusWrongAnswer
+	StatusTimeLimitExceeded
+	StatusCompilationError
+	StatusRuntimeErrorSIGSEGV
+	StatusRuntimeErrorSIGXFSZ
+	StatusRuntimeErrorSIGFPE
+	StatusRuntimeErrorSIGABRT
+	StatusRuntimeErrorNZEC
+	StatusRuntimeErrorOther
+	StatusInternalError
+	StatusExecFormatError
+)
+
+// Statuses lists every Judge0 status, in ID order.
+var Statuses = []Status{
+	{StatusInQueue, "In Queue"},
+	{StatusProcessing, "Processing"},
+	{StatusAccepted, "Accepted"},
+	{StatusWrongAnswer, "Wrong Answer"},
+	{StatusTimeLimitExceeded, "Time Limit Exceeded"},
+	{StatusCompilationError, "Compilation Error"},
+	{StatusRuntimeErrorSIGSEGV, "Runtime Error (SIGSEGV)"},
+	{StatusRuntimeErrorSIGXFSZ, "Runtime Error (SIGXFSZ)"},
+	{StatusRuntimeErrorSIGFPE, "Runtime Error (SIGFPE)"},
+	{StatusRuntimeErrorSIGABRT, "Runtime Error (SIGABRT)"},
+	{StatusRuntimeErrorNZEC, "Runtime Error (NZEC)"},
+	{StatusRuntimeErrorOther, "Runtime Error (Other)"},
+	{StatusInternalError, "Internal Error"},
+	{StatusExecFormatError, "Exec Format Error"},
+}
+
+func status(id int) Status {
+	return Statuses[id-1]
+}
+
+// signalStatuses maps the signals with their own runtime error status.
+var signalStatuses = map[int]int{
+	11: StatusRuntimeErrorSIGSEGV,
+	25: StatusRuntimeErrorSIGXFSZ,
+	8:  StatusRuntimeErrorSIGFPE,
+	6:  StatusRuntimeErrorSIGABRT,
+}
+
+// Submission is a Judge0 submission. Fields follow Judge0's names; the
+// pointer fields are null until the submission has finished.
+type Submission struct {
+	Token          string  `json:"token"`
+	LanguageID     int     `json:"language_id"`
+	SourceCode     *string `json:"source_code"`
+	Stdin          *string `json:"stdin"`
+	ExpectedOutput *string `json:"expected_output"`
+
+	Stdout        *string    `json:"stdout"`
+	Stderr        *string    `json:"stderr"`
+	CompileOutput *string    `json:"compile_output"`
+	Message       *string    `json:"message"`
+	ExitCode      *int       `json:"exit_code"`
+	ExitSignal    *int       `json:"exit_signal"`
+	Status        Status     `json:"status"`
+	StatusID      int        `json:"status_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	Time          *string    `json:"time"`
+	WallTime      *string    `json:"wall_time"`
+	Memory        *int64     `json:"memory"`
+}
+
+// DefaultFields are returned when a request does not ask for others.
+var DefaultFields = []string{"token", "stdout", "stderr", "compile_output", "message", "status", "time", "memory"}
+
+// Request is the part of a Judge0 submission kept to build responses.
+type Request struct {
+	Token          string
+	LanguageID     int
+	SourceCode     string
+	Stdin          string
+	ExpectedOutput *string
+}
+
+// FromSubmission builds the Judge0 view of an executioner submission.
+func FromSubmission(req Request, sub *submission.Submission) *Submission {
+	out := &Submission{
+		Token:          req.Token,
+		LanguageID:     req.LanguageID,
+		SourceCode:     &req.SourceCode,
+		Stdin:          &req.Stdin,
+		ExpectedOutput: req.ExpectedOutput,
+		CreatedAt:      sub.CreatedAt,
+		FinishedAt:     sub.FinishedAt,
+	}
+
+	switch {
+	case sub.Status == submission.StatusQueued:
+		out.setStatus(StatusInQueue)
+	case sub.Result == nil:
+		out.setStatus(StatusInternalError)
+		out.Message = &sub.Error
+	default:
+		out.setResult(sub.Result, req.ExpectedOutput)
+	}
+	return out
+}
+
+func (s *Submission) setStatus(id int) {
+	s.Status = status(id)
+	s.StatusID = id
+}
+
+func (s *Submission) setResult(res *executor.ExecutionResult, expected *string) {
+	seconds := fmt.Sprintf("%.3f", float64(res.TimeMs)/1000)
+	s.Time, s.WallTime = &seconds, &seconds
+	memory := res.MemoryKb
+	s.Memory = &memory
+
+	switch res.Status {
+	case "compilation_error":
+		output := joinOutput(res.Stdout, res.Stderr)
+		s.CompileOutput = &output
+		// Judge0 reports a compiler that ran out of time as a compilation
+		// error too, rather than as the program's time limit
+		if res.ErrorType == "Compile Time Limit Exceeded" {
+			message := "Compilation time limit exceeded"
+			s.Message = &message
+		} else {
+			exitCode := res.ExitCode
+			s.ExitCode = &exitCode
+		}
+		s.setStatus(StatusCompilationError)
+		return
+	case "success", "runtime_error":
+		stdout, stderr, exitCode := res.Stdout, res.Stderr, res.ExitCode
+		s.Stdout, s.Stderr, s.ExitCode = &stdout, &stderr, &exitCode
+	}
+
+	switch {
+	case res.Status == "success" && (expected == nil || sameOutput(*expected, res.Stdout)):
+		s.setStatus(StatusAccepted)
+	case res.Status == "success":
+		s.setStatus(StatusWrongAnswer)
+	case res.Status == "runtime_error":
+		id := StatusRuntimeErrorNZEC
+		// Shells report a death by signal N as exit status 128+N
+		if res.ExitCode > 128 {
+			signal := res.ExitCode - 128
+			s.ExitSignal = &signal
+			if sig, ok := signalStatuses[signal]; ok {
+				id = sig
+			} else {
+				id = StatusRuntimeErrorOther
+			}
+		}
+		message := fmt.Sprintf("Exited with error status %d", res.ExitCode)
+		s.Message = &message
+		s.setStatus(id)
+	case res.ErrorType == "Time Limit Exceeded":
+		message := "Time limit exceeded"
+		s.Message = &message
+		s.setStatus(StatusTimeLimitExceeded)
+	case res.ErrorType == "Sandbox Error":
+		// The program took its container down
+		message := res.Stderr
+		s.Message = &message
+		s.setStatus(StatusRuntimeErrorOther)
+	default:
+		message := res.ErrorType
+		if res.Stderr != "" {
+			message += ": " + res.Stderr
+		}
+		s.Message = &message
+		s.setStatus(StatusInternalError)
+	}
+}
+
+// joinOutput combines the stdout and stderr of a compiler, which may
+// report on either.
+func joinOutput(stdout, stderr string) string {
+	if stdout == "" {
+		return stderr
+	}
+	if stderr == "" {
+		return stdout
+	}
+	return stdout + "\n" + stderr
+}
+
+// sameOutput compares outputs as Judge0 does, ignoring trailing
+// whitespace on each line and trailing blank lines.
+func sameOutput(expected, actual string) bool {
+	return normalize(expected) == normalize(actual)
+}
+
+func normalize(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func (s *Submission) textFields() []**string {
+	return []**string{&s.SourceCode, &s.Stdin, &s.ExpectedOutput, &s.Stdout, &s.Stderr, &s.CompileOutput, &s.Message}
+}
+
+// Encode base64-encodes the text fields, for requests made with
+// base64_encoded=true.
+func (s *Submission) Encode() {
+	for _, field := range s.textFields() {
+		if *field != nil {
+			encoded := base64.StdEncoding.EncodeToString([]byte(**field))
+			*field = &encoded
+		}
+	}
+}
+
+// Binary reports whether a text field is not valid UTF-8, and so can
+// only be shown base64-encoded.
+func (s *Submission) Binary() bool {
+	for _, field := range s.textFields() {
+		if *field != nil && !utf8.ValidString(**field) {
+			return true
+		}
+	}
+	return false
+}
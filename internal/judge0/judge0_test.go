@@ -0,0 +1,172 @@
+package judge0
+
+import (
+	"testing"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+)
+
+func TestSetResult(t *testing.T) {
+	expected := "hello\n"
+	tests := []struct {
+		name     string
+		result   executor.ExecutionResult
+		expected *string
+
+		wantStatus  int
+		wantStdout  *string
+		wantCompile *string
+		wantMessage *string
+		wantExit    *int
+		wantSignal  *int
+	}{
+		{
+			name:       "accepted without expected output",
+			result:     executor.ExecutionResult{Status: "success", Stdout: "anything"},
+			wantStatus: StatusAccepted,
+			wantStdout: ptr("anything"),
+			wantExit:   ptr(0),
+		},
+		{
+			name:       "accepted ignoring trailing whitespace",
+			result:     executor.ExecutionResult{Status: "success", Stdout: "hello  \r\n\n"},
+			expected:   &expected,
+			wantStatus: StatusAccepted,
+			wantStdout: ptr("hello  \r\n\n"),
+			wantExit:   ptr(0),
+		},
+		{
+			name:       "wrong answer",
+			result:     executor.ExecutionResult{Status: "success", Stdout: "goodbye\n"},
+			expected:   &expected,
+			wantStatus: StatusWrongAnswer,
+			wantStdout: ptr("goodbye\n"),
+			wantExit:   ptr(0),
+		},
+		{
+			name:        "non-zero exit",
+			result:      executor.ExecutionResult{Status: "runtime_error", ExitCode: 1},
+			expected:    &expected,
+			wantStatus:  StatusRuntimeErrorNZEC,
+			wantStdout:  ptr(""),
+			wantMessage: ptr("Exited with error status 1"),
+			wantExit:    ptr(1),
+		},
+		{
+			name:        "segmentation fault",
+			result:      executor.ExecutionResult{Status: "runtime_error", ExitCode: 139},
+			wantStatus:  StatusRuntimeErrorSIGSEGV,
+			wantStdout:  ptr(""),
+			wantMessage: ptr("Exited with error status 139"),
+			wantExit:    ptr(139),
+			wantSignal:  ptr(11),
+		},
+		{
+			name:        "floating point exception",
+			result:      executor.ExecutionResult{Status: "runtime_error", ExitCode: 136},
+			wantStatus:  StatusRuntimeErrorSIGFPE,
+			wantStdout:  ptr(""),
+			wantMessage: ptr("Exited with error status 136"),
+			wantExit:    ptr(136),
+			wantSignal:  ptr(8),
+		},
+		{
+			name:        "killed by another signal",
+			result:      executor.ExecutionResult{Status: "runtime_error", ExitCode: 137},
+			wantStatus:  StatusRuntimeErrorOther,
+			wantStdout:  ptr(""),
+			wantMessage: ptr("Exited with error status 137"),
+			wantExit:    ptr(137),
+			wantSignal:  ptr(9),
+		},
+		{
+			name:        "compilation error",
+			result:      executor.ExecutionResult{Status: "compilation_error", ErrorType: "Compilation Error", Stdout: "note", Stderr: "error: nope", ExitCode: 1},
+			wantStatus:  StatusCompilationError,
+			wantCompile: ptr("note\nerror: nope"),
+			wantExit:    ptr(1),
+		},
+		{
+			name:        "compile time limit",
+			result:      executor.ExecutionResult{Status: "compilation_error", ErrorType: "Compile Time Limit Exceeded", Stderr: "partial"},
+			expected:    &expected,
+			wantStatus:  StatusCompilationError,
+			wantCompile: ptr("partial"),
+			wantMessage: ptr("Compilation time limit exceeded"),
+		},
+		{
+			name:        "time limit",
+			result:      executor.ExecutionResult{Status: "error", ErrorType: "Time Limit Exceeded"},
+			wantStatus:  StatusTimeLimitExceeded,
+			wantMessage: ptr("Time limit exceeded"),
+		},
+		{
+			name:        "container taken down",
+			result:      executor.ExecutionResult{Status: "error", ErrorType: "Sandbox Error", Stderr: "failed to inspect run exec"},
+			wantStatus:  StatusRuntimeErrorOther,
+			wantMessage: ptr("failed to inspect run exec"),
+		},
+		{
+			name:        "cancelled",
+			result:      executor.ExecutionResult{Status: executor.StatusCancelled, ErrorType: "Cancelled"},
+			wantStatus:  StatusInternalError,
+			wantMessage: ptr("Cancelled"),
+		},
+		{
+			name:        "invalid arguments",
+			result:      executor.ExecutionResult{Status: "error", ErrorType: "Invalid Arguments", Stderr: "flag rejected"},
+			wantStatus:  StatusInternalError,
+			wantMessage: ptr("Invalid Arguments: flag rejected"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Submission
+			s.setResult(&tt.result, tt.expected)
+
+			if s.StatusID != tt.wantStatus || s.Status.ID != tt.wantStatus {
+				t.Errorf("status = %d (%+v), want %d", s.StatusID, s.Status, tt.wantStatus)
+			}
+			checkField(t, "stdout", s.Stdout, tt.wantStdout)
+			checkField(t, "compile_output", s.CompileOutput, tt.wantCompile)
+			checkField(t, "message", s.Message, tt.wantMessage)
+			checkField(t, "exit_code", s.ExitCode, tt.wantExit)
+			checkField(t, "exit_signal", s.ExitSignal, tt.wantSignal)
+		})
+	}
+}
+
+func TestSetResultTimeAndMemory(t *testing.T) {
+	var s Submission
+	s.setResult(&executor.ExecutionResult{Status: "success", TimeMs: 1234, MemoryKb: 2048}, nil)
+
+	if s.Time == nil || *s.Time != "1.234" || s.WallTime == nil || *s.WallTime != "1.234" {
+		t.Errorf("time = %v, wall_time = %v, want 1.234", s.Time, s.WallTime)
+	}
+	if s.Memory == nil || *s.Memory != 2048 {
+		t.Errorf("memory = %v, want 2048", s.Memory)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func checkField[T comparable](t *testing.T, name string, got, want *T) {
+	t.Helper()
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s = %v, want %v", name, deref(got), deref(want))
+	case *got != *want:
+		t.Errorf("%s = %v, want %v", name, *got, *want)
+	}
+}
+
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
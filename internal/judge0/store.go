@@ -0,0 +1,105 @@
+package judge0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// ErrNotFound is returned for tokens not made through the Judge0 API.
+var ErrNotFound = errors.New("submission not found")
+
+// orphanAge is how long a request may go without its submission before
+// cleanup deletes it.
+const orphanAge = time.Hour
+
+// Store keeps the request fields of Judge0 submissions, which responses
+// echo and expected_output is checked against; the outcome lives in the
+// submission store. A request is saved before its submission exists, so
+// it is deleted by cleanup rather than with the submission.
+type Store struct {
+	db     *database.Database
+	logger *zerolog.Logger
+}
+
+func NewStore(db *database.Database, logger *zerolog.Logger) *Store {
+	return &Store{db: db, logger: logger}
+}
+
+// Save records a request before its submission is created. Text fields
+// are stored as bytes, since base64-encoded requests may hold any.
+func (s *Store) Save(ctx context.Context, req Request) error {
+	var expected []byte
+	if req.ExpectedOutput != nil {
+		expected = []byte(*req.ExpectedOutput)
+	}
+	_, err := s.db.Pool.Exec(ctx, `
+		INSERT INTO judge0_submissions (token, language_id, source_code, stdin, expected_output)
+		VALUES ($1, $2, $3, $4, $5)`,
+		req.Token, req.LanguageID, []byte(req.SourceCode), []byte(req.Stdin), expected,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save judge0 submission: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a request whose submission could not be queued.
+func (s *Store) Delete(ctx context.Context, token string) error {
+	if _, err := s.db.Pool.Exec(ctx, `DELETE FROM judge0_submissions WHERE token = $1`, token); err != nil {
+		return fmt.Errorf("failed to delete judge0 submission: %w", err)
+	}
+	return nil
+}
+
+// Get returns the request of a submission. Callers check the tenant on
+// the submission itself.
+func (s *Store) Get(ctx context.Context, token string) (Request, error) {
+	req := Request{Token: token}
+	var source, stdin, expected []byte
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT language_id, source_code, stdin, expected_output
+		FROM judge0_submissions
+		WHERE token = $1`,
+		token,
+	).Scan(&req.LanguageID, &source, &stdin, &expected)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return req, ErrNotFound
+	}
+	if err != nil {
+		return req, fmt.Errorf("failed to get judge0 submission: %w", err)
+	}
+	req.SourceCode, req.Stdin = string(source), string(stdin)
+	if expected != nil {
+		e := string(expected)
+		req.ExpectedOutput = &e
+	}
+	return req, nil
+}
+
+// StartCleanup deletes requests whose submission has been deleted, or was
+// never created.
+func (s *Store) StartCleanup(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_, err := s.db.Pool.Exec(ctx, `
+				DELETE FROM judge0_submissions j
+				WHERE created_at < now() - $1 * interval '1 millisecond'
+					AND NOT EXISTS (SELECT 1 FROM submissions s WHERE s.id = j.token)`,
+				orphanAge.Milliseconds(),
+			)
+			cancel()
+			if err != nil {
+				s.logger.Error().Err(err).Msg("failed to clean up judge0 submissions")
+			}
+		}
+	}()
+}
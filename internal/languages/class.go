@@ -0,0 +1,53 @@
+package languages
+
+import (
+	"regexp"
+	"slices"
+)
+
+// javaPublicClass matches a top-level public class declaration. Names are
+// limited to ASCII letters, digits and underscores, so they are safe to
+// use as file names in the sandbox.
+var javaPublicClass = regexp.MustCompile(`(?m)^public\s+(?:(?:final|abstract|strictfp)\s+)*class\s+([A-Za-z_][A-Za-z0-9_]*)\b`)
+
+// ForSource returns the configuration for a submission: for languages with
+// a ClassPattern, MainClass is renamed to the public class source declares,
+// in SourceFile and in the commands. Other configurations are returned
+// unchanged.
+func (c RuntimeConfig) ForSource(source string) RuntimeConfig {
+	if c.ClassPattern == nil {
+		return c
+	}
+	m := c.ClassPattern.FindStringSubmatch(source)
+	if m == nil || m[1] == c.MainClass {
+		return c
+	}
+
+	class := m[1]
+	rename := func(arg string) string {
+		switch arg {
+		case c.MainClass:
+			return class
+		case c.SourceFile:
+			return class + c.SourceFile[len(c.MainClass):]
+		}
+		return arg
+	}
+	renameAll := func(cmd []string) []string {
+		if cmd == nil {
+			return nil
+		}
+		cmd = slices.Clone(cmd)
+		for i, arg := range cmd {
+			cmd[i] = rename(arg)
+		}
+		return cmd
+	}
+
+	c.CompileCommand = renameAll(c.CompileCommand)
+	c.SyntaxCheckCommand = renameAll(c.SyntaxCheckCommand)
+	c.RunCommand = renameAll(c.RunCommand)
+	c.SourceFile = rename(c.SourceFile)
+	c.MainClass = class
+	return c
+}
@@ -0,0 +1,445 @@
+package languages
+
+import "github.com/itstheanurag/executioner/internal/diagnostics"
+
+// jvmFlags keep the JVM within the sandbox PID limit: the serial collector
+// and a single reported CPU stop it from spawning a GC/JIT thread per core.
+var jvmFlags = []string{"-XX:+UseSerialGC", "-XX:ActiveProcessorCount=1"}
+
+// Every default self-test program reads two integers and prints their sum.
+const (
+	sumStdin  = "2 3\n"
+	sumOutput = "5\n"
+)
+
+func (r *Registry) registerDefaults() {
+	r.Register(Language{
+		ID:   "cpp",
+		Name: "C++",
+		Config: RuntimeConfig{
+			Image:              "gcc:13",
+			SourceFile:         "solution.cpp",
+			CompileCommand:     []string{"g++", "solution.cpp", "-O2", "-o", "solution"},
+			RunCommand:         []string{"./solution"},
+			SyntaxCheckCommand: []string{"g++", "-fsyntax-only", "solution.cpp"},
+			CompileDiagnostics: diagnostics.FormatGCC,
+			Artifacts:          []string{"solution"},
+			AllowedCompileFlags: flagPatterns(
+				`-std=(c|gnu)\+\+(11|14|17|20|23)`,
+				gccOptimize, gccDefine, gccWarning,
+				`-g`,
+			),
+		},
+		SelfTest: SelfTest{
+			SourceCode: `#include <iostream>
+int main() {
+    long long a, b;
+    std::cin >> a >> b;
+    std::cout << a + b << std::endl;
+}
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "c",
+		Name: "C",
+		Config: RuntimeConfig{
+			Image:              "gcc:13",
+			SourceFile:         "solution.c",
+			CompileCommand:     []string{"gcc", "solution.c", "-std=c17", "-O2", "-o", "solution", "-lm"},
+			RunCommand:         []string{"./solution"},
+			SyntaxCheckCommand: []string{"gcc", "-fsyntax-only", "-std=c17", "solution.c"},
+			CompileDiagnostics: diagnostics.FormatGCC,
+			Artifacts:          []string{"solution"},
+			AllowedCompileFlags: flagPatterns(
+				`-std=(c|gnu)(89|99|11|17|23)`,
+				gccOptimize, gccDefine, gccWarning,
+				`-g`,
+			),
+		},
+		SelfTest: SelfTest{
+			SourceCode: `#include <stdio.h>
+int main(void) {
+    long long a, b;
+    scanf("%lld %lld", &a, &b);
+    printf("%lld\n", a + b);
+    return 0;
+}
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "python",
+		Name: "Python",
+		Config: RuntimeConfig{
+			Image:              "python:3.11-slim",
+			SourceFile:         "solution.py",
+			RunCommand:         []string{"python", "solution.py"},
+			SyntaxCheckCommand: []string{"python", "-m", "py_compile", "solution.py"},
+			CompileDiagnostics: diagnostics.FormatPython,
+			RuntimeDiagnostics: diagnostics.FormatPython,
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 5000, MemoryLimitKb: 256 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 512 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `a, b = map(int, input().split())
+print(a + b)
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "javascript",
+		Name: "JavaScript",
+		Config: RuntimeConfig{
+			Image:              "node:20-slim",
+			SourceFile:         "solution.js",
+			RunCommand:         []string{"node", "solution.js"},
+			SyntaxCheckCommand: []string{"node", "--check", "solution.js"},
+			CompileDiagnostics: diagnostics.FormatNode,
+			RuntimeDiagnostics: diagnostics.FormatNode,
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 5000, MemoryLimitKb: 512 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 1024 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `const [a, b] = require("fs").readFileSync(0, "utf8").trim().split(/\s+/).map(Number);
+console.log(a + b);
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "typescript",
+		Name: "Typescript",
+		Config: RuntimeConfig{
+			Image:              "node:20-slim",
+			SourceFile:         "solution.ts",
+			CompileCommand:     []string{"tsc", "solution.ts"},
+			RunCommand:         []string{"node", "solution.js"},
+			SyntaxCheckCommand: []string{"tsc", "--noEmit", "solution.ts"},
+			CompileDiagnostics: diagnostics.FormatMSBuild,
+			RuntimeDiagnostics: diagnostics.FormatNode,
+			Artifacts:          []string{"solution.js"},
+			AllowedCompileFlags: flagPatterns(
+				`--strict`,
+				`--noImplicitAny`,
+				`--strictNullChecks`,
+				`--noUnusedLocals`,
+			),
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 5000, MemoryLimitKb: 512 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 1024 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `declare const require: any;
+const [a, b]: number[] = require("fs").readFileSync(0, "utf8").trim().split(/\s+/).map(Number);
+console.log(a + b);
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	// Go needs a writable HOME and build cache; the standard library is
+	// compiled into the cache on first use, so the workspace is enlarged.
+	// The syntax check compiles without keeping the binary: go vet would
+	// fail code that compiles, and gofmt does not take build tags.
+	r.Register(Language{
+		ID:   "go",
+		Name: "Go",
+		Config: RuntimeConfig{
+			Image:              "golang:1.22",
+			SourceFile:         "solution.go",
+			CompileCommand:     []string{"go", "build", "-o", "solution", "solution.go"},
+			RunCommand:         []string{"./solution"},
+			SyntaxCheckCommand: []string{"go", "build", "-o", "/dev/null", "solution.go"},
+			CompileDiagnostics: diagnostics.FormatGCC,
+			Artifacts:          []string{"solution"},
+			// go build only accepts flags before the package arguments.
+			AllowedCompileFlags: flagPatterns(`-tags=[A-Za-z0-9_,]+`),
+			CompileFlagsIndex:   2,
+			Env: []string{
+				"HOME=/home/sandbox",
+				"GOCACHE=/home/sandbox/.cache/go-build",
+				"CGO_ENABLED=0",
+			},
+			WorkspaceSizeMb: 256,
+			CompileLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 20000, MemoryLimitKb: 768 * 1024},
+				Max:     Limits{TimeLimitMs: 60000, MemoryLimitKb: 1024 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `package main
+
+import "fmt"
+
+func main() {
+	var a, b int64
+	fmt.Scan(&a, &b)
+	fmt.Println(a + b)
+}
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	// rustc with optimizations routinely needs more memory than the
+	// programs it produces.
+	r.Register(Language{
+		ID:   "rust",
+		Name: "Rust",
+		Config: RuntimeConfig{
+			Image:              "rust:1.77-slim",
+			SourceFile:         "solution.rs",
+			CompileCommand:     []string{"rustc", "-O", "--edition", "2021", "--error-format=json", "-o", "solution", "solution.rs"},
+			RunCommand:         []string{"./solution"},
+			SyntaxCheckCommand: []string{"rustc", "--edition", "2021", "--error-format=json", "--emit=metadata", "-o", "solution.rmeta", "solution.rs"},
+			CompileDiagnostics: diagnostics.FormatRustcJSON,
+			Artifacts:          []string{"solution"},
+			AllowedCompileFlags: flagPatterns(
+				`-Copt-level=[0-3sz]`,
+				`-Cdebug-assertions(=(on|off))?`,
+				`-Coverflow-checks(=(on|off))?`,
+				`--cfg=[A-Za-z_][A-Za-z0-9_]*`,
+			),
+			Env:             []string{"TMPDIR=/home/sandbox"},
+			WorkspaceSizeMb: 128,
+			CompileLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 15000, MemoryLimitKb: 1024 * 1024},
+				Max:     Limits{TimeLimitMs: 60000, MemoryLimitKb: 2048 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `use std::io::Read;
+
+fn main() {
+    let mut input = String::new();
+    std::io::stdin().read_to_string(&mut input).unwrap();
+    let sum: i64 = input.split_whitespace().map(|x| x.parse::<i64>().unwrap()).sum();
+    println!("{}", sum);
+}
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	// javac requires a public class to live in a file of the same name, so
+	// the file and commands are named after the public class a submission
+	// declares, or Main if it declares none.
+	r.Register(Language{
+		ID:   "java",
+		Name: "Java",
+		Config: RuntimeConfig{
+			Image:              "eclipse-temurin:21-jdk",
+			SourceFile:         "Main.java",
+			CompileCommand:     []string{"javac", "-J-XX:+UseSerialGC", "-J-XX:ActiveProcessorCount=1", "Main.java"},
+			RunCommand:         append(append([]string{"java"}, jvmFlags...), "-cp", ".", "Main"),
+			CompileDiagnostics: diagnostics.FormatJava,
+			RuntimeDiagnostics: diagnostics.FormatJava,
+			Artifacts:          []string{"*.class"},
+			MainClass:          "Main",
+			ClassPattern:       javaPublicClass,
+			AllowedCompileFlags: flagPatterns(
+				`-Xlint(:[a-z,-]+)?`,
+				`-g`,
+				`-nowarn`,
+				`-Werror`,
+			),
+			PidsLimit: 128,
+			CompileLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 15000, MemoryLimitKb: 512 * 1024},
+				Max:     Limits{TimeLimitMs: 30000, MemoryLimitKb: 1024 * 1024},
+			},
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 4000, MemoryLimitKb: 512 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 1024 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `import java.util.Scanner;
+
+public class Main {
+    public static void main(String[] args) {
+        Scanner in = new Scanner(System.in);
+        long a = in.nextLong(), b = in.nextLong();
+        System.out.println(a + b);
+    }
+}
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	// kotlinc is itself a JVM application and is by far the slowest
+	// compiler in the catalog.
+	r.Register(Language{
+		ID:   "kotlin",
+		Name: "Kotlin",
+		Config: RuntimeConfig{
+			Image:              "zenika/kotlin:1.9",
+			SourceFile:         "solution.kt",
+			CompileCommand:     []string{"kotlinc", "solution.kt", "-include-runtime", "-d", "solution.jar"},
+			RunCommand:         append(append([]string{"java"}, jvmFlags...), "-jar", "solution.jar"),
+			CompileDiagnostics: diagnostics.FormatGCC,
+			RuntimeDiagnostics: diagnostics.FormatJava,
+			Artifacts:          []string{"solution.jar"},
+			AllowedCompileFlags: flagPatterns(
+				`-nowarn`,
+				`-Werror`,
+			),
+			Env: []string{
+				"HOME=/home/sandbox",
+				"JAVA_OPTS=-XX:+UseSerialGC -XX:ActiveProcessorCount=1",
+			},
+			PidsLimit:       128,
+			WorkspaceSizeMb: 128,
+			CompileLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 30000, MemoryLimitKb: 1024 * 1024},
+				Max:     Limits{TimeLimitMs: 60000, MemoryLimitKb: 2048 * 1024},
+			},
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 4000, MemoryLimitKb: 512 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 1024 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `fun main() {
+    val (a, b) = readLine()!!.trim().split(" ").map { it.toLong() }
+    println(a + b)
+}
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "csharp",
+		Name: "C#",
+		Config: RuntimeConfig{
+			Image:              "mono:6.12",
+			SourceFile:         "solution.cs",
+			CompileCommand:     []string{"mcs", "-optimize+", "-out:solution.exe", "solution.cs"},
+			RunCommand:         []string{"mono", "solution.exe"},
+			CompileDiagnostics: diagnostics.FormatMSBuild,
+			Artifacts:          []string{"solution.exe"},
+			AllowedCompileFlags: flagPatterns(
+				`-optimize[+-]`,
+				`-checked[+-]`,
+				`-define:[A-Za-z_][A-Za-z0-9_]*`,
+				`-warnaserror[+-]?`,
+			),
+			Env:       []string{"HOME=/home/sandbox"},
+			PidsLimit: 128,
+			CompileLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 15000, MemoryLimitKb: 512 * 1024},
+				Max:     Limits{TimeLimitMs: 30000, MemoryLimitKb: 1024 * 1024},
+			},
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 4000, MemoryLimitKb: 512 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 1024 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `using System;
+
+class Program {
+    static void Main() {
+        var parts = Console.ReadLine().Split(' ');
+        Console.WriteLine(long.Parse(parts[0]) + long.Parse(parts[1]));
+    }
+}
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "ruby",
+		Name: "Ruby",
+		Config: RuntimeConfig{
+			Image:              "ruby:3.3-slim",
+			SourceFile:         "solution.rb",
+			RunCommand:         []string{"ruby", "solution.rb"},
+			SyntaxCheckCommand: []string{"ruby", "-c", "solution.rb"},
+			CompileDiagnostics: diagnostics.FormatGCC,
+			RuntimeDiagnostics: diagnostics.FormatGCC,
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 5000, MemoryLimitKb: 256 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 512 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `a, b = gets.split.map(&:to_i)
+puts a + b
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "php",
+		Name: "PHP",
+		Config: RuntimeConfig{
+			Image:              "php:8.3-cli",
+			SourceFile:         "solution.php",
+			RunCommand:         []string{"php", "solution.php"},
+			SyntaxCheckCommand: []string{"php", "-l", "solution.php"},
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 5000, MemoryLimitKb: 256 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 512 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `<?php
+[$a, $b] = array_map('intval', explode(' ', trim(fgets(STDIN))));
+echo $a + $b, PHP_EOL;
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+
+	r.Register(Language{
+		ID:   "bash",
+		Name: "Bash",
+		Config: RuntimeConfig{
+			Image:              "bash:5.2",
+			SourceFile:         "solution.sh",
+			RunCommand:         []string{"bash", "solution.sh"},
+			SyntaxCheckCommand: []string{"bash", "-n", "solution.sh"},
+			RunLimits: PhaseLimits{
+				Default: Limits{TimeLimitMs: 5000, MemoryLimitKb: 256 * 1024},
+				Max:     Limits{TimeLimitMs: 15000, MemoryLimitKb: 512 * 1024},
+			},
+		},
+		SelfTest: SelfTest{
+			SourceCode: `read -r a b
+echo $((a + b))
+`,
+			Stdin:          sumStdin,
+			ExpectedOutput: sumOutput,
+		},
+	})
+}
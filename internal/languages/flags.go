@@ -0,0 +1,65 @@
+package languages
+
+import "regexp"
+
+// flagPatterns compiles allowlist entries, anchoring each so that a
+// pattern must match the whole flag.
+func flagPatterns(patterns ...string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		res[i] = regexp.MustCompile(`^(?:` + p + `)$`)
+	}
+	return res
+}
+
+// Shared fragments of the GCC allowlists. -W is limited to letters and
+// dashes so that -Wl,/-Wa,/-Wp, pass-through to other tools is rejected.
+const (
+	gccOptimize = `-O[0-3s]`
+	gccDefine   = `-D[A-Za-z_][A-Za-z0-9_]*(=[A-Za-z0-9_]*)?`
+	gccWarning  = `-W[a-z][a-z-]*|-w|-pedantic(-errors)?`
+)
+
+// CompileFlagAllowed reports whether a request may pass flag to the
+// compiler.
+func (c RuntimeConfig) CompileFlagAllowed(flag string) bool {
+	for _, re := range c.AllowedCompileFlags {
+		if re.MatchString(flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileCommandWith returns CompileCommand with request flags spliced in
+// at CompileFlagsIndex. The caller must have checked the flags.
+func (c RuntimeConfig) CompileCommandWith(flags []string) []string {
+	return c.withFlags(c.CompileCommand, flags)
+}
+
+// SyntaxCheckCommandWith returns SyntaxCheckCommand with request flags
+// spliced in the same way as CompileCommandWith.
+func (c RuntimeConfig) SyntaxCheckCommandWith(flags []string) []string {
+	return c.withFlags(c.SyntaxCheckCommand, flags)
+}
+
+func (c RuntimeConfig) withFlags(base, flags []string) []string {
+	if len(base) == 0 {
+		return nil
+	}
+	at := c.CompileFlagsIndex
+	if at <= 0 || at > len(base) {
+		at = len(base)
+	}
+	cmd := make([]string, 0, len(base)+len(flags))
+	cmd = append(cmd, base[:at]...)
+	cmd = append(cmd, flags...)
+	return append(cmd, base[at:]...)
+}
+
+// RunCommandWith returns RunCommand followed by the program arguments.
+func (c RuntimeConfig) RunCommandWith(args []string) []string {
+	cmd := make([]string, 0, len(c.RunCommand)+len(args))
+	cmd = append(cmd, c.RunCommand...)
+	return append(cmd, args...)
+}
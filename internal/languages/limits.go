@@ -0,0 +1,60 @@
+package languages
+
+// Limits is a time and memory budget for one phase of an execution.
+type Limits struct {
+	TimeLimitMs   int
+	MemoryLimitKb int
+}
+
+// PhaseLimits holds the budget applied when a request does not ask for one
+// and the ceiling a request may not exceed.
+type PhaseLimits struct {
+	Default Limits
+	Max     Limits
+}
+
+var (
+	DefaultCompileLimits = PhaseLimits{
+		Default: Limits{TimeLimitMs: 10000, MemoryLimitKb: 512 * 1024},
+		Max:     Limits{TimeLimitMs: 30000, MemoryLimitKb: 1024 * 1024},
+	}
+
+	DefaultRunLimits = PhaseLimits{
+		Default: Limits{TimeLimitMs: 2000, MemoryLimitKb: 256 * 1024},
+		Max:     Limits{TimeLimitMs: 10000, MemoryLimitKb: 512 * 1024},
+	}
+)
+
+// Compiled reports whether the runtime has a compile phase.
+func (c RuntimeConfig) Compiled() bool {
+	return len(c.CompileCommand) > 0
+}
+
+// EffectiveCompileLimits returns the compile limits with unset fields
+// filled from DefaultCompileLimits.
+func (c RuntimeConfig) EffectiveCompileLimits() PhaseLimits {
+	return c.CompileLimits.withFallback(DefaultCompileLimits)
+}
+
+// EffectiveRunLimits returns the run limits with unset fields filled from
+// DefaultRunLimits.
+func (c RuntimeConfig) EffectiveRunLimits() PhaseLimits {
+	return c.RunLimits.withFallback(DefaultRunLimits)
+}
+
+func (p PhaseLimits) withFallback(fallback PhaseLimits) PhaseLimits {
+	return PhaseLimits{
+		Default: p.Default.withFallback(fallback.Default),
+		Max:     p.Max.withFallback(fallback.Max),
+	}
+}
+
+func (l Limits) withFallback(fallback Limits) Limits {
+	if l.TimeLimitMs == 0 {
+		l.TimeLimitMs = fallback.TimeLimitMs
+	}
+	if l.MemoryLimitKb == 0 {
+		l.MemoryLimitKb = fallback.MemoryLimitKb
+	}
+	return l
+}
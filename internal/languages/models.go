@@ -1,14 +1,66 @@
 package languages
 
+import "regexp"
+
 type RuntimeConfig struct {
 	Image          string
 	SourceFile     string
 	CompileCommand []string
 	RunCommand     []string
+
+	// Env is passed to the sandbox container, e.g. to point toolchain
+	// caches at a writable directory.
+	Env []string
+	// PidsLimit overrides the sandbox default process/thread limit.
+	// Runtimes such as the JVM start a thread per core for GC and JIT.
+	PidsLimit int64
+	// WorkspaceSizeMb overrides the size of the /home/sandbox tmpfs.
+	WorkspaceSizeMb int
+	// SyntaxCheckCommand validates the source without producing runnable
+	// output, for editor feedback. Languages without one fall back to
+	// CompileCommand.
+	SyntaxCheckCommand []string
+
+	// CompileDiagnostics and RuntimeDiagnostics name the diagnostics
+	// format of compiler and crash output, if it can be parsed.
+	CompileDiagnostics string
+	RuntimeDiagnostics string
+
+	// Artifacts lists the files (shell globs) produced by CompileCommand
+	// that RunCommand needs. Languages that set it can reuse cached
+	// compiler output.
+	Artifacts []string
+
+	// AllowedCompileFlags is the allowlist for flags a request may add to
+	// CompileCommand; each pattern must match a flag in full. Flags are
+	// spliced in at CompileFlagsIndex, or appended when it is zero.
+	AllowedCompileFlags []*regexp.Regexp
+	CompileFlagsIndex   int
+
+	// MainClass is the class SourceFile and the commands are named after,
+	// for languages that require a public class to live in a file of the
+	// same name. ClassPattern finds the public class a submission declares
+	// in its first group; ForSource renames MainClass to it.
+	MainClass    string
+	ClassPattern *regexp.Regexp
+
+	// CompileLimits and RunLimits bound each phase. Zero fields fall back
+	// to DefaultCompileLimits and DefaultRunLimits.
+	CompileLimits PhaseLimits
+	RunLimits     PhaseLimits
+}
+
+// SelfTest is a known-good program used to verify that a runtime's image
+// and commands work end to end.
+type SelfTest struct {
+	SourceCode     string
+	Stdin          string
+	ExpectedOutput string
 }
 
 type Language struct {
-	ID     string
-	Name   string
-	Config RuntimeConfig
+	ID       string
+	Name     string
+	Config   RuntimeConfig
+	SelfTest SelfTest
 }
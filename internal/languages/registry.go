@@ -9,14 +9,19 @@ var (
 	ErrLanguageNotFound = errors.New("language not found")
 )
 
+// Registry holds the languages. A disabled language stays registered, so
+// jobs already queued for it still run, but new requests for it are
+// refused.
 type Registry struct {
 	mu        sync.RWMutex
 	languages map[string]Language
+	disabled  map[string]bool
 }
 
 func NewRegistry() *Registry {
 	r := &Registry{
 		languages: make(map[string]Language),
+		disabled:  make(map[string]bool),
 	}
 	r.registerDefaults()
 	return r
@@ -48,46 +53,37 @@ func (r *Registry) List() []Language {
 	return langs
 }
 
-func (r *Registry) registerDefaults() {
-	r.Register(Language{
-		ID:   "cpp",
-		Name: "C++",
-		Config: RuntimeConfig{
-			Image:          "gcc:13",
-			SourceFile:     "solution.cpp",
-			CompileCommand: []string{"g++", "solution.cpp", "-O2", "-o", "solution"},
-			RunCommand:     []string{"./solution"},
-		},
-	})
-
-	r.Register(Language{
-		ID:   "python",
-		Name: "Python",
-		Config: RuntimeConfig{
-			Image:      "python:3.11-slim",
-			SourceFile: "solution.py",
-			RunCommand: []string{"python", "solution.py"},
-		},
-	})
+// Available returns the languages that are not disabled.
+func (r *Registry) Available() []Language {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	langs := make([]Language, 0, len(r.languages))
+	for id, l := range r.languages {
+		if !r.disabled[id] {
+			langs = append(langs, l)
+		}
+	}
+	return langs
+}
 
-	r.Register(Language{
-		ID:   "javascript",
-		Name: "JavaScript",
-		Config: RuntimeConfig{
-			Image:      "node:20-slim",
-			SourceFile: "solution.js",
-			RunCommand: []string{"node", "solution.js"},
-		},
-	})
+// SetDisabled disables or re-enables a language.
+func (r *Registry) SetDisabled(id string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.languages[id]; !ok {
+		return ErrLanguageNotFound
+	}
+	if disabled {
+		r.disabled[id] = true
+	} else {
+		delete(r.disabled, id)
+	}
+	return nil
+}
 
-	r.Register(Language{
-		ID:   "typescript",
-		Name: "Typescript",
-		Config: RuntimeConfig{
-			Image:          "node:20-slim",
-			SourceFile:     "solution.ts",
-			CompileCommand: []string{"tsc", "solution.ts"},
-			RunCommand:     []string{"node", "solution.js"},
-		},
-	})
+// Disabled reports whether a language has been disabled.
+func (r *Registry) Disabled(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.disabled[id]
 }
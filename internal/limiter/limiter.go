@@ -1,12 +1,18 @@
 package limiter
 
 import (
+	"context"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/itstheanurag/executioner/internal/clientip"
 	"github.com/itstheanurag/executioner/internal/metrics"
 	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type RateLimiter struct {
@@ -72,15 +78,30 @@ func (rl *RateLimiter) Done() {
 	rl.mu.Unlock()
 }
 
+// InFlight returns the number of requests admitted and not yet done.
+func (rl *RateLimiter) InFlight() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return int(rl.currentConc)
+}
+
 func (rl *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return rl.MiddlewareWith(next, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	})
+}
+
+// MiddlewareWith is Middleware with reject answering the requests over
+// the limits.
+func (rl *RateLimiter) MiddlewareWith(next, reject http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
-		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-			ip = forwarded
+		ip := clientip.FromContext(r.Context())
+		if ip == "" {
+			ip = r.RemoteAddr
 		}
 
 		if !rl.Allow(ip) {
-			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			reject(w, r)
 			return
 		}
 		defer rl.Done()
@@ -89,6 +110,24 @@ func (rl *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// UnaryInterceptor applies the limits to the gRPC methods named, by full
+// method name, as Middleware does to HTTP handlers. client identifies the
+// caller for the per-IP limit.
+func (rl *RateLimiter) UnaryInterceptor(client func(context.Context) string, methods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if !rl.Allow(client(ctx)) {
+			return nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+		defer rl.Done()
+
+		return handler(ctx, req)
+	}
+}
+
 // CleanupOldLimiters removes IP limiters that haven't been used recently
 func (rl *RateLimiter) StartCleanup(interval time.Duration) {
 	go func() {
@@ -30,6 +30,80 @@ var (
 		},
 	)
 
+	QueueDepthByPriority = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "executioner_queue_depth_by_priority",
+			Help: "Current number of jobs in the queue per priority class",
+		},
+		[]string{"priority"},
+	)
+
+	QueueEstimatedWait = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "executioner_queue_estimated_wait_seconds",
+			Help: "Estimated time a newly queued job waits, from queue depth and recent throughput",
+		},
+	)
+
+	QueueFullRejections = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "executioner_queue_full_rejections_total",
+			Help: "Total number of jobs rejected because the queue was full",
+		},
+	)
+
+	JobsCancelled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "executioner_jobs_cancelled_total",
+			Help: "Total number of cancelled jobs, by the state they were in",
+		},
+		[]string{"state"}, // "queued" or "running"
+	)
+
+	JobRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "executioner_job_retries_total",
+			Help: "Total number of job attempts retried after an infrastructure error",
+		},
+		[]string{"language"},
+	)
+
+	DeadLetters = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "executioner_dead_letters_total",
+			Help: "Total number of jobs moved to the dead-letter store after exhausting retries",
+		},
+	)
+
+	WorkerPoolSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "executioner_worker_pool_size",
+			Help: "Current number of workers in the pool",
+		},
+	)
+
+	AutoscalerDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "executioner_autoscaler_decisions_total",
+			Help: "Total number of worker pool resizes by the autoscaler",
+		},
+		[]string{"direction", "reason"},
+	)
+
+	RemoteNodes = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "executioner_remote_nodes",
+			Help: "Current number of connected remote worker nodes",
+		},
+	)
+
+	RemoteJobsReassigned = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "executioner_remote_jobs_reassigned_total",
+			Help: "Total number of jobs taken back from lost remote worker nodes",
+		},
+	)
+
 	ActiveWorkers = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "executioner_active_workers",
@@ -60,4 +134,48 @@ var (
 			Help: "Total number of requests rejected by rate limiter",
 		},
 	)
+
+	CompileCacheRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "executioner_compile_cache_requests_total",
+			Help: "Compile cache lookups by result",
+		},
+		[]string{"result"}, // result: "hit", "miss"
+	)
+
+	CompileCacheBytes = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "executioner_compile_cache_bytes",
+			Help: "Size of compiled artifacts held in the compile cache",
+		},
+	)
+
+	CompileCacheEvictions = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "executioner_compile_cache_evictions_total",
+			Help: "Total number of compile cache entries evicted to stay within the size bound",
+		},
+	)
+
+	SandboxContainers = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "executioner_sandbox_containers",
+			Help: "Sandbox containers on the Docker host, from any instance, at the last reaper sweep",
+		},
+	)
+
+	SandboxContainersReaped = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "executioner_sandbox_containers_reaped_total",
+			Help: "Total number of sandbox containers removed by the reaper after outliving their maximum lifetime",
+		},
+	)
+
+	WebhookDeliveries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "executioner_webhook_deliveries_total",
+			Help: "Submission callback attempts by outcome",
+		},
+		[]string{"result"}, // result: "delivered", "retry", "failed"
+	)
 )
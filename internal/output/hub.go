@@ -0,0 +1,171 @@
+// Package output relays the output of jobs running in this process to the
+// clients following them, as the programs write it.
+package output
+
+import (
+	"io"
+	"sync"
+)
+
+// Chunk is a piece of a program's output.
+type Chunk struct {
+	Stderr bool
+	Data   []byte
+}
+
+// Hub holds the output of the jobs that local workers are running. A job's
+// output is kept until it finishes and nobody follows it any more, so a
+// client that starts following late still gets all of it.
+type Hub struct {
+	mu    sync.Mutex
+	feeds map[string]*feed
+}
+
+type feed struct {
+	// chunks is the output of the current attempt, the gen-th
+	chunks    []Chunk
+	gen       int
+	running   bool
+	finished  bool
+	followers map[*Follower]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{feeds: make(map[string]*feed)}
+}
+
+// feed returns the feed of job id, creating it if needed. h.mu must be
+// held.
+func (h *Hub) feed(id string) *feed {
+	f, ok := h.feeds[id]
+	if !ok {
+		f = &feed{followers: make(map[*Follower]struct{})}
+		h.feeds[id] = f
+	}
+	return f
+}
+
+// Start marks job id as running and returns writers for its stdout and
+// stderr. Finish must be called once the attempt is over. The output of an
+// earlier attempt is dropped, and followers start over from this one's.
+func (h *Hub) Start(id string) (stdout, stderr io.Writer) {
+	h.mu.Lock()
+	f := h.feed(id)
+	f.running, f.finished = true, false
+	f.chunks = nil
+	f.gen++
+	f.wake()
+	h.mu.Unlock()
+	return &writer{hub: h, id: id}, &writer{hub: h, id: id, stderr: true}
+}
+
+// Finish marks an attempt at job id as over. Its followers are woken to
+// look up the job's outcome; if it is retried, Start restarts the feed.
+func (h *Hub) Finish(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, ok := h.feeds[id]
+	if !ok {
+		return
+	}
+	f.running, f.finished = false, true
+	if len(f.followers) == 0 {
+		delete(h.feeds, id)
+		return
+	}
+	f.wake()
+}
+
+func (h *Hub) write(id string, stderr bool, p []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, ok := h.feeds[id]
+	if !ok || !f.running {
+		return
+	}
+	f.chunks = append(f.chunks, Chunk{Stderr: stderr, Data: append([]byte(nil), p...)})
+	f.wake()
+}
+
+func (f *feed) wake() {
+	for follower := range f.followers {
+		select {
+		case follower.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// writer appends what it is given to a job's feed. Writes never fail, so
+// a slow client cannot hold up the sandbox.
+type writer struct {
+	hub    *Hub
+	id     string
+	stderr bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.hub.write(w.id, w.stderr, p)
+	return len(p), nil
+}
+
+// Follower reads the output of one job. Jobs that run in another process
+// or on a remote node produce no output here, so followers must also
+// watch for the job to finish by other means.
+type Follower struct {
+	hub  *Hub
+	id   string
+	gen  int
+	next int
+	wake chan struct{}
+}
+
+// Follow starts following job id, which need not have started yet. The
+// Follower must be closed.
+func (h *Hub) Follow(id string) *Follower {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f := h.feed(id)
+	follower := &Follower{hub: h, id: id, gen: f.gen, wake: make(chan struct{}, 1)}
+	f.followers[follower] = struct{}{}
+	return follower
+}
+
+// Wait returns a channel that receives when Read may have more to return.
+func (f *Follower) Wait() <-chan struct{} {
+	return f.wake
+}
+
+// Read returns the output written since the last call. restarted reports
+// that a new attempt at the job began since then, in which case chunks
+// are from its start and earlier output is void. finished reports that
+// the latest attempt is over.
+func (f *Follower) Read() (chunks []Chunk, restarted, finished bool) {
+	f.hub.mu.Lock()
+	defer f.hub.mu.Unlock()
+	fd, ok := f.hub.feeds[f.id]
+	if !ok {
+		return nil, false, false
+	}
+	if fd.gen != f.gen {
+		f.gen, f.next = fd.gen, 0
+		restarted = true
+	}
+	chunks = fd.chunks[f.next:]
+	f.next = len(fd.chunks)
+	return chunks, restarted, fd.finished
+}
+
+// Close stops following the job.
+func (f *Follower) Close() {
+	f.hub.mu.Lock()
+	defer f.hub.mu.Unlock()
+	fd, ok := f.hub.feeds[f.id]
+	if !ok {
+		return
+	}
+	delete(fd.followers, f)
+	if len(fd.followers) == 0 && !fd.running {
+		delete(f.hub.feeds, f.id)
+	}
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"io"
+	"testing"
+)
+
+func write(t *testing.T, w io.Writer, s string) {
+	t.Helper()
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func text(chunks []Chunk) string {
+	var s string
+	for _, c := range chunks {
+		if c.Stderr {
+			s += "!"
+		}
+		s += string(c.Data)
+	}
+	return s
+}
+
+func TestFollowerReadsOutput(t *testing.T) {
+	h := NewHub()
+	stdout, stderr := h.Start("job-1")
+	write(t, stdout, "a")
+
+	// A late follower still gets the attempt's output from its start
+	f := h.Follow("job-1")
+	defer f.Close()
+	write(t, stderr, "b")
+	<-f.Wait()
+
+	chunks, restarted, finished := f.Read()
+	if got := text(chunks); got != "a!b" || restarted || finished {
+		t.Errorf("Read() = %q, %v, %v, want \"a!b\", false, false", got, restarted, finished)
+	}
+	write(t, stdout, "c")
+	if chunks, _, _ := f.Read(); text(chunks) != "c" {
+		t.Errorf("second Read() = %q, want \"c\"", text(chunks))
+	}
+
+	h.Finish("job-1")
+	<-f.Wait()
+	if chunks, _, finished := f.Read(); len(chunks) != 0 || !finished {
+		t.Errorf("Read() after Finish = %q, %v, want nothing, finished", text(chunks), finished)
+	}
+}
+
+func TestFollowerRestartsWithRetry(t *testing.T) {
+	h := NewHub()
+	f := h.Follow("job-1")
+	defer f.Close()
+
+	stdout, _ := h.Start("job-1")
+	write(t, stdout, "failed attempt")
+	f.Read()
+	h.Finish("job-1")
+	// Writes after an attempt is over are dropped
+	write(t, stdout, "late")
+
+	stdout, _ = h.Start("job-1")
+	write(t, stdout, "retry")
+	chunks, restarted, finished := f.Read()
+	if got := text(chunks); got != "retry" || !restarted || finished {
+		t.Errorf("Read() = %q, %v, %v, want \"retry\", true, false", got, restarted, finished)
+	}
+}
+
+func TestFeedIsDroppedWhenUnused(t *testing.T) {
+	h := NewHub()
+	h.Start("job-1")
+	h.Finish("job-1")
+	if len(h.feeds) != 0 {
+		t.Errorf("%d feeds left after an unfollowed job, want 0", len(h.feeds))
+	}
+
+	f := h.Follow("job-2")
+	h.Start("job-2")
+	h.Finish("job-2")
+	f.Close()
+	if len(h.feeds) != 0 {
+		t.Errorf("%d feeds left after the last follower closed, want 0", len(h.feeds))
+	}
+}
@@ -0,0 +1,237 @@
+package queue
+
+import (
+	"cmp"
+	"container/heap"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Priority classes for Job.Priority, from most to least urgent.
+const (
+	PriorityInteractive = "interactive"
+	PrioritySubmission  = "submission"
+	PriorityBatch       = "batch"
+)
+
+// Priorities lists the priority classes in order of urgency.
+var Priorities = []string{PriorityInteractive, PrioritySubmission, PriorityBatch}
+
+// priorityWeights is each class's share of the workers while all classes
+// have jobs queued: interactive runs get 8 slots for every batch job.
+var priorityWeights = map[string]float64{
+	PriorityInteractive: 8,
+	PrioritySubmission:  4,
+	PriorityBatch:       1,
+}
+
+// ValidPriority reports whether p is a known priority class. The empty
+// string is valid and selects PrioritySubmission, so that clients must
+// ask for interactive priority.
+func ValidPriority(p string) error {
+	if p == "" {
+		return nil
+	}
+	if _, ok := priorityWeights[p]; !ok {
+		return fmt.Errorf("unknown priority %q: must be one of %v", p, Priorities)
+	}
+	return nil
+}
+
+// normalize fills the scheduling fields a submitter left empty.
+func (j *Job) normalize() {
+	if j.Priority == "" {
+		j.Priority = PrioritySubmission
+	}
+	if j.Weight <= 0 {
+		j.Weight = 1
+	}
+	j.submitted = time.Now()
+}
+
+// ownedBy reports whether the job was submitted by tenant. Every job
+// matches the empty tenant.
+func (j *Job) ownedBy(tenant string) bool {
+	return tenant == "" || j.Tenant == tenant
+}
+
+// share is the job's weight in the fair queue: its class weight scaled by
+// its tenant's weight.
+func (j *Job) share() float64 {
+	return priorityWeights[j.Priority] * float64(j.Weight)
+}
+
+// streamKey identifies one tenant's jobs in one priority class.
+type streamKey struct {
+	tenant   string
+	priority string
+}
+
+// fairQueue orders jobs by start-time fair queuing. Each job is tagged
+// with a virtual finish time: it starts when the previous job of its
+// stream finishes, or at the current virtual time if the stream was idle,
+// and takes 1/share of virtual time. Popping the smallest tag gives every
+// (tenant, class) stream a share of dispatches proportional to its weight,
+// so a tenant bulk-submitting batch jobs cannot starve others.
+type fairQueue struct {
+	jobs  jobHeap
+	byID  map[string]*fairEntry
+	seq   uint64
+	vtime float64
+	// last holds the finish tag of each stream's newest queued job
+	last map[streamKey]float64
+	// queued counts jobs per stream and per class
+	queued  map[streamKey]int
+	classes map[string]int
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{
+		byID:    make(map[string]*fairEntry),
+		last:    make(map[streamKey]float64),
+		queued:  make(map[streamKey]int),
+		classes: make(map[string]int),
+	}
+}
+
+func (q *fairQueue) Len() int {
+	return len(q.jobs)
+}
+
+// depth returns the number of queued jobs in a priority class.
+func (q *fairQueue) depth(priority string) int {
+	return q.classes[priority]
+}
+
+func (q *fairQueue) push(job *Job) {
+	key := streamKey{job.Tenant, job.Priority}
+	start := q.vtime
+	if last, ok := q.last[key]; ok && last > start {
+		start = last
+	}
+	tag := start + 1/job.share()
+
+	q.last[key] = tag
+	q.queued[key]++
+	q.classes[job.Priority]++
+	q.seq++
+	e := &fairEntry{job: job, tag: tag, seq: q.seq}
+	q.byID[job.ID] = e
+	heap.Push(&q.jobs, e)
+}
+
+// pop removes the job with the smallest tag among those accepted by match
+// (all jobs if match is nil), or returns nil if none is accepted. Skipped
+// jobs keep their tags.
+func (q *fairQueue) pop(match func(*Job) bool) *Job {
+	var (
+		found   *fairEntry
+		skipped []*fairEntry
+	)
+	for len(q.jobs) > 0 {
+		e := heap.Pop(&q.jobs).(*fairEntry)
+		if match == nil || match(e.job) {
+			found = e
+			break
+		}
+		skipped = append(skipped, e)
+	}
+	for _, e := range skipped {
+		heap.Push(&q.jobs, e)
+	}
+	if found == nil {
+		return nil
+	}
+
+	q.vtime = found.tag
+	q.forget(found.job)
+	return found.job
+}
+
+// list returns the queued jobs in the order pop would hand them out.
+func (q *fairQueue) list() []*Job {
+	entries := slices.Clone(q.jobs)
+	slices.SortFunc(entries, func(a, b *fairEntry) int {
+		if a.tag != b.tag {
+			return cmp.Compare(a.tag, b.tag)
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	jobs := make([]*Job, len(entries))
+	for i, e := range entries {
+		jobs[i] = e.job
+	}
+	return jobs
+}
+
+// get returns a queued job, or nil if it is not queued.
+func (q *fairQueue) get(id string) *Job {
+	if e, ok := q.byID[id]; ok {
+		return e.job
+	}
+	return nil
+}
+
+// remove takes a job out of the queue, returning nil if it is not queued.
+// The stream keeps its tag, so cancelling does not buy a tenant an earlier
+// turn.
+func (q *fairQueue) remove(id string) *Job {
+	e, ok := q.byID[id]
+	if !ok {
+		return nil
+	}
+	heap.Remove(&q.jobs, e.index)
+	q.forget(e.job)
+	return e.job
+}
+
+func (q *fairQueue) forget(job *Job) {
+	delete(q.byID, job.ID)
+
+	key := streamKey{job.Tenant, job.Priority}
+	q.classes[job.Priority]--
+	if q.queued[key]--; q.queued[key] == 0 {
+		delete(q.queued, key)
+		delete(q.last, key)
+	}
+}
+
+type fairEntry struct {
+	job   *Job
+	tag   float64
+	seq   uint64
+	index int
+}
+
+// jobHeap is a min-heap of entries by tag, then submission order.
+type jobHeap []*fairEntry
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].tag != h[j].tag {
+		return h[i].tag < h[j].tag
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x any) {
+	e := x.(*fairEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,236 @@
+package queue
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+)
+
+// testJob describes a job to push onto a fairQueue.
+type testJob struct {
+	id       string
+	tenant   string
+	priority string
+	weight   int
+	language string
+}
+
+func (tj testJob) job() *Job {
+	j := &Job{
+		ID:       tj.id,
+		Tenant:   tj.tenant,
+		Priority: tj.priority,
+		Weight:   tj.weight,
+		Options:  executor.ExecuteOptions{LanguageID: tj.language},
+	}
+	j.normalize()
+	return j
+}
+
+func pushAll(q *fairQueue, jobs ...testJob) {
+	for _, tj := range jobs {
+		q.push(tj.job())
+	}
+}
+
+// popAll pops jobs accepted by match until none is left, returning their
+// IDs in order.
+func popAll(q *fairQueue, match func(*Job) bool) []string {
+	var ids []string
+	for {
+		j := q.pop(match)
+		if j == nil {
+			return ids
+		}
+		ids = append(ids, j.ID)
+	}
+}
+
+func ids(jobs []*Job) []string {
+	out := make([]string, len(jobs))
+	for i, j := range jobs {
+		out[i] = j.ID
+	}
+	return out
+}
+
+func TestFairQueueOrder(t *testing.T) {
+	python := func(j *Job) bool { return j.Options.LanguageID == "python" }
+
+	tests := []struct {
+		name  string
+		jobs  []testJob
+		match func(*Job) bool
+		want  []string
+		left  []string
+	}{
+		{
+			name: "a stream runs in submission order",
+			jobs: []testJob{
+				{id: "a1", tenant: "a"},
+				{id: "a2", tenant: "a"},
+				{id: "a3", tenant: "a"},
+			},
+			want: []string{"a1", "a2", "a3"},
+		},
+		{
+			name: "interactive overtakes batch",
+			jobs: []testJob{
+				{id: "b1", tenant: "a", priority: PriorityBatch},
+				{id: "s1", tenant: "a"},
+				{id: "i1", tenant: "a", priority: PriorityInteractive},
+			},
+			want: []string{"i1", "s1", "b1"},
+		},
+		{
+			name: "classes share by weight",
+			jobs: []testJob{
+				{id: "b1", tenant: "a", priority: PriorityBatch},
+				{id: "b2", tenant: "a", priority: PriorityBatch},
+				{id: "i1", tenant: "a", priority: PriorityInteractive},
+				{id: "i2", tenant: "a", priority: PriorityInteractive},
+				{id: "i3", tenant: "a", priority: PriorityInteractive},
+				{id: "i4", tenant: "a", priority: PriorityInteractive},
+				{id: "i5", tenant: "a", priority: PriorityInteractive},
+				{id: "i6", tenant: "a", priority: PriorityInteractive},
+				{id: "i7", tenant: "a", priority: PriorityInteractive},
+				{id: "i8", tenant: "a", priority: PriorityInteractive},
+				{id: "i9", tenant: "a", priority: PriorityInteractive},
+			},
+			want: []string{"i1", "i2", "i3", "i4", "i5", "i6", "i7", "b1", "i8", "i9", "b2"},
+		},
+		{
+			name: "a bulk submitter does not starve a late tenant",
+			jobs: []testJob{
+				{id: "a1", tenant: "a"},
+				{id: "a2", tenant: "a"},
+				{id: "a3", tenant: "a"},
+				{id: "b1", tenant: "b"},
+				{id: "b2", tenant: "b"},
+			},
+			want: []string{"a1", "b1", "a2", "b2", "a3"},
+		},
+		{
+			name: "tenant weight scales the share",
+			jobs: []testJob{
+				{id: "a1", tenant: "a", weight: 2},
+				{id: "a2", tenant: "a", weight: 2},
+				{id: "a3", tenant: "a", weight: 2},
+				{id: "a4", tenant: "a", weight: 2},
+				{id: "b1", tenant: "b"},
+				{id: "b2", tenant: "b"},
+			},
+			want: []string{"a1", "a2", "b1", "a3", "a4", "b2"},
+		},
+		{
+			name: "filtered pops skip other jobs",
+			jobs: []testJob{
+				{id: "a1", tenant: "a", language: "go"},
+				{id: "a2", tenant: "a", language: "python"},
+				{id: "b1", tenant: "b", language: "python"},
+				{id: "b2", tenant: "b", language: "go"},
+				{id: "a3", tenant: "a", language: "python"},
+			},
+			match: python,
+			want:  []string{"b1", "a2", "a3"},
+			left:  []string{"a1", "b2"},
+		},
+		{
+			name: "filtered pop with nothing accepted",
+			jobs: []testJob{
+				{id: "a1", tenant: "a", language: "go"},
+				{id: "b1", tenant: "b", language: "go"},
+			},
+			match: python,
+			left:  []string{"a1", "b1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newFairQueue()
+			pushAll(q, tt.jobs...)
+
+			if tt.match == nil {
+				if got := ids(q.list()); !slices.Equal(got, tt.want) {
+					t.Errorf("list() = %v, want %v", got, tt.want)
+				}
+			}
+			if got := popAll(q, tt.match); !slices.Equal(got, tt.want) {
+				t.Errorf("popped %v, want %v", got, tt.want)
+			}
+			if got := ids(q.list()); !slices.Equal(got, tt.left) {
+				t.Errorf("left %v, want %v", got, tt.left)
+			}
+			if q.Len() != len(tt.left) {
+				t.Errorf("Len() = %d, want %d", q.Len(), len(tt.left))
+			}
+		})
+	}
+}
+
+func TestFairQueueIdleStreamGetsNoCredit(t *testing.T) {
+	q := newFairQueue()
+	pushAll(q,
+		testJob{id: "a1", tenant: "a"},
+		testJob{id: "a2", tenant: "a"},
+		testJob{id: "a3", tenant: "a"},
+	)
+	if got := popAll(q, func(j *Job) bool { return j.ID != "a3" }); !slices.Equal(got, []string{"a1", "a2"}) {
+		t.Fatalf("popped %v, want [a1 a2]", got)
+	}
+
+	// b was idle while a ran, so it starts at the current virtual time
+	// rather than ahead of a's queued job
+	pushAll(q, testJob{id: "b1", tenant: "b"})
+	if got := popAll(q, nil); !slices.Equal(got, []string{"a3", "b1"}) {
+		t.Errorf("popped %v, want [a3 b1]", got)
+	}
+}
+
+func TestFairQueueRemoveKeepsStreamTag(t *testing.T) {
+	q := newFairQueue()
+	pushAll(q,
+		testJob{id: "a1", tenant: "a"},
+		testJob{id: "a2", tenant: "a"},
+		testJob{id: "b1", tenant: "b"},
+	)
+	if j := q.remove("a2"); j == nil || j.ID != "a2" {
+		t.Fatalf("remove(a2) = %v", j)
+	}
+	if j := q.remove("a2"); j != nil {
+		t.Errorf("second remove(a2) = %v, want nil", j)
+	}
+
+	// a3 takes the turn after the cancelled a2, not a2's
+	pushAll(q,
+		testJob{id: "a3", tenant: "a"},
+		testJob{id: "b2", tenant: "b"},
+	)
+	if got := popAll(q, nil); !slices.Equal(got, []string{"a1", "b1", "b2", "a3"}) {
+		t.Errorf("popped %v, want [a1 b1 b2 a3]", got)
+	}
+	if q.depth(PrioritySubmission) != 0 {
+		t.Errorf("depth = %d, want 0", q.depth(PrioritySubmission))
+	}
+}
+
+func TestFairQueueDepth(t *testing.T) {
+	q := newFairQueue()
+	pushAll(q,
+		testJob{id: "i1", tenant: "a", priority: PriorityInteractive},
+		testJob{id: "s1", tenant: "a"},
+		testJob{id: "s2", tenant: "b"},
+	)
+	if d := q.depth(PriorityInteractive); d != 1 {
+		t.Errorf("interactive depth = %d, want 1", d)
+	}
+	if d := q.depth(PrioritySubmission); d != 2 {
+		t.Errorf("submission depth = %d, want 2", d)
+	}
+	q.pop(nil)
+	if d := q.depth(PriorityInteractive); d != 0 {
+		t.Errorf("interactive depth after pop = %d, want 0", d)
+	}
+}
@@ -0,0 +1,782 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+)
+
+// Job states stored in the jobs table.
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// PostgresQueueOptions tunes leasing and polling.
+type PostgresQueueOptions struct {
+	// LeaseGrace is added to a job's own timeout to form its lease. A job
+	// whose lease expires is assumed to belong to a crashed worker and is
+	// handed out again.
+	LeaseGrace time.Duration
+	// MaxAttempts bounds how often an expired job is retried before it is
+	// marked failed.
+	MaxAttempts int
+	// PollInterval is how often idle workers check for new jobs, and how
+	// often Start's upkeep runs.
+	PollInterval time.Duration
+	// MaxDepth bounds the number of queued jobs; Submit rejects jobs beyond
+	// it with a *FullError.
+	MaxDepth int
+}
+
+// PostgresQueue is a durable queue backed by the jobs table. Workers lease
+// jobs with SELECT ... FOR UPDATE SKIP LOCKED, so several workers (or
+// processes) can share it without handing out a job twice.
+//
+// A job's outcome reaches its submitter directly when this process runs
+// it, and through the table when another does. Start must be called for
+// the latter, and to expire abandoned leases.
+//
+// Pausing intake applies to this process only; other processes sharing
+// the table keep accepting jobs.
+type PostgresQueue struct {
+	intake
+
+	db     *database.Database
+	opts   PostgresQueueOptions
+	logger *zerolog.Logger
+
+	// wake lets Submit rouse an idle worker without waiting for a poll
+	wake chan struct{}
+
+	// waiters holds jobs submitted by this process whose submitter is
+	// waiting on the result channels. Whoever removes a job from it
+	// delivers the outcome, so that it is delivered once.
+	mu      sync.Mutex
+	waiters map[string]*Job
+	// running holds jobs leased by this process, so Cancel can stop them
+	// without waiting for watch to poll
+	running map[string]*Job
+
+	// depth is the queued job count seen by the last updateDepth
+	depth      atomic.Int64
+	throughput throughput
+	closed     atomic.Bool
+}
+
+func NewPostgresQueue(db *database.Database, opts PostgresQueueOptions, logger *zerolog.Logger) *PostgresQueue {
+	if opts.LeaseGrace <= 0 {
+		opts.LeaseGrace = 30 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 500 * time.Millisecond
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 1000
+	}
+	return &PostgresQueue{
+		db:      db,
+		opts:    opts,
+		logger:  logger,
+		wake:    make(chan struct{}, 1),
+		waiters: make(map[string]*Job),
+		running: make(map[string]*Job),
+	}
+}
+
+// insertJob enqueues a job unless the queue is at its maximum depth. The
+// depth check, fair-queuing tag and insert are one statement, so
+// concurrent submitters cannot overshoot MaxDepth by much. Tags follow
+// fairQueue: a job starts at the current virtual time (that of the newest
+// running job) or after its stream's last queued job.
+const insertJob = `
+	INSERT INTO jobs (id, state, options, timeout_ms, priority, tenant, weight, tracked, vtime)
+	SELECT $1, $2, $3, $4, $5, $6, $7, $11, greatest(
+		coalesce(
+			(SELECT max(vtime) FROM jobs WHERE state = $8),
+			(SELECT min(vtime) FROM jobs WHERE state = $2),
+			0),
+		coalesce((SELECT max(vtime) FROM jobs WHERE state = $2 AND tenant = $6 AND priority = $5), 0)
+	) + $9
+	WHERE (SELECT count(*) FROM jobs WHERE state = $2) < $10`
+
+// insertArgs returns the arguments to insertJob for job.
+func (q *PostgresQueue) insertArgs(job *Job) ([]any, error) {
+	options, err := json.Marshal(job.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job options: %w", err)
+	}
+	return []any{
+		job.ID, StateQueued, options, job.Options.Timeout().Milliseconds(),
+		job.Priority, job.Tenant, job.Weight, StateRunning, 1 / job.share(), q.opts.MaxDepth, job.Tracked,
+	}, nil
+}
+
+// Submit rejects the job immediately if MaxDepth jobs are already queued:
+// unlike the in-memory queue there is nothing to wait on, and the depth is
+// shared with other processes.
+func (q *PostgresQueue) Submit(ctx context.Context, job *Job) error {
+	if q.closed.Load() {
+		return ErrShuttingDown
+	}
+	if q.Paused() {
+		return ErrPaused
+	}
+	job.normalize()
+	args, err := q.insertArgs(job)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	if _, ok := q.waiters[job.ID]; ok {
+		q.mu.Unlock()
+		return ErrDuplicateJob
+	}
+	q.waiters[job.ID] = job
+	q.mu.Unlock()
+
+	tag, err := q.db.Pool.Exec(ctx, insertJob, args...)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		q.forget(job.ID)
+		return ErrDuplicateJob
+	}
+	if err != nil {
+		q.forget(job.ID)
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		q.forget(job.ID)
+		return q.full()
+	}
+
+	q.notify()
+	return nil
+}
+
+// SubmitBatch inserts the jobs in one transaction, which is rolled back if
+// any of them would take the queue past MaxDepth.
+func (q *PostgresQueue) SubmitBatch(ctx context.Context, jobs []*Job) error {
+	if q.closed.Load() {
+		return ErrShuttingDown
+	}
+	if q.Paused() {
+		return ErrPaused
+	}
+	if len(jobs) > q.opts.MaxDepth {
+		return ErrBatchTooLarge
+	}
+
+	batch := &pgx.Batch{}
+	for _, job := range jobs {
+		job.normalize()
+		args, err := q.insertArgs(job)
+		if err != nil {
+			return err
+		}
+		batch.Queue(insertJob, args...)
+	}
+
+	q.mu.Lock()
+	for i, job := range jobs {
+		if _, ok := q.waiters[job.ID]; ok {
+			for _, added := range jobs[:i] {
+				delete(q.waiters, added.ID)
+			}
+			q.mu.Unlock()
+			return ErrDuplicateJob
+		}
+		q.waiters[job.ID] = job
+	}
+	q.mu.Unlock()
+
+	err := q.insertBatch(ctx, batch)
+	if err != nil {
+		q.mu.Lock()
+		for _, job := range jobs {
+			delete(q.waiters, job.ID)
+		}
+		q.mu.Unlock()
+		return err
+	}
+
+	q.notify()
+	return nil
+}
+
+func (q *PostgresQueue) insertBatch(ctx context.Context, batch *pgx.Batch) error {
+	tx, err := q.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue jobs: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	results := tx.SendBatch(ctx, batch)
+	for range batch.Len() {
+		tag, err := results.Exec()
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			results.Close()
+			return ErrDuplicateJob
+		}
+		if err != nil {
+			results.Close()
+			return fmt.Errorf("failed to enqueue jobs: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			results.Close()
+			return q.full()
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("failed to enqueue jobs: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to enqueue jobs: %w", err)
+	}
+	return nil
+}
+
+// full records a rejection for lack of room.
+func (q *PostgresQueue) full() error {
+	q.depth.Store(int64(q.opts.MaxDepth))
+	metrics.QueueFullRejections.Inc()
+	return &FullError{RetryAfter: q.EstimatedWait()}
+}
+
+// notify rouses an idle worker without waiting for a poll.
+func (q *PostgresQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *PostgresQueue) Next(ctx context.Context, languages ...string) (*Job, error) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if q.closed.Load() {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		job, err := q.lease(ctx, languages)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil {
+			return job, nil
+		}
+
+		select {
+		case <-q.wake:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// lease claims the runnable job with the smallest fair-queuing tag, or
+// returns nil if there is none. Running jobs whose lease has expired are
+// runnable again until they run out of attempts.
+func (q *PostgresQueue) lease(ctx context.Context, languages []string) (*Job, error) {
+	var (
+		id        string
+		options   []byte
+		attempts  int
+		timeoutMs int64
+		priority  string
+		tenant    string
+		weight    int
+		tracked   bool
+		failures  []byte
+	)
+	err := q.db.Pool.QueryRow(ctx, `
+		UPDATE jobs
+		SET state = $1,
+			attempts = attempts + 1,
+			leased_until = now() + (timeout_ms + $2) * interval '1 millisecond',
+			updated_at = now()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE ((state = $3 AND run_after <= now())
+				OR (state = $1 AND leased_until < now() AND attempts < $4))
+				AND (coalesce(cardinality($5::text[]), 0) = 0 OR options->>'LanguageID' = ANY($5))
+			ORDER BY vtime, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, options, attempts, timeout_ms, priority, tenant, weight, tracked, failures`,
+		StateRunning, q.opts.LeaseGrace.Milliseconds(), StateQueued, q.opts.MaxAttempts, languages,
+	).Scan(&id, &options, &attempts, &timeoutMs, &priority, &tenant, &weight, &tracked, &failures)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease job: %w", err)
+	}
+
+	job := &Job{ID: id, Priority: priority, Tenant: tenant, Weight: weight, Tracked: tracked, attempt: attempts}
+	if err := json.Unmarshal(failures, &job.Failures); err != nil {
+		q.logger.Warn().Err(err).Str("job_id", id).Msg("failed to decode job failures")
+	}
+	if err := json.Unmarshal(options, &job.Options); err != nil {
+		q.fail(ctx, id, attempts, fmt.Errorf("failed to decode job options: %w", err))
+		return nil, nil
+	}
+
+	if attempts > 1 {
+		q.logger.Warn().Str("job_id", id).Int("attempt", attempts).Msg("retrying job after expired lease")
+	}
+
+	// Jobs run to completion even if their submitter stops waiting, so the
+	// context is bounded by the job's own timeout only
+	job.Ctx, job.cancel = context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+
+	q.mu.Lock()
+	if waiter, ok := q.waiters[id]; ok {
+		job.Result = waiter.Result
+		job.Err = waiter.Err
+	}
+	q.running[id] = job
+	q.mu.Unlock()
+
+	go q.watch(job)
+
+	return job, nil
+}
+
+// watch cancels a running job when it is cancelled through another
+// process, polling its state until the job finishes.
+func (q *PostgresQueue) watch(job *Job) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-job.Ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var state string
+		err := q.db.Pool.QueryRow(job.Ctx, `SELECT state FROM jobs WHERE id = $1`, job.ID).Scan(&state)
+		if err == nil && state == StateCancelled {
+			job.cancel()
+			return
+		}
+	}
+}
+
+func (q *PostgresQueue) Complete(job *Job, result *executor.ExecutionResult, err error) bool {
+	if job.cancel != nil {
+		job.cancel()
+	}
+	q.mu.Lock()
+	_, running := q.running[job.ID]
+	q.mu.Unlock()
+	if !running {
+		// Abandoned and requeued for another process
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// If the write itself fails the outcome still counts, as the job was
+	// ours as far as this process knows
+	recorded := true
+	if err != nil {
+		recorded = q.fail(ctx, job.ID, job.attempt, err)
+	} else {
+		encoded, encErr := json.Marshal(result)
+		if encErr != nil {
+			q.logger.Error().Err(encErr).Str("job_id", job.ID).Msg("failed to encode job result")
+		}
+		// A cancelled job keeps its state but records the final result
+		tag, dbErr := q.db.Pool.Exec(ctx, `
+			UPDATE jobs
+			SET state = CASE WHEN state = $5 THEN state ELSE $1 END,
+				result = $2, leased_until = NULL, updated_at = now()
+			WHERE id = $3 AND attempts = $4 AND state IN ($5, $6)`,
+			StateCompleted, encoded, job.ID, job.attempt, StateCancelled, StateRunning,
+		)
+		if dbErr != nil {
+			q.logger.Error().Err(dbErr).Str("job_id", job.ID).Msg("failed to record job result")
+		} else {
+			recorded = tag.RowsAffected() > 0
+		}
+	}
+
+	q.throughput.record(time.Now())
+	q.mu.Lock()
+	delete(q.running, job.ID)
+	q.mu.Unlock()
+	if waiter, ok := q.claim(job.ID); ok {
+		waiter.deliver(result, err)
+	}
+	return recorded
+}
+
+// Retry puts the job back in the queued state with its failures recorded.
+// It keeps its fair-queuing tag, so it runs ahead of jobs submitted since.
+func (q *PostgresQueue) Retry(job *Job, delay time.Duration) {
+	q.mu.Lock()
+	_, running := q.running[job.ID]
+	delete(q.running, job.ID)
+	q.mu.Unlock()
+	job.cancel()
+	if !running {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	failures, err := json.Marshal(job.Failures)
+	if err != nil {
+		failures = []byte("[]")
+	}
+	_, err = q.db.Pool.Exec(ctx, `
+		UPDATE jobs
+		SET state = $1, run_after = now() + $2 * interval '1 millisecond',
+			failures = $3, leased_until = NULL, updated_at = now()
+		WHERE id = $4 AND attempts = $5 AND state = $6`,
+		StateQueued, delay.Milliseconds(), failures, job.ID, job.attempt, StateRunning,
+	)
+	if err != nil {
+		// The lease will expire and hand the job out again anyway
+		q.logger.Error().Err(err).Str("job_id", job.ID).Msg("failed to requeue job for retry")
+	}
+
+	// The retry runs after this process has gone
+	if q.closed.Load() {
+		if waiter, ok := q.claim(job.ID); ok {
+			waiter.deliver(nil, ErrShuttingDown)
+		}
+	}
+}
+
+func (q *PostgresQueue) Cancel(ctx context.Context, id, tenant string) error {
+	var previous string
+	err := q.db.Pool.QueryRow(ctx, `
+		UPDATE jobs j
+		SET state = $1, leased_until = NULL, updated_at = now()
+		FROM (
+			SELECT id, state FROM jobs
+			WHERE id = $2 AND state IN ($3, $4) AND ($5 = '' OR tenant = $5)
+			FOR UPDATE
+		) old
+		WHERE j.id = old.id
+		RETURNING old.state`,
+		StateCancelled, id, StateQueued, StateRunning, tenant,
+	).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrJobNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to cancel job: %w", err)
+	}
+	metrics.JobsCancelled.WithLabelValues(previous).Inc()
+
+	if previous == StateQueued {
+		result := CancelledResult()
+		if encoded, err := json.Marshal(result); err == nil {
+			_, _ = q.db.Pool.Exec(ctx, `UPDATE jobs SET result = $1 WHERE id = $2`, encoded, id)
+		}
+		q.updateDepth(ctx)
+
+		if waiter, ok := q.claim(id); ok {
+			waiter.deliver(result, nil)
+		}
+		return nil
+	}
+
+	// Running here: stop it now. Elsewhere, that process's watch will.
+	q.mu.Lock()
+	job, ok := q.running[id]
+	q.mu.Unlock()
+	if ok {
+		job.cancel()
+	}
+	return nil
+}
+
+// Close leaves queued jobs in the table for other processes, or for this
+// one after a restart. Their local submitters are told to give up, since
+// the results will not reach them.
+func (q *PostgresQueue) Close() int {
+	q.closed.Store(true)
+
+	q.mu.Lock()
+	var jobs []*Job
+	for id, job := range q.waiters {
+		if _, ok := q.running[id]; !ok {
+			delete(q.waiters, id)
+			jobs = append(jobs, job)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, job := range jobs {
+		job.deliver(nil, ErrShuttingDown)
+	}
+	return len(jobs)
+}
+
+// Abandon returns the jobs this process is running to the queue, without
+// counting the interrupted attempt.
+func (q *PostgresQueue) Abandon(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	jobs := q.running
+	q.running = make(map[string]*Job)
+	var waiters []*Job
+	for id := range jobs {
+		if waiter, ok := q.waiters[id]; ok {
+			delete(q.waiters, id)
+			waiters = append(waiters, waiter)
+		}
+	}
+	q.mu.Unlock()
+	if len(jobs) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(jobs))
+	for id, job := range jobs {
+		job.cancel()
+		ids = append(ids, id)
+	}
+	for _, waiter := range waiters {
+		waiter.deliver(nil, ErrShuttingDown)
+	}
+
+	_, err := q.db.Pool.Exec(ctx, `
+		UPDATE jobs
+		SET state = $1, attempts = attempts - 1, leased_until = NULL, updated_at = now()
+		WHERE id = ANY($2) AND state = $3`,
+		StateQueued, ids, StateRunning,
+	)
+	if err != nil {
+		// The leases will expire and hand the jobs out again anyway
+		return len(jobs), fmt.Errorf("failed to requeue running jobs: %w", err)
+	}
+	return len(jobs), nil
+}
+
+// Depth returns the queued job count as of the last upkeep run.
+func (q *PostgresQueue) Depth() int {
+	return int(q.depth.Load())
+}
+
+// Jobs lists the jobs of every process sharing the table. Queued jobs
+// waiting out a retry backoff are listed in their turn, although they are
+// skipped until it has passed.
+func (q *PostgresQueue) Jobs(ctx context.Context, limit int) ([]JobInfo, error) {
+	rows, err := q.db.Pool.Query(ctx, `
+		SELECT id, state, coalesce(options->>'LanguageID', ''), priority, tenant,
+			jsonb_array_length(failures), created_at
+		FROM jobs
+		WHERE state IN ($1, $2)
+		ORDER BY state = $1 DESC, vtime, created_at
+		LIMIT $3`,
+		StateRunning, StateQueued, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	jobs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[JobInfo])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// EstimatedWait uses this process's throughput, so it assumes other
+// processes sharing the table drain it at a similar rate.
+func (q *PostgresQueue) EstimatedWait() time.Duration {
+	return q.throughput.estimateWait(int(q.depth.Load()))
+}
+
+// fail marks a job failed, unless another worker has since leased it or
+// it was cancelled. It reports false in those cases.
+func (q *PostgresQueue) fail(ctx context.Context, id string, attempt int, jobErr error) bool {
+	tag, err := q.db.Pool.Exec(ctx, `
+		UPDATE jobs
+		SET state = $1, error = $2, leased_until = NULL, updated_at = now()
+		WHERE id = $3 AND attempts = $4 AND state = $5`,
+		StateFailed, jobErr.Error(), id, attempt, StateRunning,
+	)
+	if err != nil {
+		q.logger.Error().Err(err).Str("job_id", id).Msg("failed to record job failure")
+		return true
+	}
+	return tag.RowsAffected() > 0
+}
+
+// failExhausted gives up on expired jobs that have used all their attempts
+// and notifies any local submitter.
+func (q *PostgresQueue) failExhausted(ctx context.Context) error {
+	rows, err := q.db.Pool.Query(ctx, `
+		UPDATE jobs
+		SET state = $1, error = 'lease expired after ' || attempts || ' attempts', leased_until = NULL, updated_at = now()
+		WHERE state = $2 AND leased_until < now() AND attempts >= $3
+		RETURNING id`,
+		StateFailed, StateRunning, q.opts.MaxAttempts,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to expire jobs: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to expire jobs: %w", err)
+	}
+
+	for _, id := range ids {
+		q.logger.Error().Str("job_id", id).Int("attempts", q.opts.MaxAttempts).Msg("job abandoned after repeated lease expiry")
+		if waiter, ok := q.claim(id); ok {
+			waiter.deliver(nil, fmt.Errorf("job %s abandoned after %d attempts", id, q.opts.MaxAttempts))
+		}
+	}
+	return nil
+}
+
+func (q *PostgresQueue) forget(id string) {
+	q.claim(id)
+}
+
+// claim removes a job's local submitter, if it is still waiting, for the
+// caller to deliver the outcome to.
+func (q *PostgresQueue) claim(id string) (*Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waiter, ok := q.waiters[id]
+	delete(q.waiters, id)
+	return waiter, ok
+}
+
+// Start runs the queue's upkeep every PollInterval until Close: it fails
+// jobs whose leases have expired too often, refreshes the depth, and
+// delivers the outcomes of jobs submitted here and run by other processes.
+func (q *PostgresQueue) Start() {
+	go func() {
+		ticker := time.NewTicker(q.opts.PollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if q.closed.Load() {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := q.failExhausted(ctx); err != nil {
+				q.logger.Error().Err(err).Msg("failed to expire jobs")
+			}
+			q.updateDepth(ctx)
+			if err := q.collect(ctx); err != nil {
+				q.logger.Error().Err(err).Msg("failed to collect job results")
+			}
+			cancel()
+		}
+	}()
+}
+
+// collect delivers the outcomes of finished jobs that local submitters are
+// waiting for but another process ran. A job cancelled before its worker
+// recorded a result gets the cancelled result.
+func (q *PostgresQueue) collect(ctx context.Context) error {
+	q.mu.Lock()
+	ids := make([]string, 0, len(q.waiters))
+	for id := range q.waiters {
+		if _, ok := q.running[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	q.mu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := q.db.Pool.Query(ctx, `
+		SELECT id, state, result, coalesce(error, '')
+		FROM jobs
+		WHERE id = ANY($1) AND state IN ($2, $3, $4)`,
+		ids, StateCompleted, StateFailed, StateCancelled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query job results: %w", err)
+	}
+	var (
+		id, state, jobErr string
+		encoded           []byte
+	)
+	_, err = pgx.ForEachRow(rows, []any{&id, &state, &encoded, &jobErr}, func() error {
+		waiter, ok := q.claim(id)
+		if !ok {
+			return nil
+		}
+		switch {
+		case state == StateFailed:
+			waiter.deliver(nil, errors.New(jobErr))
+		case state == StateCancelled && encoded == nil:
+			waiter.deliver(CancelledResult(), nil)
+		default:
+			var result executor.ExecutionResult
+			if err := json.Unmarshal(encoded, &result); err != nil {
+				waiter.deliver(nil, fmt.Errorf("failed to decode job result: %w", err))
+				return nil
+			}
+			waiter.deliver(&result, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query job results: %w", err)
+	}
+	return nil
+}
+
+func (q *PostgresQueue) updateDepth(ctx context.Context) {
+	rows, err := q.db.Pool.Query(ctx, `SELECT priority, count(*) FROM jobs WHERE state = $1 GROUP BY priority`, StateQueued)
+	if err != nil {
+		return
+	}
+	var (
+		priority string
+		count    int
+	)
+	byPriority := make(map[string]int)
+	_, err = pgx.ForEachRow(rows, []any{&priority, &count}, func() error {
+		byPriority[priority] = count
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	depth := 0
+	for _, p := range Priorities {
+		depth += byPriority[p]
+		metrics.QueueDepthByPriority.WithLabelValues(p).Set(float64(byPriority[p]))
+	}
+	q.depth.Store(int64(depth))
+	metrics.QueueDepth.Set(float64(depth))
+}
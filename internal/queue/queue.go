@@ -2,6 +2,14 @@ package queue
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/itstheanurag/executioner/internal/executor"
 	"github.com/itstheanurag/executioner/internal/metrics"
@@ -13,27 +21,504 @@ type Job struct {
 	Result  chan *executor.ExecutionResult
 	Err     chan error
 	Ctx     context.Context
+
+	// Priority is one of the Priority* classes; empty means submission.
+	// Tenant identifies the submitter for fair scheduling, and Weight
+	// scales its share of the workers (default 1).
+	Priority string
+	Tenant   string
+	Weight   int
+
+	// Failures lists earlier attempts that hit infrastructure errors.
+	Failures []Failure
+
+	// Tracked marks asynchronous submissions, whose outcome is recorded
+	// in the submission store when the job finishes.
+	Tracked bool
+
+	// Set by queues that lease jobs: attempt fences completions from a
+	// worker whose lease has expired, cancel releases Ctx.
+	attempt int
+	cancel  context.CancelFunc
+	// base is the submitter's context, which Ctx derives from afresh on
+	// every attempt
+	base context.Context
+	// submitted is when the job entered the memory queue
+	submitted time.Time
+}
+
+// Queue hands submitted jobs to workers. Manager keeps jobs in memory;
+// PostgresQueue persists them so they survive restarts.
+type Queue interface {
+	// Submit enqueues job, waiting a bounded time for room. It returns a
+	// *FullError if the queue stays full, or ctx's error if the submitter
+	// gives up first.
+	Submit(ctx context.Context, job *Job) error
+	// SubmitBatch enqueues all of jobs or none of them, like Submit. It
+	// returns ErrBatchTooLarge if they could never fit in the queue at once.
+	SubmitBatch(ctx context.Context, jobs []*Job) error
+	// Next blocks until a job is available or ctx is done. If languages are
+	// given, only jobs for those languages are handed out.
+	Next(ctx context.Context, languages ...string) (*Job, error)
+	// Complete records the outcome of a job returned by Next and delivers
+	// it to the submitter if it is still waiting. It reports false if the
+	// outcome was discarded because the job had been abandoned or handed
+	// to another worker.
+	Complete(job *Job, result *executor.ExecutionResult, err error) bool
+	// Retry returns a job handed out by Next to the queue after a failed
+	// attempt, making it available to Next again after delay.
+	Retry(job *Job, delay time.Duration)
+	// Cancel stops a queued or running job. A queued job is removed and its
+	// submitter receives a cancelled result; a running job's context is
+	// cancelled, killing its container. Unless tenant is empty, only jobs
+	// submitted by tenant are cancelled. It returns ErrJobNotFound if the
+	// job has already finished, never existed or belongs to another tenant.
+	Cancel(ctx context.Context, id, tenant string) error
+	// Depth returns the number of jobs waiting for a worker.
+	Depth() int
+	// Jobs lists up to limit queued and running jobs: running jobs first,
+	// then queued ones in the order they are due to be handed out.
+	Jobs(ctx context.Context, limit int) ([]JobInfo, error)
+	// SetPaused pauses or resumes intake. While paused, Submit and
+	// SubmitBatch return ErrPaused, but queued jobs are still handed out.
+	SetPaused(paused bool)
+	// Paused reports whether intake is paused.
+	Paused() bool
+	// EstimatedWait predicts how long a job submitted now would wait for
+	// a worker, from the queue depth and recent throughput.
+	EstimatedWait() time.Duration
+	// Close stops accepting and handing out jobs, for shutdown. Submit
+	// returns ErrShuttingDown from then on, including to submitters waiting
+	// for room, and so do the submitters of queued jobs. Running jobs may
+	// still complete. It returns the number of queued jobs given up on.
+	Close() int
+	// Abandon gives up on the jobs still running after Close, once the
+	// workers have had their chance to finish them: their contexts are
+	// cancelled and their submitters receive ErrShuttingDown. Later
+	// Complete and Retry calls for them are ignored. It returns the number
+	// of jobs abandoned.
+	Abandon(ctx context.Context) (int, error)
+}
+
+var (
+	// ErrJobNotFound is returned by Cancel for unknown or finished jobs.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrDuplicateJob is returned by Submit when a job with the same ID is
+	// already queued or running, or, in PostgresQueue, recorded at all.
+	ErrDuplicateJob = errors.New("a job with this ID already exists")
+	// ErrShuttingDown is returned by Submit after Close, and delivered to
+	// the submitters of jobs dropped by Abandon.
+	ErrShuttingDown = errors.New("server is shutting down")
+	// ErrPaused is returned by Submit while intake is paused.
+	ErrPaused = errors.New("job intake is paused")
+	// ErrBatchTooLarge is returned by SubmitBatch for more jobs than the
+	// queue can hold.
+	ErrBatchTooLarge = errors.New("batch is larger than the queue's capacity")
+)
+
+// ErrQueueFull matches any *FullError.
+var ErrQueueFull = errors.New("job queue is full")
+
+// FullError is returned by Submit when the queue has no room.
+type FullError struct {
+	// RetryAfter is the estimated time until the queue has drained enough
+	// to accept the job.
+	RetryAfter time.Duration
+}
+
+func (e *FullError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrQueueFull, e.RetryAfter.Round(time.Second))
 }
 
+func (e *FullError) Is(target error) bool {
+	return target == ErrQueueFull
+}
+
+// JobInfo describes a queued or running job to operators. Failures counts
+// the earlier attempts that hit infrastructure errors.
+type JobInfo struct {
+	ID          string    `json:"id"`
+	State       string    `json:"state"`
+	Language    string    `json:"language"`
+	Priority    string    `json:"priority"`
+	Tenant      string    `json:"tenant"`
+	Failures    int       `json:"failures"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+func (j *Job) info(state string) JobInfo {
+	return JobInfo{
+		ID:          j.ID,
+		State:       state,
+		Language:    j.Options.LanguageID,
+		Priority:    j.Priority,
+		Tenant:      j.Tenant,
+		Failures:    len(j.Failures),
+		SubmittedAt: j.submitted,
+	}
+}
+
+// intake is the pause switch shared by the queue implementations.
+type intake struct {
+	paused atomic.Bool
+}
+
+func (i *intake) SetPaused(paused bool) {
+	i.paused.Store(paused)
+}
+
+func (i *intake) Paused() bool {
+	return i.paused.Load()
+}
+
+// NewJobID returns a random, globally unique job ID.
+func NewJobID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "job-" + hex.EncodeToString(b[:])
+}
+
+// CancelledResult is delivered to the submitter of a job cancelled before
+// a worker picked it up.
+func CancelledResult() *executor.ExecutionResult {
+	return &executor.ExecutionResult{
+		Status:    executor.StatusCancelled,
+		ErrorType: "Cancelled",
+	}
+}
+
+// deliver hands the outcome to a waiting submitter. The channels are
+// buffered, so this never blocks even if the submitter has given up.
+func (j *Job) deliver(result *executor.ExecutionResult, err error) {
+	if err != nil {
+		if j.Err != nil {
+			j.Err <- err
+		}
+		return
+	}
+	if j.Result != nil {
+		j.Result <- result
+	}
+}
+
+// DefaultSubmitWait bounds how long Submit waits for room in a full queue.
+const DefaultSubmitWait = 2 * time.Second
+
+// Manager is an in-memory queue. Jobs are dispatched by weighted fair
+// queuing across priority classes and tenants rather than in FIFO order.
 type Manager struct {
-	jobQueue chan *Job
+	intake
+
+	capacity   int
+	submitWait time.Duration
+	throughput throughput
+
+	mu   sync.Mutex
+	jobs *fairQueue
+	// running holds jobs handed to a worker, so Cancel can reach them
+	running map[string]*Job
+	// delayed holds jobs waiting out a retry backoff
+	delayed map[string]*delayedJob
+	// changed is closed and replaced whenever a job is pushed or popped,
+	// waking submitters waiting for room and workers waiting for jobs
+	changed chan struct{}
+	closed  bool
 }
 
-func NewManager(capacity int) *Manager {
+func NewManager(capacity int, submitWait time.Duration) *Manager {
+	if submitWait <= 0 {
+		submitWait = DefaultSubmitWait
+	}
 	return &Manager{
-		jobQueue: make(chan *Job, capacity),
+		capacity:   capacity,
+		submitWait: submitWait,
+		jobs:       newFairQueue(),
+		running:    make(map[string]*Job),
+		delayed:    make(map[string]*delayedJob),
+		changed:    make(chan struct{}),
+	}
+}
+
+func (m *Manager) Submit(ctx context.Context, job *Job) error {
+	return m.SubmitBatch(ctx, []*Job{job})
+}
+
+// SubmitBatch waits, up to the submit wait, until there is room for every
+// job.
+func (m *Manager) SubmitBatch(ctx context.Context, jobs []*Job) error {
+	if len(jobs) > m.capacity {
+		return ErrBatchTooLarge
+	}
+	ids := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		if ids[job.ID] {
+			return ErrDuplicateJob
+		}
+		ids[job.ID] = true
+		job.normalize()
+	}
+
+	var timer *time.Timer
+	for {
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			return ErrShuttingDown
+		}
+		if m.Paused() {
+			m.mu.Unlock()
+			return ErrPaused
+		}
+		for _, job := range jobs {
+			if m.active(job.ID) {
+				m.mu.Unlock()
+				return ErrDuplicateJob
+			}
+		}
+		if m.jobs.Len()+len(jobs) <= m.capacity {
+			for _, job := range jobs {
+				m.jobs.push(job)
+			}
+			m.notifyLocked()
+			m.mu.Unlock()
+			return nil
+		}
+		changed := m.changed
+		m.mu.Unlock()
+
+		if timer == nil {
+			timer = time.NewTimer(m.submitWait)
+			defer timer.Stop()
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			metrics.QueueFullRejections.Inc()
+			return &FullError{RetryAfter: m.EstimatedWait()}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 }
 
-func (m *Manager) Submit(job *Job) {
-	m.jobQueue <- job
-	metrics.QueueDepth.Set(float64(len(m.jobQueue)))
+func (m *Manager) Next(ctx context.Context, languages ...string) (*Job, error) {
+	match := languageFilter(languages)
+	for {
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		if job := m.jobs.pop(match); job != nil {
+			if job.base == nil {
+				job.base = job.Ctx
+			}
+			job.Ctx, job.cancel = context.WithCancel(job.base)
+			m.running[job.ID] = job
+			m.notifyLocked()
+			m.mu.Unlock()
+			return job, nil
+		}
+		changed := m.changed
+		m.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (m *Manager) Complete(job *Job, result *executor.ExecutionResult, err error) bool {
+	m.mu.Lock()
+	_, running := m.running[job.ID]
+	delete(m.running, job.ID)
+	m.mu.Unlock()
+	if job.cancel != nil {
+		job.cancel()
+	}
+	if !running {
+		// Abandoned; the submitter has been told already
+		return false
+	}
+
+	m.throughput.record(time.Now())
+	job.deliver(result, err)
+	return true
 }
 
-func (m *Manager) NextJob() <-chan *Job {
-	return m.jobQueue
+type delayedJob struct {
+	job   *Job
+	timer *time.Timer
 }
 
-func (m *Manager) UpdateQueueMetric() {
-	metrics.QueueDepth.Set(float64(len(m.jobQueue)))
+// Retry requeues the job once delay has passed. Requeued jobs may exceed
+// the queue's capacity briefly, as they were admitted already.
+func (m *Manager) Retry(job *Job, delay time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, running := m.running[job.ID]
+	delete(m.running, job.ID)
+	job.cancel()
+	if !running {
+		return
+	}
+	if m.closed {
+		job.deliver(nil, ErrShuttingDown)
+		return
+	}
+
+	d := &delayedJob{job: job}
+	d.timer = time.AfterFunc(delay, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.delayed[job.ID] != d {
+			return
+		}
+		delete(m.delayed, job.ID)
+		m.jobs.push(job)
+		m.notifyLocked()
+	})
+	m.delayed[job.ID] = d
+}
+
+func (m *Manager) Cancel(ctx context.Context, id, tenant string) error {
+	m.mu.Lock()
+	if d, ok := m.delayed[id]; ok && d.job.ownedBy(tenant) {
+		d.timer.Stop()
+		delete(m.delayed, id)
+		m.mu.Unlock()
+		metrics.JobsCancelled.WithLabelValues(StateQueued).Inc()
+		d.job.deliver(CancelledResult(), nil)
+		return nil
+	}
+	if job := m.jobs.get(id); job != nil && job.ownedBy(tenant) {
+		m.jobs.remove(id)
+		m.notifyLocked()
+		m.mu.Unlock()
+		metrics.JobsCancelled.WithLabelValues(StateQueued).Inc()
+		job.deliver(CancelledResult(), nil)
+		return nil
+	}
+	job, ok := m.running[id]
+	m.mu.Unlock()
+	if !ok || !job.ownedBy(tenant) {
+		return ErrJobNotFound
+	}
+
+	// The worker sees the cancelled context and reports the outcome
+	metrics.JobsCancelled.WithLabelValues(StateRunning).Inc()
+	job.cancel()
+	return nil
+}
+
+// Close drops the queued jobs and those waiting out a retry backoff, since
+// nothing survives the process.
+func (m *Manager) Close() int {
+	m.mu.Lock()
+	m.closed = true
+	var jobs []*Job
+	for job := m.jobs.pop(nil); job != nil; job = m.jobs.pop(nil) {
+		jobs = append(jobs, job)
+	}
+	for id, d := range m.delayed {
+		d.timer.Stop()
+		delete(m.delayed, id)
+		jobs = append(jobs, d.job)
+	}
+	m.notifyLocked()
+	m.mu.Unlock()
+
+	for _, job := range jobs {
+		job.deliver(nil, ErrShuttingDown)
+	}
+	return len(jobs)
+}
+
+func (m *Manager) Abandon(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	jobs := m.running
+	m.running = make(map[string]*Job)
+	m.mu.Unlock()
+
+	for _, job := range jobs {
+		job.cancel()
+		job.deliver(nil, ErrShuttingDown)
+	}
+	return len(jobs), nil
+}
+
+// languageFilter matches jobs for the given languages, or every job if
+// there are none.
+func languageFilter(languages []string) func(*Job) bool {
+	if len(languages) == 0 {
+		return nil
+	}
+	return func(job *Job) bool {
+		return slices.Contains(languages, job.Options.LanguageID)
+	}
+}
+
+// active reports whether a job is queued or running. m.mu must be held.
+func (m *Manager) active(id string) bool {
+	if _, ok := m.running[id]; ok {
+		return true
+	}
+	if _, ok := m.delayed[id]; ok {
+		return true
+	}
+	_, ok := m.jobs.byID[id]
+	return ok
+}
+
+func (m *Manager) Depth() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs.Len()
+}
+
+// Jobs lists jobs waiting out a retry backoff as queued, after the others.
+func (m *Manager) Jobs(ctx context.Context, limit int) ([]JobInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var jobs []JobInfo
+	for _, job := range m.running {
+		jobs = append(jobs, job.info(StateRunning))
+	}
+	slices.SortFunc(jobs, func(a, b JobInfo) int {
+		return a.SubmittedAt.Compare(b.SubmittedAt)
+	})
+	for _, job := range m.jobs.list() {
+		jobs = append(jobs, job.info(StateQueued))
+	}
+	for _, d := range m.delayed {
+		jobs = append(jobs, d.job.info(StateQueued))
+	}
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (m *Manager) EstimatedWait() time.Duration {
+	m.mu.Lock()
+	depth := m.jobs.Len()
+	m.mu.Unlock()
+	return m.throughput.estimateWait(depth)
+}
+
+// notifyLocked wakes all waiters and refreshes the depth metrics. m.mu
+// must be held.
+func (m *Manager) notifyLocked() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+
+	metrics.QueueDepth.Set(float64(m.jobs.Len()))
+	for _, p := range Priorities {
+		metrics.QueueDepthByPriority.WithLabelValues(p).Set(float64(m.jobs.depth(p)))
+	}
 }
@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"time"
+)
+
+// Failure records one failed attempt at a job.
+type Failure struct {
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error"`
+	At      time.Time `json:"at"`
+}
+
+// RetryPolicy decides how jobs hit by infrastructure errors are retried.
+// The zero value never retries.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// BaseDelay is the wait before the first retry; it doubles with every
+	// further retry, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Delay returns the backoff before the given retry, counting from 1.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Deadline bounds how long a submitter waits for a job whose attempts each
+// take up to timeout: every attempt plus the backoff between them.
+func (p RetryPolicy) Deadline(timeout time.Duration) time.Duration {
+	deadline := timeout
+	for retry := 1; retry <= p.MaxRetries; retry++ {
+		deadline += p.Delay(retry) + timeout
+	}
+	return deadline
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/metrics"
+)
+
+// throughputWindow is the period over which completed jobs are counted to
+// estimate how fast the queue drains.
+const throughputWindow = 60
+
+// defaultRetryAfter is suggested to rejected clients when there is no
+// recent throughput to estimate from.
+const defaultRetryAfter = 5 * time.Second
+
+// throughput counts completed jobs in one-second buckets over a sliding
+// window.
+type throughput struct {
+	mu      sync.Mutex
+	counts  [throughputWindow]int
+	seconds [throughputWindow]int64
+}
+
+func (t *throughput) record(now time.Time) {
+	sec := now.Unix()
+	i := sec % throughputWindow
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seconds[i] != sec {
+		t.seconds[i] = sec
+		t.counts[i] = 0
+	}
+	t.counts[i]++
+}
+
+// rate returns completed jobs per second over the window.
+func (t *throughput) rate(now time.Time) float64 {
+	sec := now.Unix()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	total := 0
+	for i := range t.counts {
+		if sec-t.seconds[i] < throughputWindow {
+			total += t.counts[i]
+		}
+	}
+	return float64(total) / throughputWindow
+}
+
+// estimateWait predicts how long a newly queued job waits before a worker
+// picks it up. It returns 0 when the queue is empty and defaultRetryAfter
+// when nothing has completed recently.
+func (t *throughput) estimateWait(depth int) time.Duration {
+	var wait time.Duration
+	if depth > 0 {
+		wait = defaultRetryAfter
+		if rate := t.rate(time.Now()); rate > 0 {
+			wait = time.Duration(float64(depth) / rate * float64(time.Second))
+		}
+	}
+	metrics.QueueEstimatedWait.Set(wait.Seconds())
+	return wait
+}
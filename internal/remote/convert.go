@@ -0,0 +1,87 @@
+package remote
+
+import (
+	"github.com/itstheanurag/executioner/internal/diagnostics"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/workerpb"
+)
+
+func optionsToProto(opts executor.ExecuteOptions) *workerpb.ExecuteOptions {
+	return &workerpb.ExecuteOptions{
+		LanguageId:           opts.LanguageID,
+		SourceCode:           []byte(opts.SourceCode),
+		Stdin:                []byte(opts.Stdin),
+		TimeLimitMs:          int32(opts.TimeLimitMs),
+		MemoryLimitKb:        int32(opts.MemoryLimitKb),
+		CompileTimeLimitMs:   int32(opts.CompileTimeLimitMs),
+		CompileMemoryLimitKb: int32(opts.CompileMemoryLimitKb),
+		CompilerOptions:      opts.CompilerOptions,
+		Args:                 opts.Args,
+		Mode:                 opts.Mode,
+	}
+}
+
+func optionsFromProto(opts *workerpb.ExecuteOptions) executor.ExecuteOptions {
+	return executor.ExecuteOptions{
+		LanguageID:           opts.GetLanguageId(),
+		SourceCode:           string(opts.GetSourceCode()),
+		Stdin:                string(opts.GetStdin()),
+		TimeLimitMs:          int(opts.GetTimeLimitMs()),
+		MemoryLimitKb:        int(opts.GetMemoryLimitKb()),
+		CompileTimeLimitMs:   int(opts.GetCompileTimeLimitMs()),
+		CompileMemoryLimitKb: int(opts.GetCompileMemoryLimitKb()),
+		CompilerOptions:      opts.GetCompilerOptions(),
+		Args:                 opts.GetArgs(),
+		Mode:                 opts.GetMode(),
+	}
+}
+
+func resultToProto(res *executor.ExecutionResult) *workerpb.ExecutionResult {
+	diags := make([]*workerpb.Diagnostic, len(res.Diagnostics))
+	for i, d := range res.Diagnostics {
+		diags[i] = &workerpb.Diagnostic{
+			File:     d.File,
+			Line:     int32(d.Line),
+			Column:   int32(d.Column),
+			Severity: d.Severity,
+			Message:  d.Message,
+		}
+	}
+	return &workerpb.ExecutionResult{
+		Status:         res.Status,
+		Stdout:         []byte(res.Stdout),
+		Stderr:         []byte(res.Stderr),
+		ExitCode:       int32(res.ExitCode),
+		TimeMs:         res.TimeMs,
+		MemoryKb:       res.MemoryKb,
+		ErrorType:      res.ErrorType,
+		Diagnostics:    diags,
+		CompileCommand: res.CompileCommand,
+		RunCommand:     res.RunCommand,
+	}
+}
+
+func resultFromProto(res *workerpb.ExecutionResult) *executor.ExecutionResult {
+	var diags []diagnostics.Diagnostic
+	for _, d := range res.GetDiagnostics() {
+		diags = append(diags, diagnostics.Diagnostic{
+			File:     d.GetFile(),
+			Line:     int(d.GetLine()),
+			Column:   int(d.GetColumn()),
+			Severity: d.GetSeverity(),
+			Message:  d.GetMessage(),
+		})
+	}
+	return &executor.ExecutionResult{
+		Status:         res.GetStatus(),
+		Stdout:         string(res.GetStdout()),
+		Stderr:         string(res.GetStderr()),
+		ExitCode:       int(res.GetExitCode()),
+		TimeMs:         res.GetTimeMs(),
+		MemoryKb:       res.GetMemoryKb(),
+		ErrorType:      res.GetErrorType(),
+		Diagnostics:    diags,
+		CompileCommand: res.GetCompileCommand(),
+		RunCommand:     res.GetRunCommand(),
+	}
+}
@@ -0,0 +1,466 @@
+// Package remote runs jobs on worker nodes in other processes. The API
+// server's Dispatcher hands jobs from its queue to connected nodes over
+// gRPC, and a Node runs them in its local sandbox.
+package remote
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/worker"
+	"github.com/itstheanurag/executioner/internal/workerpb"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nextJobBackoff is how long a node's dispatch loop waits after the queue
+// fails to hand out a job.
+const nextJobBackoff = time.Second
+
+// drainPollInterval is how often Drain checks for jobs still on nodes.
+const drainPollInterval = 100 * time.Millisecond
+
+// DispatcherOptions sets the heartbeat protocol.
+type DispatcherOptions struct {
+	// HeartbeatInterval is how often nodes are asked to send heartbeats.
+	HeartbeatInterval time.Duration
+	// HeartbeatTimeout is how long a node may stay silent before it is
+	// considered dead and its jobs are reassigned.
+	HeartbeatTimeout time.Duration
+}
+
+// Dispatcher implements WorkerService. Each connected node gets a dispatch
+// loop that pulls jobs for the node's languages from the queue while the
+// node has free capacity. Results go through the same Finisher as local
+// workers, so infrastructure errors, including losing a node, are retried.
+type Dispatcher struct {
+	workerpb.UnimplementedWorkerServiceServer
+
+	queue    queue.Queue
+	finisher *worker.Finisher
+	opts     DispatcherOptions
+	logger   *zerolog.Logger
+
+	// draining is closed by Drain to stop handing out jobs; inflight
+	// counts the jobs assigned to nodes
+	draining  chan struct{}
+	drainOnce sync.Once
+	inflight  atomic.Int64
+
+	// nodes holds the connected nodes by ID
+	mu    sync.Mutex
+	nodes map[string]*node
+}
+
+func NewDispatcher(q queue.Queue, finisher *worker.Finisher, opts DispatcherOptions, logger *zerolog.Logger) *Dispatcher {
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = 5 * time.Second
+	}
+	if opts.HeartbeatTimeout <= 0 {
+		opts.HeartbeatTimeout = 3 * opts.HeartbeatInterval
+	}
+	return &Dispatcher{
+		queue:    q,
+		finisher: finisher,
+		opts:     opts,
+		logger:   logger,
+		draining: make(chan struct{}),
+		nodes:    make(map[string]*node),
+	}
+}
+
+// NodeStatus describes a connected node and the jobs it is running.
+type NodeStatus struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	Languages     []string             `json:"languages"`
+	Capacity      int                  `json:"capacity"`
+	LastHeartbeat time.Time            `json:"last_heartbeat"`
+	Jobs          []*worker.RunningJob `json:"jobs"`
+}
+
+// Nodes returns the status of every connected node, by name.
+func (d *Dispatcher) Nodes() []NodeStatus {
+	d.mu.Lock()
+	nodes := make([]*node, 0, len(d.nodes))
+	for _, n := range d.nodes {
+		nodes = append(nodes, n)
+	}
+	d.mu.Unlock()
+
+	statuses := make([]NodeStatus, len(nodes))
+	for i, n := range nodes {
+		n.mu.Lock()
+		jobs := make([]*worker.RunningJob, 0, len(n.jobs))
+		for _, a := range n.jobs {
+			job := worker.NewRunningJob(a.job)
+			job.StartedAt = a.started
+			jobs = append(jobs, job)
+		}
+		statuses[i] = NodeStatus{
+			ID:            n.id,
+			Name:          n.name,
+			Languages:     n.languages,
+			Capacity:      cap(n.slots),
+			LastHeartbeat: n.lastHeartbeat,
+			Jobs:          jobs,
+		}
+		n.mu.Unlock()
+		slices.SortFunc(jobs, func(a, b *worker.RunningJob) int {
+			return a.StartedAt.Compare(b.StartedAt)
+		})
+	}
+	slices.SortFunc(statuses, func(a, b NodeStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return statuses
+}
+
+// Drain stops sending jobs to nodes and waits until the jobs they hold have
+// finished or ctx is done. Nodes stay connected, so their results still
+// arrive and jobs abandoned through the queue are cancelled on them.
+func (d *Dispatcher) Drain(ctx context.Context) error {
+	d.drainOnce.Do(func() { close(d.draining) })
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for d.inflight.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// node is a connected worker node.
+type node struct {
+	id        string
+	name      string
+	languages []string
+	logger    zerolog.Logger
+
+	// slots holds a token per job running on the node
+	slots chan struct{}
+
+	sendMu sync.Mutex
+	stream workerpb.WorkerService_ConnectServer
+
+	mu            sync.Mutex
+	jobs          map[string]*assignment
+	lastHeartbeat time.Time
+}
+
+// assignment is a job running on a node.
+type assignment struct {
+	job     *queue.Job
+	started time.Time
+	// done is closed when the node reports the job or is lost
+	done chan struct{}
+}
+
+func (d *Dispatcher) Connect(stream workerpb.WorkerService_ConnectServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	reg := first.GetRegister()
+	if reg == nil {
+		return status.Error(codes.InvalidArgument, "first message must be Register")
+	}
+	if reg.GetCapacity() < 1 || len(reg.GetLanguages()) == 0 {
+		return status.Error(codes.InvalidArgument, "node must offer at least one language and a capacity of at least 1")
+	}
+
+	n := &node{
+		id:            newNodeID(),
+		name:          reg.GetName(),
+		languages:     reg.GetLanguages(),
+		slots:         make(chan struct{}, reg.GetCapacity()),
+		stream:        stream,
+		jobs:          make(map[string]*assignment),
+		lastHeartbeat: time.Now(),
+	}
+	n.logger = d.logger.With().Str("node_id", n.id).Str("node", n.name).Logger()
+
+	if err := n.send(&workerpb.ServerMessage{Message: &workerpb.ServerMessage_Registered{
+		Registered: &workerpb.Registered{
+			NodeId:              n.id,
+			HeartbeatIntervalMs: d.opts.HeartbeatInterval.Milliseconds(),
+		},
+	}}); err != nil {
+		return err
+	}
+
+	n.logger.Info().
+		Strs("languages", n.languages).
+		Int32("capacity", reg.GetCapacity()).
+		Str("version", reg.GetVersion()).
+		Msg("worker node connected")
+	metrics.RemoteNodes.Inc()
+	d.mu.Lock()
+	d.nodes[n.id] = n
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d.dispatch(ctx, cancel, n)
+	}()
+	go func() {
+		defer wg.Done()
+		d.watchHeartbeats(ctx, cancel, n)
+	}()
+
+	err = d.receive(ctx, n)
+	cancel()
+	wg.Wait()
+
+	d.mu.Lock()
+	delete(d.nodes, n.id)
+	d.mu.Unlock()
+	d.reassign(n)
+	metrics.RemoteNodes.Dec()
+	n.logger.Info().Err(err).Msg("worker node disconnected")
+	return err
+}
+
+// receive handles heartbeats and results until the stream fails or ctx is
+// cancelled.
+func (d *Dispatcher) receive(ctx context.Context, n *node) error {
+	msgs := make(chan *workerpb.NodeMessage)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := n.stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case msg := <-msgs:
+			n.mu.Lock()
+			n.lastHeartbeat = time.Now()
+			n.mu.Unlock()
+
+			switch {
+			case msg.GetResult() != nil:
+				d.complete(n, msg.GetResult())
+			case msg.GetHeartbeat() != nil:
+				d.reconcile(n, msg.GetHeartbeat().GetRunningJobIds())
+			}
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// dispatch sends jobs to the node while it has free slots, until the
+// session ends or the dispatcher drains.
+func (d *Dispatcher) dispatch(ctx context.Context, cancel context.CancelFunc, n *node) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() {
+		select {
+		case <-d.draining:
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case n.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		job, err := d.queue.Next(ctx, n.languages...)
+		if err != nil {
+			<-n.slots
+			if ctx.Err() != nil {
+				return
+			}
+			n.logger.Error().Err(err).Msg("failed to fetch job for node")
+			select {
+			case <-time.After(nextJobBackoff):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		a := &assignment{job: job, started: time.Now(), done: make(chan struct{})}
+		d.inflight.Add(1)
+		n.mu.Lock()
+		n.jobs[job.ID] = a
+		n.mu.Unlock()
+
+		err = n.send(&workerpb.ServerMessage{Message: &workerpb.ServerMessage_Job{
+			Job: &workerpb.Job{Id: job.ID, Options: optionsToProto(job.Options)},
+		}})
+		if err != nil {
+			// The job is reassigned once the session ends
+			n.logger.Error().Err(err).Str("job_id", job.ID).Msg("failed to send job to node")
+			cancel()
+			return
+		}
+		n.logger.Info().Str("job_id", job.ID).Msg("dispatched job to node")
+		go d.propagateCancel(n, a)
+	}
+}
+
+// propagateCancel tells the node to stop a job whose context ends, e.g.
+// because it was cancelled through the queue.
+func (d *Dispatcher) propagateCancel(n *node, a *assignment) {
+	select {
+	case <-a.job.Ctx.Done():
+		_ = n.send(&workerpb.ServerMessage{Message: &workerpb.ServerMessage_Cancel{
+			Cancel: &workerpb.CancelJob{Id: a.job.ID},
+		}})
+	case <-a.done:
+	}
+}
+
+// watchHeartbeats ends the session when the node goes silent.
+func (d *Dispatcher) watchHeartbeats(ctx context.Context, cancel context.CancelFunc, n *node) {
+	ticker := time.NewTicker(d.opts.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n.mu.Lock()
+			silent := time.Since(n.lastHeartbeat)
+			n.mu.Unlock()
+			if silent > d.opts.HeartbeatTimeout {
+				n.logger.Warn().Dur("silent", silent).Msg("worker node missed heartbeats")
+				cancel()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// complete reports a job's outcome. Results for jobs the node no longer
+// holds, because they were reassigned, are dropped.
+func (d *Dispatcher) complete(n *node, res *workerpb.JobResult) {
+	a := n.take(res.GetId())
+	if a == nil {
+		n.logger.Warn().Str("job_id", res.GetId()).Msg("dropping result for unknown job")
+		return
+	}
+	<-n.slots
+	defer d.inflight.Add(-1)
+
+	duration := time.Duration(res.GetDurationMs()) * time.Millisecond
+	if msg := res.GetInfrastructureError(); msg != "" {
+		err := &executor.InfrastructureError{Err: fmt.Errorf("node %s: %s", n.name, msg)}
+		d.finisher.Finish(a.job, nil, duration, err)
+		return
+	}
+	if res.GetResult() == nil {
+		err := &executor.InfrastructureError{Err: fmt.Errorf("node %s sent an empty result", n.name)}
+		d.finisher.Finish(a.job, nil, duration, err)
+		return
+	}
+	d.finisher.Finish(a.job, resultFromProto(res.GetResult()), duration, nil)
+}
+
+// reconcile reassigns jobs the node has forgotten, e.g. because it
+// restarted within the heartbeat timeout. Jobs sent recently may not be in
+// the node's list yet, so only those older than the timeout are checked.
+func (d *Dispatcher) reconcile(n *node, running []string) {
+	n.mu.Lock()
+	var lost []*assignment
+	for id, a := range n.jobs {
+		if time.Since(a.started) > d.opts.HeartbeatTimeout && !slices.Contains(running, id) {
+			delete(n.jobs, id)
+			close(a.done)
+			lost = append(lost, a)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, a := range lost {
+		<-n.slots
+		d.lose(n, a)
+	}
+}
+
+// reassign returns the jobs of a lost node to the queue through the retry
+// path, so a job that keeps killing nodes ends up in the dead letters.
+func (d *Dispatcher) reassign(n *node) {
+	n.mu.Lock()
+	jobs := n.jobs
+	n.jobs = make(map[string]*assignment)
+	n.mu.Unlock()
+
+	for _, a := range jobs {
+		close(a.done)
+		d.lose(n, a)
+	}
+}
+
+func (d *Dispatcher) lose(n *node, a *assignment) {
+	defer d.inflight.Add(-1)
+	metrics.RemoteJobsReassigned.Inc()
+	n.logger.Warn().Str("job_id", a.job.ID).Msg("reassigning job lost by node")
+	err := &executor.InfrastructureError{Err: fmt.Errorf("worker node %s lost the job", n.name)}
+	d.finisher.Finish(a.job, nil, time.Since(a.started), err)
+}
+
+// take removes a job from the node, returning nil if it is not there.
+func (n *node) take(id string) *assignment {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, ok := n.jobs[id]
+	if !ok {
+		return nil
+	}
+	delete(n.jobs, id)
+	close(a.done)
+	return a
+}
+
+func (n *node) send(msg *workerpb.ServerMessage) error {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+	if err := n.stream.Send(msg); err != nil {
+		return fmt.Errorf("failed to send to node: %w", err)
+	}
+	return nil
+}
+
+func newNodeID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return "node-" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,204 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/workerpb"
+	"github.com/rs/zerolog"
+)
+
+// NodeOptions describes what a node offers the API server.
+type NodeOptions struct {
+	Name      string
+	Languages []string
+	Capacity  int
+	Version   string
+}
+
+// Node runs jobs from an API server's Dispatcher in the local sandbox.
+type Node struct {
+	client   workerpb.WorkerServiceClient
+	executor *executor.Executor
+	opts     NodeOptions
+	logger   *zerolog.Logger
+}
+
+func NewNode(client workerpb.WorkerServiceClient, exec *executor.Executor, opts NodeOptions, logger *zerolog.Logger) *Node {
+	return &Node{
+		client:   client,
+		executor: exec,
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+// session is one connection to the server.
+type session struct {
+	node   *Node
+	stream workerpb.WorkerService_ConnectClient
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// Run registers with the server and runs the jobs it sends until ctx is
+// cancelled or the connection fails. Jobs still running when the
+// connection fails are cancelled; the server reassigns them.
+func (n *Node) Run(parent context.Context) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	stream, err := n.client.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	s := &session{node: n, stream: stream, running: make(map[string]context.CancelFunc)}
+	defer s.wg.Wait()
+
+	err = s.send(&workerpb.NodeMessage{Message: &workerpb.NodeMessage_Register{
+		Register: &workerpb.Register{
+			Name:      n.opts.Name,
+			Languages: n.opts.Languages,
+			Capacity:  int32(n.opts.Capacity),
+			Version:   n.opts.Version,
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to register: %w", err)
+	}
+	reg := first.GetRegistered()
+	if reg == nil {
+		return errors.New("server did not acknowledge registration")
+	}
+	n.logger.Info().Str("node_id", reg.GetNodeId()).Msg("registered with server")
+
+	go s.heartbeat(ctx, time.Duration(reg.GetHeartbeatIntervalMs())*time.Millisecond)
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			cancel()
+			if parent.Err() != nil {
+				return parent.Err()
+			}
+			return fmt.Errorf("connection lost: %w", err)
+		}
+
+		switch {
+		case msg.GetJob() != nil:
+			s.start(ctx, msg.GetJob())
+		case msg.GetCancel() != nil:
+			s.cancel(msg.GetCancel().GetId())
+		}
+	}
+}
+
+// start runs a job in the background.
+func (s *session) start(ctx context.Context, job *workerpb.Job) {
+	opts := optionsFromProto(job.GetOptions())
+	opts.JobID = job.GetId()
+	jobCtx, cancel := context.WithTimeout(ctx, opts.Timeout())
+
+	s.mu.Lock()
+	s.running[job.GetId()] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.finish(job.GetId())
+
+		log := s.node.logger.With().Str("job_id", job.GetId()).Logger()
+		log.Info().Msg("processing job")
+
+		startTime := time.Now()
+		result, err := s.node.executor.Execute(jobCtx, opts)
+		if ctx.Err() != nil {
+			// The session is over and the server has reassigned the job
+			return
+		}
+		res := &workerpb.JobResult{Id: job.GetId(), DurationMs: time.Since(startTime).Milliseconds()}
+		if err != nil {
+			log.Error().Err(err).Msg("job failed")
+			res.Outcome = &workerpb.JobResult_InfrastructureError{InfrastructureError: err.Error()}
+		} else {
+			res.Outcome = &workerpb.JobResult_Result{Result: resultToProto(result)}
+		}
+
+		if err := s.send(&workerpb.NodeMessage{Message: &workerpb.NodeMessage_Result{Result: res}}); err != nil {
+			log.Error().Err(err).Msg("failed to report result")
+		}
+	}()
+}
+
+func (s *session) finish(id string) {
+	s.mu.Lock()
+	cancel, ok := s.running[id]
+	delete(s.running, id)
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *session) cancel(id string) {
+	s.mu.Lock()
+	cancel, ok := s.running[id]
+	s.mu.Unlock()
+	if ok {
+		s.node.logger.Info().Str("job_id", id).Msg("cancelling job")
+		cancel()
+	}
+}
+
+// heartbeat reports the running jobs every interval.
+func (s *session) heartbeat(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		s.mu.Lock()
+		ids := make([]string, 0, len(s.running))
+		for id := range s.running {
+			ids = append(ids, id)
+		}
+		s.mu.Unlock()
+
+		err := s.send(&workerpb.NodeMessage{Message: &workerpb.NodeMessage_Heartbeat{
+			Heartbeat: &workerpb.Heartbeat{RunningJobIds: ids},
+		}})
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *session) send(msg *workerpb.NodeMessage) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if err := s.stream.Send(msg); err != nil {
+		return fmt.Errorf("failed to send to server: %w", err)
+	}
+	return nil
+}
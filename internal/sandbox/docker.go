@@ -3,20 +3,35 @@ package sandbox
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"sync"
 	"time"
 
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/itstheanurag/executioner/internal/compilecache"
 	"github.com/rs/zerolog"
 )
 
 type DockerSandbox struct {
-	cli    *client.Client
-	logger *zerolog.Logger
+	cli        *client.Client
+	logger     *zerolog.Logger
+	instanceID string
+
+	cache    *compilecache.Cache
+	imageIDs sync.Map // image reference -> image ID
+
+	// containers holds the IDs of containers created by Run and not yet
+	// removed, so Close can clean up after runs it interrupts
+	mu         sync.Mutex
+	containers map[string]struct{}
+	closed     bool
 }
 
 func NewDockerSandbox(logger *zerolog.Logger) (*DockerSandbox, error) {
@@ -24,14 +39,72 @@ func NewDockerSandbox(logger *zerolog.Logger) (*DockerSandbox, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &DockerSandbox{cli: cli, logger: logger}, nil
+	return &DockerSandbox{
+		cli:        cli,
+		logger:     logger,
+		instanceID: newInstanceID(),
+		containers: make(map[string]struct{}),
+	}, nil
+}
+
+// InstanceID identifies this process in the labels of its containers.
+func (s *DockerSandbox) InstanceID() string {
+	return s.instanceID
 }
 
+// UseCompileCache enables reuse of compiled artifacts across runs.
+func (s *DockerSandbox) UseCompileCache(cache *compilecache.Cache) {
+	s.cache = cache
+}
+
+const (
+	defaultPidsLimit          = 64
+	defaultWorkspaceSizeMb    = 64
+	defaultCompileTimeLimitMs = 10000
+)
+
 func (s *DockerSandbox) Run(ctx context.Context, cfg RunConfig) (*Result, error) {
 	// Security: Limit PID count to prevent fork bombs
-	pidsLimit := int64(64)
+	pidsLimit := int64(defaultPidsLimit)
+	if cfg.PidsLimit > 0 {
+		pidsLimit = cfg.PidsLimit
+	}
+
+	workspaceSizeMb := defaultWorkspaceSizeMb
+	if cfg.WorkspaceSizeMb > 0 {
+		workspaceSizeMb = cfg.WorkspaceSizeMb
+	}
+
+	// A cached artifact replaces the compile phase entirely
+	var cacheKey string
+	var artifacts []byte
+	if s.cache != nil && len(cfg.CompileCmd) > 0 && len(cfg.Artifacts) > 0 {
+		key, err := s.compileCacheKey(ctx, cfg)
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("compile cache disabled for this run")
+		} else {
+			cacheKey = key
+			artifacts, _ = s.cache.Get(key)
+		}
+	}
+	compile := len(cfg.CompileCmd) > 0 && artifacts == nil
+
+	if cfg.CompileOnly && !compile {
+		// Nothing left to do: the artifacts already exist
+		return &Result{Phase: PhaseCompile}, nil
+	}
 
-	// 1. Create container with security hardening
+	// Compilers may need more memory than the program they produce, so the
+	// container starts with the larger of the two limits and is shrunk
+	// before the run phase.
+	memoryLimit := int64(cfg.MemoryLimitKb * 1024)
+	compileMemoryLimit := memoryLimit
+	if compile && cfg.CompileMemoryLimitKb*1024 > cfg.MemoryLimitKb*1024 {
+		compileMemoryLimit = int64(cfg.CompileMemoryLimitKb * 1024)
+	}
+
+	// 1. Create container with security hardening. The labels let the
+	// reaper find it if this process dies before removing it.
 	resp, err := s.cli.ContainerCreate(ctx, &container.Config{
 		Image:           cfg.Image,
 		Cmd:             []string{"sleep", "infinity"}, // Keep it alive while we compile
@@ -41,12 +114,18 @@ func (s *DockerSandbox) Run(ctx context.Context, cfg RunConfig) (*Result, error)
 		NetworkDisabled: true,
 		WorkingDir:      "/home/sandbox",
 		User:            "nobody",
+		Env:             cfg.Env,
+		Labels: map[string]string{
+			LabelInstance: s.instanceID,
+			LabelJob:      cfg.JobID,
+			LabelExpires:  strconv.FormatInt(time.Now().Add(maxLifetime(cfg)).Unix(), 10),
+		},
 	}, &container.HostConfig{
 		Resources: container.Resources{
-			Memory:     int64(cfg.MemoryLimitKb * 1024),
-			MemorySwap: int64(cfg.MemoryLimitKb * 1024), // No swap allowed
-			CPUQuota:   100000,                          // 1 CPU
-			PidsLimit:  &pidsLimit,                      // Prevent fork bombs
+			Memory:     compileMemoryLimit,
+			MemorySwap: compileMemoryLimit, // No swap allowed
+			CPUQuota:   100000,             // 1 CPU
+			PidsLimit:  &pidsLimit,         // Prevent fork bombs
 		},
 		NetworkMode: "none",
 		// Note: ReadonlyRootfs disabled because CopyToContainer doesn't work with it
@@ -54,97 +133,140 @@ func (s *DockerSandbox) Run(ctx context.Context, cfg RunConfig) (*Result, error)
 		SecurityOpt: []string{"no-new-privileges"},
 		CapDrop:     []string{"ALL"},
 		Tmpfs: map[string]string{
-			"/home/sandbox": "rw,exec,nosuid,size=64m,mode=1777",
+			"/home/sandbox": fmt.Sprintf("rw,exec,nosuid,size=%dm,mode=1777", workspaceSizeMb),
 			"/tmp":          "rw,noexec,nosuid,size=16m,mode=1777",
 		},
 	}, nil, nil, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create container: %w", err)
+		return nil, &DaemonError{Err: fmt.Errorf("failed to create container: %w", err)}
+	}
+	defer s.remove(resp.ID)
+	if !s.track(resp.ID) {
+		return nil, ErrClosed
 	}
-	defer s.cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
 
 	// 2. Start container
 	if err := s.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		return nil, fmt.Errorf("failed to start container: %w", err)
+		return nil, &DaemonError{Err: fmt.Errorf("failed to start container: %w", err)}
 	}
 
 	// 3. Write source code using exec (CopyToContainer doesn't work with tmpfs mounts)
 	writeCmd := []string{"sh", "-c", fmt.Sprintf("cat > /home/sandbox/%s", cfg.SourceFile)}
-	execResp, err := s.cli.ContainerExecCreate(ctx, resp.ID, container.ExecOptions{
-		Cmd:         writeCmd,
-		AttachStdin: true,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create write exec: %w", err)
-	}
-
-	attachResp, err := s.cli.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to attach write exec: %w", err)
-	}
-
-	_, err = attachResp.Conn.Write([]byte(cfg.SourceCode))
-	if err != nil {
-		attachResp.Close()
+	if err := s.execWithInput(ctx, resp.ID, writeCmd, []byte(cfg.SourceCode)); err != nil {
 		return nil, fmt.Errorf("failed to write source code: %w", err)
 	}
-	attachResp.CloseWrite()
-	attachResp.Close()
 
-	// Wait for write to complete
-	for {
-		inspect, err := s.cli.ContainerExecInspect(ctx, execResp.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to inspect write exec: %w", err)
-		}
-		if !inspect.Running {
-			break
+	s.logger.Debug().Str("container", resp.ID).Msg("source code written via exec")
+
+	// 4. Restore cached artifacts
+	if artifacts != nil {
+		if err := s.execWithInput(ctx, resp.ID, []string{"tar", "-xf", "-", "-C", "/home/sandbox"}, artifacts); err != nil {
+			return nil, fmt.Errorf("failed to restore cached artifacts: %w", err)
 		}
+		s.logger.Debug().Str("container", resp.ID).Str("key", cacheKey).Msg("compile skipped, artifacts restored from cache")
 	}
 
-	s.logger.Debug().Str("container", resp.ID).Msg("source code written via exec")
-
 	// 5. Compile if needed
-	if len(cfg.CompileCmd) > 0 {
-		execResp, err := s.cli.ContainerExecCreate(ctx, resp.ID, container.ExecOptions{
+	if compile {
+		compileTimeLimitMs := cfg.CompileTimeLimitMs
+		if compileTimeLimitMs <= 0 {
+			compileTimeLimitMs = defaultCompileTimeLimitMs
+		}
+		compileCtx, cancel := context.WithTimeout(ctx, time.Duration(compileTimeLimitMs)*time.Millisecond)
+		defer cancel()
+
+		execResp, err := s.cli.ContainerExecCreate(compileCtx, resp.ID, container.ExecOptions{
 			Cmd:          cfg.CompileCmd,
 			WorkingDir:   "/home/sandbox",
 			AttachStdout: true,
 			AttachStderr: true,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create compile exec: %w", err)
+			return nil, &DaemonError{Err: fmt.Errorf("failed to create compile exec: %w", err)}
 		}
 
-		startResp, err := s.cli.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{})
+		startResp, err := s.cli.ContainerExecAttach(compileCtx, execResp.ID, container.ExecStartOptions{})
 		if err != nil {
-			return nil, fmt.Errorf("failed to start compile exec: %w", err)
+			return nil, &DaemonError{Err: fmt.Errorf("failed to start compile exec: %w", err)}
 		}
 		defer startResp.Close()
 
+		compileStart := time.Now()
 		var stdout, stderr bytes.Buffer
-		_, err = stdcopy.StdCopy(&stdout, &stderr, startResp.Reader)
-		if err != nil {
-			return nil, fmt.Errorf("failed to capture compile logs: %w", err)
+		done := make(chan error, 1)
+		go func() {
+			_, err := stdcopy.StdCopy(&stdout, &stderr, startResp.Reader)
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				return nil, fmt.Errorf("failed to capture compile logs: %w", err)
+			}
+		case <-compileCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// Closing the connection ends the copy, so the output
+			// captured so far can be read
+			startResp.Close()
+			<-done
+			return &Result{
+				Stdout:   stdout.String(),
+				Stderr:   stderr.String(),
+				TimeMs:   time.Since(compileStart).Milliseconds(),
+				Phase:    PhaseCompile,
+				TimedOut: true,
+			}, nil
 		}
 
 		inspect, err := s.cli.ContainerExecInspect(ctx, execResp.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to inspect compile exec: %w", err)
+			return nil, unreachable(fmt.Errorf("failed to inspect compile exec: %w", err))
 		}
 
+		compileResult := &Result{
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			ExitCode: inspect.ExitCode,
+			TimeMs:   time.Since(compileStart).Milliseconds(),
+			Phase:    PhaseCompile,
+		}
 		if inspect.ExitCode != 0 {
-			return &Result{
-				Stdout:   stdout.String(),
-				Stderr:   stderr.String(),
-				ExitCode: inspect.ExitCode,
-			}, nil // Return with compilation error
+			return compileResult, nil // Return with compilation error
+		}
+
+		if cacheKey != "" {
+			s.saveArtifacts(ctx, resp.ID, cacheKey, cfg.Artifacts)
+		}
+
+		if cfg.CompileOnly {
+			return compileResult, nil
+		}
+
+		if compileMemoryLimit != memoryLimit {
+			_, err := s.cli.ContainerUpdate(ctx, resp.ID, container.UpdateConfig{
+				Resources: container.Resources{
+					Memory:     memoryLimit,
+					MemorySwap: memoryLimit,
+				},
+			})
+			if err != nil {
+				return nil, &DaemonError{Err: fmt.Errorf("failed to apply run memory limit: %w", err)}
+			}
 		}
 	}
 
 	// 6. Execute
+	if cfg.TimeLimitMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.TimeLimitMs)*time.Millisecond)
+		defer cancel()
+	}
+
 	startTime := time.Now()
-	execResp, err = s.cli.ContainerExecCreate(ctx, resp.ID, container.ExecOptions{
+	execResp, err := s.cli.ContainerExecCreate(ctx, resp.ID, container.ExecOptions{
 		Cmd:          cfg.RunCmd,
 		WorkingDir:   "/home/sandbox",
 		AttachStdout: true,
@@ -152,12 +274,12 @@ func (s *DockerSandbox) Run(ctx context.Context, cfg RunConfig) (*Result, error)
 		AttachStdin:  true,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create run exec: %w", err)
+		return nil, &DaemonError{Err: fmt.Errorf("failed to create run exec: %w", err)}
 	}
 
 	startResp, err := s.cli.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to start run exec: %w", err)
+		return nil, &DaemonError{Err: fmt.Errorf("failed to start run exec: %w", err)}
 	}
 	defer startResp.Close()
 
@@ -167,9 +289,16 @@ func (s *DockerSandbox) Run(ctx context.Context, cfg RunConfig) (*Result, error)
 	}
 
 	var stdout, stderr bytes.Buffer
+	var stdoutW, stderrW io.Writer = &stdout, &stderr
+	if cfg.Stdout != nil {
+		stdoutW = io.MultiWriter(&stdout, cfg.Stdout)
+	}
+	if cfg.Stderr != nil {
+		stderrW = io.MultiWriter(&stderr, cfg.Stderr)
+	}
 	done := make(chan error, 1)
 	go func() {
-		_, err := stdcopy.StdCopy(&stdout, &stderr, startResp.Reader)
+		_, err := stdcopy.StdCopy(stdoutW, stderrW, startResp.Reader)
 		done <- err
 	}()
 
@@ -185,7 +314,7 @@ func (s *DockerSandbox) Run(ctx context.Context, cfg RunConfig) (*Result, error)
 	duration := time.Since(startTime)
 	inspect, err := s.cli.ContainerExecInspect(ctx, execResp.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to inspect run exec: %w", err)
+		return nil, unreachable(fmt.Errorf("failed to inspect run exec: %w", err))
 	}
 
 	return &Result{
@@ -194,9 +323,48 @@ func (s *DockerSandbox) Run(ctx context.Context, cfg RunConfig) (*Result, error)
 		ExitCode: inspect.ExitCode,
 		TimeMs:   duration.Milliseconds(),
 		MemoryKb: 0, // Finding actual memory usage is complex in Docker Exec, leaving at 0 for MVP
+		Phase:    PhaseRun,
 	}, nil
 }
 
+// track registers a container for Close, reporting false if the sandbox
+// has been closed.
+func (s *DockerSandbox) track(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.containers[id] = struct{}{}
+	return true
+}
+
+func (s *DockerSandbox) remove(id string) {
+	s.mu.Lock()
+	delete(s.containers, id)
+	s.mu.Unlock()
+	_ = s.cli.ContainerRemove(context.Background(), id, container.RemoveOptions{Force: true})
+}
+
+func (s *DockerSandbox) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	ids := make([]string, 0, len(s.containers))
+	for id := range s.containers {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, id := range ids {
+		s.logger.Warn().Str("container", id).Msg("removing container left running at shutdown")
+		if err := s.cli.ContainerRemove(ctx, id, container.RemoveOptions{Force: true}); err != nil && !cerrdefs.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to remove container %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (s *DockerSandbox) EnsureImage(ctx context.Context, img string) error {
 	_, _, err := s.cli.ImageInspectWithRaw(ctx, img)
 	if err == nil {
@@ -213,6 +381,7 @@ func (s *DockerSandbox) EnsureImage(ctx context.Context, img string) error {
 	// Important: must consume the reader to finish the pull
 	_, _ = io.Copy(io.Discard, reader)
 
+	s.imageIDs.Delete(img)
 	s.logger.Info().Str("image", img).Msg("successfully pulled docker image")
 	return nil
 }
@@ -0,0 +1,141 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/itstheanurag/executioner/internal/compilecache"
+)
+
+// execWithInput runs cmd in the container, streams input to its stdin and
+// waits for it to exit successfully.
+func (s *DockerSandbox) execWithInput(ctx context.Context, containerID string, cmd []string, input []byte) error {
+	execResp, err := s.cli.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:         cmd,
+		WorkingDir:  "/home/sandbox",
+		AttachStdin: true,
+	})
+	if err != nil {
+		return &DaemonError{Err: fmt.Errorf("failed to create exec: %w", err)}
+	}
+
+	attachResp, err := s.cli.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{})
+	if err != nil {
+		return &DaemonError{Err: fmt.Errorf("failed to attach exec: %w", err)}
+	}
+
+	_, err = attachResp.Conn.Write(input)
+	if err != nil {
+		attachResp.Close()
+		return fmt.Errorf("failed to write input: %w", err)
+	}
+	attachResp.CloseWrite()
+	attachResp.Close()
+
+	// Wait for the command to complete
+	for {
+		inspect, err := s.cli.ContainerExecInspect(ctx, execResp.ID)
+		if err != nil {
+			return unreachable(fmt.Errorf("failed to inspect exec: %w", err))
+		}
+		if !inspect.Running {
+			if inspect.ExitCode != 0 {
+				return fmt.Errorf("%s exited with code %d", cmd[0], inspect.ExitCode)
+			}
+			return nil
+		}
+	}
+}
+
+// unreachable wraps err in a DaemonError if the daemon could not be
+// reached. Other failures of a started container are left to the program.
+func unreachable(err error) error {
+	if client.IsErrConnectionFailed(err) {
+		return &DaemonError{Err: err}
+	}
+	return err
+}
+
+// execOutput runs cmd in the container and returns its stdout.
+func (s *DockerSandbox) execOutput(ctx context.Context, containerID string, cmd []string) ([]byte, error) {
+	execResp, err := s.cli.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		WorkingDir:   "/home/sandbox",
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	attachResp, err := s.cli.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach exec: %w", err)
+	}
+	defer attachResp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attachResp.Reader); err != nil {
+		return nil, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	inspect, err := s.cli.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return nil, fmt.Errorf("%s exited with code %d: %s", cmd[0], inspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// compileCacheKey identifies the artifacts cfg would compile to. The image
+// ID rather than its tag is hashed, so re-pulled toolchains miss.
+func (s *DockerSandbox) compileCacheKey(ctx context.Context, cfg RunConfig) (string, error) {
+	imageID, err := s.imageID(ctx, cfg.Image)
+	if err != nil {
+		return "", err
+	}
+	return compilecache.Key(
+		imageID,
+		cfg.SourceFile,
+		cfg.SourceCode,
+		strings.Join(cfg.CompileCmd, "\x00"),
+		strings.Join(cfg.Env, "\x00"),
+		strings.Join(cfg.Artifacts, "\x00"),
+	), nil
+}
+
+func (s *DockerSandbox) imageID(ctx context.Context, img string) (string, error) {
+	if id, ok := s.imageIDs.Load(img); ok {
+		return id.(string), nil
+	}
+	inspect, _, err := s.cli.ImageInspectWithRaw(ctx, img)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", img, err)
+	}
+	s.imageIDs.Store(img, inspect.ID)
+	return inspect.ID, nil
+}
+
+// saveArtifacts archives the compiled artifacts and stores them in the
+// compile cache. Failures only cost a future cache miss, so they are
+// logged rather than returned.
+func (s *DockerSandbox) saveArtifacts(ctx context.Context, containerID, key string, artifacts []string) {
+	// Artifacts may be globs (e.g. *.class), so let the shell expand them
+	cmd := []string{"sh", "-c", "tar -cf - -- " + strings.Join(artifacts, " ")}
+	data, err := s.execOutput(ctx, containerID, cmd)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to archive compiled artifacts")
+		return
+	}
+	if err := s.cache.Put(key, data); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to store compiled artifacts")
+	}
+}
@@ -0,0 +1,126 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/itstheanurag/executioner/internal/metrics"
+)
+
+// Labels set on every sandbox container.
+const (
+	LabelInstance = "executioner.instance"
+	LabelJob      = "executioner.job"
+	// LabelExpires is the Unix time after which the container has outlived
+	// its run and may be removed by any instance's reaper.
+	LabelExpires = "executioner.expires"
+)
+
+const (
+	// containerGrace covers container setup and teardown on top of a
+	// run's time limits.
+	containerGrace = time.Minute
+	// fallbackLifetime applies to labeled containers without a readable
+	// expiry.
+	fallbackLifetime = time.Hour
+)
+
+// maxLifetime is how long a container for cfg can legitimately exist.
+func maxLifetime(cfg RunConfig) time.Duration {
+	compile := 0
+	if len(cfg.CompileCmd) > 0 {
+		compile = cfg.CompileTimeLimitMs
+		if compile <= 0 {
+			compile = defaultCompileTimeLimitMs
+		}
+	}
+	return time.Duration(compile+cfg.TimeLimitMs)*time.Millisecond + containerGrace
+}
+
+// Reap removes sandbox containers that have outlived their maximum
+// lifetime, whichever instance created them: they were left behind by a
+// process that crashed mid-run. Containers this sandbox is still running
+// are never removed. It returns the number of containers removed.
+func (s *DockerSandbox) Reap(ctx context.Context) (int, error) {
+	list, err := s.cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", LabelInstance)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sandbox containers: %w", err)
+	}
+	metrics.SandboxContainers.Set(float64(len(list)))
+
+	now := time.Now()
+	reaped := 0
+	var errs []error
+	for _, c := range list {
+		if s.tracked(c.ID) || now.Before(expiry(c)) {
+			continue
+		}
+		err := s.cli.ContainerRemove(ctx, c.ID, container.RemoveOptions{Force: true})
+		if err != nil && !cerrdefs.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to remove container %s: %w", c.ID, err))
+			continue
+		}
+		reaped++
+		s.logger.Warn().
+			Str("container", c.ID).
+			Str("instance", c.Labels[LabelInstance]).
+			Str("job_id", c.Labels[LabelJob]).
+			Msg("reaped orphaned sandbox container")
+	}
+	metrics.SandboxContainersReaped.Add(float64(reaped))
+	return reaped, errors.Join(errs...)
+}
+
+// StartReaper runs Reap every interval.
+func (s *DockerSandbox) StartReaper(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_, err := s.Reap(ctx)
+			cancel()
+			if err != nil {
+				s.logger.Error().Err(err).Msg("failed to reap orphaned sandbox containers")
+			}
+		}
+	}()
+}
+
+func (s *DockerSandbox) tracked(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.containers[id]
+	return ok
+}
+
+func expiry(c container.Summary) time.Time {
+	if unix, err := strconv.ParseInt(c.Labels[LabelExpires], 10, 64); err == nil {
+		return time.Unix(unix, 0)
+	}
+	return time.Unix(c.Created, 0).Add(fallbackLifetime)
+}
+
+// newInstanceID names this process: the host name, for operators, and a
+// random suffix to tell restarts apart.
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "executioner"
+	}
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return host + "-" + hex.EncodeToString(b[:])
+}
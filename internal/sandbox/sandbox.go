@@ -2,6 +2,14 @@ package sandbox
 
 import (
 	"context"
+	"errors"
+	"io"
+)
+
+// Phases of a run, reported in Result.Phase.
+const (
+	PhaseCompile = "compile"
+	PhaseRun     = "run"
 )
 
 type Result struct {
@@ -10,11 +18,40 @@ type Result struct {
 	ExitCode int
 	TimeMs   int64
 	MemoryKb int64
+
+	// Phase is the last phase that ran: a non-zero ExitCode with
+	// PhaseCompile is a compilation failure.
+	Phase string
+	// TimedOut reports a compile phase stopped at its time limit. Stdout
+	// and Stderr hold what the compiler wrote until then.
+	TimedOut bool
 }
 
 type Sandbox interface {
 	Run(ctx context.Context, config RunConfig) (*Result, error)
 	EnsureImage(ctx context.Context, image string) error
+	// Close force-removes any containers still running and makes later
+	// runs fail with ErrClosed.
+	Close(ctx context.Context) error
+}
+
+// ErrClosed is returned by Run after Close.
+var ErrClosed = errors.New("sandbox is closed")
+
+// DaemonError is returned by Run when the container runtime fails: the
+// daemon cannot be reached, or will not create, start or exec into a
+// container. Run's other errors come from a container the program broke,
+// e.g. by exhausting its memory, and say nothing about the daemon.
+type DaemonError struct {
+	Err error
+}
+
+func (e *DaemonError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DaemonError) Unwrap() error {
+	return e.Err
 }
 
 type RunConfig struct {
@@ -26,4 +63,25 @@ type RunConfig struct {
 	Stdin         string
 	TimeLimitMs   int
 	MemoryLimitKb int
+
+	Env                  []string
+	PidsLimit            int64
+	WorkspaceSizeMb      int
+	CompileTimeLimitMs   int
+	CompileMemoryLimitKb int
+
+	// CompileOnly stops after CompileCmd, without running the program.
+	CompileOnly bool
+
+	// Artifacts lists the files (shell globs) the compile phase produces.
+	// Only runs that set it are eligible for the compile cache.
+	Artifacts []string
+
+	// JobID labels the container, so leftovers can be traced to the job.
+	JobID string
+
+	// Stdout and Stderr, if set, also receive the run phase's output as
+	// the program writes it.
+	Stdout io.Writer
+	Stderr io.Writer
 }
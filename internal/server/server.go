@@ -3,20 +3,54 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/itstheanurag/executioner/internal/api"
+	"github.com/itstheanurag/executioner/internal/clientip"
+	"github.com/itstheanurag/executioner/internal/compilecache"
 	config "github.com/itstheanurag/executioner/internal/config"
+	"github.com/itstheanurag/executioner/internal/controls"
 	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/itstheanurag/executioner/internal/deadletter"
 	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/idempotency"
+	"github.com/itstheanurag/executioner/internal/judge0"
 	"github.com/itstheanurag/executioner/internal/languages"
 	"github.com/itstheanurag/executioner/internal/limiter"
+	"github.com/itstheanurag/executioner/internal/output"
 	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/remote"
 	"github.com/itstheanurag/executioner/internal/sandbox"
+	"github.com/itstheanurag/executioner/internal/submission"
+	"github.com/itstheanurag/executioner/internal/tenant"
 	"github.com/itstheanurag/executioner/internal/worker"
+	"github.com/itstheanurag/executioner/internal/workerpb"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	defaultWorkerCount   = 5
+	defaultQueueCapacity = 100
+	defaultRetryMax      = 2
+
+	// DefaultShutdownTimeout is how long running jobs get to finish when
+	// the server stops.
+	DefaultShutdownTimeout = 30 * time.Second
+	// cleanupTimeout bounds the work Stop does after that deadline
+	cleanupTimeout = 10 * time.Second
+
+	defaultReapInterval = time.Minute
+
+	defaultSubmissionRetention = 7 * 24 * time.Hour
+
+	// controlsSyncInterval is how long intake pauses and disabled
+	// languages set through another instance take to apply here
+	controlsSyncInterval = 5 * time.Second
 )
 
 type Server struct {
@@ -27,10 +61,20 @@ type Server struct {
 	registry    *languages.Registry
 	sandbox     sandbox.Sandbox
 	executor    *executor.Executor
-	queue       *queue.Manager
-	workers     []*worker.Worker
+	queue       queue.Queue
+	pool        *worker.Pool
+	autoscaler  *worker.Autoscaler
+	nodeServer  *grpc.Server
+	dispatcher  *remote.Dispatcher
+	grpcServer  *grpc.Server
+	sender      *submission.Sender
 	rateLimiter *limiter.RateLimiter
 	cancelFunc  context.CancelFunc
+
+	// The webhook sender outlives the workers during shutdown, so that
+	// the callbacks of jobs finishing then are sent
+	stopSender context.CancelFunc
+	senderDone chan struct{}
 }
 
 func New(
@@ -43,21 +87,203 @@ func New(
 		return nil, fmt.Errorf("failed to create database: %w", err)
 	}
 
+	if err := database.Migrate(context.Background(), logger, conf); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
 	// Initialize components
 	registry := languages.NewRegistry()
 	sb, err := sandbox.NewDockerSandbox(logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sandbox: %w", err)
 	}
+	logger.Info().Str("instance", sb.InstanceID()).Msg("labeling sandbox containers")
+
+	// Remove containers left behind by earlier processes that crashed
+	// mid-run, then keep looking for them
+	if n, err := sb.Reap(context.Background()); err != nil {
+		logger.Warn().Err(err).Msg("failed to reap orphaned sandbox containers")
+	} else if n > 0 {
+		logger.Info().Int("containers", n).Msg("reaped orphaned sandbox containers")
+	}
+	reapInterval := time.Duration(conf.Sandbox.ReapInterval) * time.Second
+	if reapInterval == 0 {
+		reapInterval = defaultReapInterval
+	}
+	sb.StartReaper(reapInterval)
+
+	if conf.Cache.Dir != "" {
+		maxSizeMb := conf.Cache.MaxSizeMb
+		if maxSizeMb == 0 {
+			maxSizeMb = compilecache.DefaultMaxSizeMb
+		}
+		cache, err := compilecache.New(conf.Cache.Dir, int64(maxSizeMb)*1024*1024, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create compile cache: %w", err)
+		}
+		sb.UseCompileCache(cache)
+	}
 
 	exec := executor.NewExecutor(registry, sb)
-	q := queue.NewManager(100)
+
+	var q queue.Queue
+	switch conf.Queue.Backend {
+	case "postgres":
+		pq := queue.NewPostgresQueue(db, queue.PostgresQueueOptions{
+			LeaseGrace:   time.Duration(conf.Queue.LeaseGrace) * time.Second,
+			MaxAttempts:  conf.Queue.MaxAttempts,
+			PollInterval: time.Duration(conf.Queue.PollIntervalMs) * time.Millisecond,
+			MaxDepth:     conf.Queue.MaxDepth,
+		}, logger)
+		pq.Start()
+		q = pq
+		logger.Info().Msg("using durable postgres job queue")
+	default:
+		capacity := conf.Queue.Capacity
+		if capacity == 0 {
+			capacity = defaultQueueCapacity
+		}
+		q = queue.NewManager(capacity, time.Duration(conf.Queue.SubmitWaitMs)*time.Millisecond)
+	}
+
+	// Apply the operator switches before serving, then follow changes
+	// made through other instances
+	switches := controls.New(db, q, registry, logger)
+	if err := switches.Sync(context.Background()); err != nil {
+		return nil, err
+	}
+	switches.Start(controlsSyncInterval)
+
+	proxies, err := clientip.NewResolver(conf.Server.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
 	// Rate limiter: 100 req/sec global, 10 req/sec per IP, 50 concurrent executions
 	rl := limiter.NewRateLimiter(100, 10, 20, 50)
 	rl.StartCleanup(5 * time.Minute)
 
-	handler := api.NewHandler(q)
+	// Compile-only limiter: 200 req/sec global, 20 req/sec per IP, 50 concurrent checks
+	compileRL := limiter.NewRateLimiter(200, 20, 40, 50)
+	compileRL.StartCleanup(5 * time.Minute)
+
+	results := idempotency.New(db, idempotency.Options{
+		KeyTTL:    time.Duration(conf.Idempotency.KeyTTL) * time.Second,
+		DedupeTTL: time.Duration(conf.Idempotency.DedupeTTL) * time.Second,
+	}, logger)
+	results.StartCleanup(10 * time.Minute)
+
+	retry := queue.RetryPolicy{
+		MaxRetries: defaultRetryMax,
+		BaseDelay:  time.Duration(conf.Queue.RetryBaseDelayMs) * time.Millisecond,
+		MaxDelay:   time.Duration(conf.Queue.RetryMaxDelayMs) * time.Millisecond,
+	}
+	if conf.Queue.RetryMax != nil {
+		retry.MaxRetries = *conf.Queue.RetryMax
+	}
+	if retry.BaseDelay == 0 {
+		retry.BaseDelay = 500 * time.Millisecond
+	}
+	if retry.MaxDelay == 0 {
+		retry.MaxDelay = 10 * time.Second
+	}
+	deadLetters := deadletter.New(db)
+
+	submissions := submission.New(db, logger)
+	retention := time.Duration(conf.Webhooks.Retention) * time.Second
+	if retention == 0 {
+		retention = defaultSubmissionRetention
+	}
+	submissions.StartCleanup(10*time.Minute, retention)
+
+	callbackRetry := submission.DefaultRetry
+	if conf.Webhooks.MaxRetries != 0 {
+		callbackRetry.MaxRetries = conf.Webhooks.MaxRetries
+	}
+	if conf.Webhooks.BaseDelayMs != 0 {
+		callbackRetry.BaseDelay = time.Duration(conf.Webhooks.BaseDelayMs) * time.Millisecond
+	}
+	if conf.Webhooks.MaxDelayMs != 0 {
+		callbackRetry.MaxDelay = time.Duration(conf.Webhooks.MaxDelayMs) * time.Millisecond
+	}
+	sender := submission.NewSender(submissions, submission.SenderOptions{
+		Secret:               conf.Webhooks.Secret,
+		Retry:                callbackRetry,
+		Timeout:              time.Duration(conf.Webhooks.TimeoutMs) * time.Millisecond,
+		AllowPrivateNetworks: conf.Webhooks.AllowPrivateNetworks,
+	}, logger)
+
+	tenants := tenant.NewStore(db)
+	auth := api.NewAuthenticator(tenants, !conf.Auth.AllowAnonymous)
+	if conf.Auth.AllowAnonymous {
+		logger.Warn().Msg("clients without an API key are served without quotas")
+	}
+
+	// Create the worker pool; it is started, at its initial size, by Start
+	// The output of running jobs, for gRPC clients streaming it
+	outputs := output.NewHub()
+	finisher := worker.NewFinisher(q, retry, deadLetters, submissions, tenants, logger)
+	pool := worker.NewPool(func(id int) *worker.Worker {
+		return worker.NewWorker(id, exec, q, finisher, outputs, logger)
+	}, logger)
+
+	var autoscaler *worker.Autoscaler
+	if conf.Workers.Autoscale {
+		autoscaler = worker.NewAutoscaler(pool, q, worker.AutoscalerOptions{
+			Min:            conf.Workers.Min,
+			Max:            conf.Workers.Max,
+			Interval:       time.Duration(conf.Workers.ScaleIntervalMs) * time.Millisecond,
+			MaxCPULoad:     conf.Workers.MaxCPULoad,
+			MaxMemoryUsage: conf.Workers.MaxMemoryUsage,
+		}, logger)
+	}
+
+	// Listener for remote worker nodes, which share the local workers' queue
+	// and finisher
+	var (
+		nodeServer *grpc.Server
+		dispatcher *remote.Dispatcher
+	)
+	if conf.Remote.ListenAddr != "" {
+		opts := []grpc.ServerOption{grpc.StreamInterceptor(remote.AuthStreamInterceptor(conf.Remote.Token))}
+		if conf.Remote.TLSCert != "" {
+			creds, err := credentials.NewServerTLSFromFile(conf.Remote.TLSCert, conf.Remote.TLSKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load worker listener TLS certificate: %w", err)
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		nodeServer = grpc.NewServer(opts...)
+		dispatcher = remote.NewDispatcher(q, finisher, remote.DispatcherOptions{
+			HeartbeatInterval: time.Duration(conf.Remote.HeartbeatIntervalMs) * time.Millisecond,
+			HeartbeatTimeout:  time.Duration(conf.Remote.HeartbeatTimeoutMs) * time.Millisecond,
+		}, logger)
+		workerpb.RegisterWorkerServiceServer(nodeServer, dispatcher)
+	}
+
+	limits := api.DefaultLimits
+	if conf.Limits.MaxBodyKb != 0 {
+		limits.MaxBody = int64(conf.Limits.MaxBodyKb) * 1024
+	}
+	if conf.Limits.MaxBatchBodyKb != 0 {
+		limits.MaxBatchBody = int64(conf.Limits.MaxBatchBodyKb) * 1024
+	}
+	if conf.Limits.MaxSourceKb != 0 {
+		limits.MaxSource = conf.Limits.MaxSourceKb * 1024
+	}
+	if conf.Limits.MaxStdinKb != 0 {
+		limits.MaxStdin = conf.Limits.MaxStdinKb * 1024
+	}
+	if conf.Limits.MaxExpectedOutputKb != 0 {
+		limits.MaxExpectedOutput = conf.Limits.MaxExpectedOutputKb * 1024
+	}
+
+	handler := api.NewHandler(q, exec, results, submissions, tenants, retry, limits, conf.Webhooks.Secret != "")
+	admin := api.NewAdminHandler(handler, registry, switches, pool, dispatcher, rl, deadLetters, conf.Admin.Token)
+
+	judge0Requests := judge0.NewStore(db, logger)
+	judge0Requests.StartCleanup(10 * time.Minute)
+	judge0Handler := api.NewJudge0Handler(handler, registry, judge0Requests)
 
 	mux := http.NewServeMux()
 
@@ -70,23 +296,93 @@ func New(
 	// Prometheus metrics endpoint
 	mux.Handle("/metrics", promhttp.Handler())
 
-	// execution endpoint with rate limiting
-	mux.HandleFunc("/execute", rl.Middleware(handler.Execute))
+	// execution endpoint with rate limiting; endpoints that run or show
+	// jobs identify the client by its API key
+	mux.HandleFunc("/execute", rl.Middleware(auth.Middleware(handler.Execute)))
+
+	// compile/syntax check endpoint; editors call it as the user types, so
+	// it gets its own, more generous bucket
+	mux.HandleFunc("/compile", compileRL.Middleware(auth.Middleware(handler.Compile)))
+
+	mux.HandleFunc("/queue", handler.QueueStatus)
+	mux.HandleFunc("/usage", auth.Middleware(handler.Usage))
+
+	mux.HandleFunc("/submissions/{id}", auth.Middleware(handler.Submission))
+
+	// a batch is one request however many jobs it holds, so rejudging a
+	// contest is not throttled job by job
+	mux.HandleFunc("/submissions/batch", rl.Middleware(auth.Middleware(handler.SubmitBatch)))
+	mux.HandleFunc("/submissions/batch/{id}", auth.Middleware(handler.GetBatch))
+
+	// v1 API: the same handlers, with JSON errors and snake_case results
+	mux.HandleFunc("/v1/execute", api.V1(rl.MiddlewareWith(auth.Middleware(handler.Execute), api.TooManyRequests)))
+	mux.HandleFunc("/v1/compile", api.V1(compileRL.MiddlewareWith(auth.Middleware(handler.Compile), api.TooManyRequests)))
+	mux.HandleFunc("/v1/languages", api.V1(api.LanguagesHandler(registry)))
+	mux.HandleFunc("/v1/queue", api.V1(handler.QueueStatus))
+	mux.HandleFunc("/v1/usage", api.V1(auth.Middleware(handler.Usage)))
+	mux.HandleFunc("/v1/submissions/{id}", api.V1(auth.Middleware(handler.Submission)))
+	mux.HandleFunc("/v1/submissions/batch", api.V1(rl.MiddlewareWith(auth.Middleware(handler.SubmitBatch), api.TooManyRequests)))
+	mux.HandleFunc("/v1/submissions/batch/{id}", api.V1(auth.Middleware(handler.GetBatch)))
+	mux.HandleFunc("/openapi.json", api.OpenAPI)
+
+	// Judge0-compatible API, for clients written against Judge0
+	mux.HandleFunc("/judge0/submissions", rl.Middleware(auth.Middleware(judge0Handler.Submissions)))
+	mux.HandleFunc("/judge0/submissions/{token}", auth.Middleware(judge0Handler.Submission))
+	mux.HandleFunc("/judge0/languages", judge0Handler.Languages)
+	mux.HandleFunc("/judge0/languages/{id}", judge0Handler.Language)
+	mux.HandleFunc("/judge0/statuses", judge0Handler.Statuses)
+
+	// operator endpoints, disabled without an admin token
+	mux.HandleFunc("/admin/dead-letters", admin.Authorize(admin.DeadLetters))
+	mux.HandleFunc("/admin/dead-letters/{id}", admin.Authorize(admin.DeadLetter))
+	mux.HandleFunc("/admin/dead-letters/{id}/requeue", admin.Authorize(admin.RequeueDeadLetter))
+	mux.HandleFunc("/admin/tenants", admin.Authorize(admin.Tenants))
+	mux.HandleFunc("/admin/tenants/{id}", admin.Authorize(admin.Tenant))
+	mux.HandleFunc("/admin/tenants/{id}/keys", admin.Authorize(admin.TenantKeys))
+	mux.HandleFunc("/admin/tenants/{id}/keys/{key}", admin.Authorize(admin.TenantKey))
+	mux.HandleFunc("/admin/queue", admin.Authorize(admin.Queue))
+	mux.HandleFunc("/admin/queue/pause", admin.Authorize(admin.PauseQueue))
+	mux.HandleFunc("/admin/queue/resume", admin.Authorize(admin.ResumeQueue))
+	mux.HandleFunc("/admin/workers", admin.Authorize(admin.Workers))
+	mux.HandleFunc("/admin/jobs/{id}", admin.Authorize(admin.KillJob))
+	mux.HandleFunc("/admin/languages", admin.Authorize(admin.Languages))
+	mux.HandleFunc("/admin/languages/{id}/disable", admin.Authorize(admin.DisableLanguage))
+	mux.HandleFunc("/admin/languages/{id}/enable", admin.Authorize(admin.EnableLanguage))
+	mux.HandleFunc("/admin/usage", admin.Authorize(admin.Usage))
 
 	httpServer := &http.Server{
 		Addr:         ":" + conf.Server.Port,
-		Handler:      mux,
+		Handler:      proxies.Middleware(mux),
 		ReadTimeout:  time.Duration(conf.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(conf.Server.WriteTimeout) * time.Second,
 		IdleTimeout:  time.Duration(conf.Server.IdleTimeout) * time.Second,
 	}
 
-	// Create workers
-	numWorkers := 5
-	workers := make([]*worker.Worker, numWorkers)
-
-	for i := 0; i < numWorkers; i++ {
-		workers[i] = worker.NewWorker(i, exec, q, logger)
+	// gRPC API, sharing the HTTP handler's queue, API keys and the
+	// /execute rate limiter
+	var grpcServer *grpc.Server
+	if conf.GRPC.ListenAddr != "" {
+		opts := []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(
+				proxies.UnaryInterceptor(),
+				rl.UnaryInterceptor(api.ClientAddr, api.RateLimitedMethods...),
+				auth.UnaryInterceptor(),
+			),
+			grpc.ChainStreamInterceptor(
+				proxies.StreamInterceptor(),
+				auth.StreamInterceptor(),
+			),
+			grpc.MaxRecvMsgSize(int(limits.MaxBody)),
+		}
+		if conf.GRPC.TLSCert != "" {
+			creds, err := credentials.NewServerTLSFromFile(conf.GRPC.TLSCert, conf.GRPC.TLSKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load gRPC API TLS certificate: %w", err)
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		grpcServer = grpc.NewServer(opts...)
+		api.NewGRPCService(handler, registry, outputs).Register(grpcServer)
 	}
 
 	s := &Server{
@@ -98,7 +394,12 @@ func New(
 		sandbox:     sb,
 		executor:    exec,
 		queue:       q,
-		workers:     workers,
+		pool:        pool,
+		autoscaler:  autoscaler,
+		nodeServer:  nodeServer,
+		dispatcher:  dispatcher,
+		grpcServer:  grpcServer,
+		sender:      sender,
 		rateLimiter: rl,
 	}
 
@@ -119,8 +420,47 @@ func (s *Server) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancelFunc = cancel
 	
-	for _, w := range s.workers {
-		go w.Start(ctx)
+	if !s.conf.Workers.DisableLocal {
+		s.pool.Start(ctx, s.workerCount())
+		if s.autoscaler != nil {
+			go s.autoscaler.Run(ctx)
+		}
+	}
+
+	// The sender also fails submissions whose jobs were lost, so it runs
+	// even while callbacks are disabled
+	senderCtx, stopSender := context.WithCancel(context.Background())
+	s.stopSender = stopSender
+	s.senderDone = make(chan struct{})
+	go func() {
+		defer close(s.senderDone)
+		s.sender.Run(senderCtx)
+	}()
+
+	if s.nodeServer != nil {
+		lis, err := net.Listen("tcp", s.conf.Remote.ListenAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for worker nodes: %w", err)
+		}
+		s.logger.Info().Str("addr", s.conf.Remote.ListenAddr).Msg("accepting remote worker nodes")
+		go func() {
+			if err := s.nodeServer.Serve(lis); err != nil {
+				s.logger.Error().Err(err).Msg("worker node listener failed")
+			}
+		}()
+	}
+
+	if s.grpcServer != nil {
+		lis, err := net.Listen("tcp", s.conf.GRPC.ListenAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC API: %w", err)
+		}
+		s.logger.Info().Str("addr", s.conf.GRPC.ListenAddr).Msg("starting gRPC API server")
+		go func() {
+			if err := s.grpcServer.Serve(lis); err != nil {
+				s.logger.Error().Err(err).Msg("gRPC API server failed")
+			}
+		}()
 	}
 
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -130,6 +470,22 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// workerCount is the pool's fixed size, or its initial size when the
+// autoscaler manages it.
+func (s *Server) workerCount() int {
+	count := s.conf.Workers.Count
+	if count == 0 {
+		count = defaultWorkerCount
+	}
+	if s.conf.Workers.Autoscale {
+		count = max(count, s.conf.Workers.Min)
+		if s.conf.Workers.Max > 0 {
+			count = min(count, s.conf.Workers.Max)
+		}
+	}
+	return count
+}
+
 func (s *Server) ensureImages(ctx context.Context) error {
 	langs := s.registry.List()
 	uniqueImages := make(map[string]bool)
@@ -146,20 +502,106 @@ func (s *Server) ensureImages(ctx context.Context) error {
 	return nil
 }
 
+// Stop drains the server. New jobs are rejected and queued ones given up
+// on, then running jobs have until ctx is done to finish. Whatever is
+// still running after that is abandoned, and any containers left behind
+// are force-removed.
 func (s *Server) Stop(ctx context.Context) error {
-	s.logger.Info().Msg("shutting down HTTP server")
+	s.logger.Info().Msg("shutting down")
+
+	if n := s.queue.Close(); n > 0 {
+		s.logger.Warn().Int("jobs", n).Msg("gave up on queued jobs")
+	}
+
+	// The HTTP server stops accepting connections, but requests waiting
+	// on a running job are answered before it returns
+	httpDone := make(chan error, 1)
+	go func() {
+		httpDone <- s.httpServer.Shutdown(context.Background())
+	}()
+	grpcDone := make(chan struct{})
+	go func() {
+		defer close(grpcDone)
+		if s.grpcServer != nil {
+			s.grpcServer.GracefulStop()
+		}
+	}()
 
+	// Workers and nodes finish their current job and take no more
 	if s.cancelFunc != nil {
 		s.cancelFunc()
 	}
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		s.pool.Wait()
+		if s.dispatcher != nil {
+			_ = s.dispatcher.Drain(ctx)
+		}
+	}()
+	select {
+	case <-drained:
+		s.logger.Info().Msg("running jobs finished")
+	case <-ctx.Done():
+		s.logger.Warn().Msg("shutdown deadline passed with jobs still running")
+	}
+
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	n, err := s.queue.Abandon(cleanupCtx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to abandon running jobs")
+	}
+	if n > 0 {
+		s.logger.Warn().Int("jobs", n).Msg("abandoned running jobs")
+		// Cancelled workers remove their own containers; Close below
+		// catches any they do not get to
+		select {
+		case <-drained:
+		case <-cleanupCtx.Done():
+		}
+	}
+	if err := s.sandbox.Close(cleanupCtx); err != nil {
+		s.logger.Error().Err(err).Msg("failed to remove sandbox containers")
+	}
+
+	// Node streams only end when nodes disconnect, so they are closed
+	// rather than waited for
+	if s.nodeServer != nil {
+		s.nodeServer.Stop()
+	}
 
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+	var httpErr error
+	select {
+	case httpErr = <-httpDone:
+	case <-cleanupCtx.Done():
+		httpErr = s.httpServer.Close()
+	}
+	if s.grpcServer != nil {
+		select {
+		case <-grpcDone:
+		case <-cleanupCtx.Done():
+			// Streams following submissions do not end on their own
+			s.grpcServer.Stop()
+		}
+	}
+
+	// Callbacks still due are sent by other instances, or after a restart
+	if s.stopSender != nil {
+		s.stopSender()
+		select {
+		case <-s.senderDone:
+		case <-cleanupCtx.Done():
+		}
 	}
 
 	if s.db != nil {
 		s.db.Close()
 	}
 
+	if httpErr != nil {
+		return fmt.Errorf("failed to shutdown HTTP server: %w", httpErr)
+	}
 	return nil
 }
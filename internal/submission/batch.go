@@ -0,0 +1,146 @@
+package submission
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrBatchNotFound is returned for unknown batches, and for those of other
+// tenants.
+var ErrBatchNotFound = errors.New("batch not found")
+
+// Batch is a group of submissions made in one request, with their
+// progress. Submissions are listed in the order they were submitted.
+type Batch struct {
+	ID        string    `json:"batch_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Total     int       `json:"total"`
+	Queued    int       `json:"queued"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+	// Finished is set once no submission is queued.
+	Finished    bool         `json:"finished"`
+	Submissions []Submission `json:"submissions"`
+}
+
+// Item is one submission of a batch. CallbackURL may be empty.
+type Item struct {
+	ID          string
+	CallbackURL string
+}
+
+// NewBatchID returns a random, globally unique batch ID.
+func NewBatchID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "batch-" + hex.EncodeToString(b[:])
+}
+
+// CreateBatch records a batch and its submissions in one transaction,
+// before their jobs are queued. deadline applies to every submission, as
+// for Create.
+func (s *Store) CreateBatch(ctx context.Context, id, tenant string, items []Item, deadline time.Time) error {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create batch: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(ctx, `INSERT INTO batches (id, tenant, total) VALUES ($1, $2, $3)`,
+		id, tenant, len(items)); err != nil {
+		return fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	rows := make([][]any, len(items))
+	for i, item := range items {
+		var url, state *string
+		if item.CallbackURL != "" {
+			waiting := CallbackWaiting
+			url, state = &item.CallbackURL, &waiting
+		}
+		rows[i] = []any{item.ID, tenant, deadline, url, state, id, i}
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"submissions"},
+		[]string{"id", "tenant", "deadline", "callback_url", "callback_state", "batch_id", "position"},
+		pgx.CopyFromRows(rows),
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create batch submissions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to create batch: %w", err)
+	}
+	return nil
+}
+
+// DeleteBatch removes a batch, and its submissions, whose jobs could not be
+// queued.
+func (s *Store) DeleteBatch(ctx context.Context, id string) error {
+	if _, err := s.db.Pool.Exec(ctx, `DELETE FROM batches WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete batch: %w", err)
+	}
+	return nil
+}
+
+// GetBatch returns a tenant's batch with all its submissions.
+func (s *Store) GetBatch(ctx context.Context, id, tenant string) (*Batch, error) {
+	var batch Batch
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT id, created_at, total FROM batches
+		WHERE id = $1 AND tenant = $2`,
+		id, tenant,
+	).Scan(&batch.ID, &batch.CreatedAt, &batch.Total)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT `+submissionColumns+`
+		FROM submissions
+		WHERE batch_id = $1
+		ORDER BY position`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch submissions: %w", err)
+	}
+	subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Submission, error) {
+		sub, err := scanSubmission(row)
+		if err != nil {
+			return Submission{}, err
+		}
+		return *sub, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch submissions: %w", err)
+	}
+
+	batch.Submissions = subs
+	for _, sub := range subs {
+		switch sub.Status {
+		case StatusQueued:
+			batch.Queued++
+		case StatusCompleted:
+			batch.Completed++
+		case StatusFailed:
+			batch.Failed++
+		}
+	}
+	batch.Finished = batch.Queued == 0
+	return &batch, nil
+}
@@ -0,0 +1,358 @@
+// Package submission records asynchronous submissions: jobs whose client
+// does not wait on the connection for the result. The worker that finishes
+// such a job stores its outcome here, and a Sender delivers it to the
+// submission's callback URL.
+package submission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+)
+
+// Submission statuses.
+const (
+	StatusQueued    = "queued"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Callback delivery states. A callback waits while its job runs, is
+// pending until delivered, and fails once it runs out of attempts.
+const (
+	CallbackWaiting   = "waiting"
+	CallbackPending   = "pending"
+	CallbackDelivered = "delivered"
+	CallbackFailed    = "failed"
+)
+
+// expiryGrace is how long after its deadline an unfinished submission
+// whose job has gone is failed.
+const expiryGrace = time.Minute
+
+var (
+	// ErrNotFound is returned for unknown submissions, and for those of
+	// other tenants.
+	ErrNotFound = errors.New("submission not found")
+	// ErrDuplicate is returned by Create when the ID has been used before.
+	ErrDuplicate = errors.New("a submission with this ID already exists")
+)
+
+// Submission is an asynchronous submission and, once finished, its
+// outcome. Result is set for completed submissions, Error for failed ones.
+type Submission struct {
+	ID         string                    `json:"submission_id"`
+	BatchID    string                    `json:"batch_id,omitempty"`
+	Status     string                    `json:"status"`
+	Result     *executor.ExecutionResult `json:"result,omitempty"`
+	Error      string                    `json:"error,omitempty"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	FinishedAt *time.Time                `json:"finished_at,omitempty"`
+	Callback   *Callback                 `json:"callback,omitempty"`
+}
+
+// Callback is the delivery status of a submission's callback. Attempts
+// are listed only for a single submission, not for those of a batch.
+type Callback struct {
+	URL           string     `json:"url"`
+	State         string     `json:"state"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	Attempts      []Attempt  `json:"attempts,omitempty"`
+}
+
+// Attempt is one delivery attempt. StatusCode is 0 if no response arrived.
+type Attempt struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+type Store struct {
+	db     *database.Database
+	logger *zerolog.Logger
+	// due wakes the Sender when a callback becomes due
+	due chan struct{}
+}
+
+func New(db *database.Database, logger *zerolog.Logger) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+		due:    make(chan struct{}, 1),
+	}
+}
+
+// Create records a submission before its job is queued. callbackURL may be
+// empty. deadline is when the job must have finished by; past it, a
+// submission whose job is no longer queued or running fails.
+func (s *Store) Create(ctx context.Context, id, tenant, callbackURL string, deadline time.Time) error {
+	var url, state *string
+	if callbackURL != "" {
+		waiting := CallbackWaiting
+		url, state = &callbackURL, &waiting
+	}
+
+	_, err := s.db.Pool.Exec(ctx, `
+		INSERT INTO submissions (id, tenant, deadline, callback_url, callback_state)
+		VALUES ($1, $2, $3, $4, $5)`,
+		id, tenant, deadline, url, state,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create submission: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a submission whose job could not be queued.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.Pool.Exec(ctx, `DELETE FROM submissions WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete submission: %w", err)
+	}
+	return nil
+}
+
+// Finish records the outcome of a submission's job and makes its callback
+// due. Exactly one of result and jobErr is set. It reports false if the
+// submission had already finished, e.g. because it was cancelled.
+func (s *Store) Finish(ctx context.Context, id string, result *executor.ExecutionResult, jobErr error) (bool, error) {
+	status, encoded, message := StatusCompleted, []byte(nil), (*string)(nil)
+	if jobErr != nil {
+		status = StatusFailed
+		msg := jobErr.Error()
+		message = &msg
+	} else {
+		var err error
+		if encoded, err = json.Marshal(result); err != nil {
+			return false, fmt.Errorf("failed to encode result: %w", err)
+		}
+	}
+
+	tag, err := s.db.Pool.Exec(ctx, `
+		UPDATE submissions
+		SET status = $1, result = $2, error = $3, finished_at = now(),
+			callback_state = CASE WHEN callback_url IS NULL THEN NULL ELSE $4::text END,
+			next_callback_at = CASE WHEN callback_url IS NULL THEN NULL ELSE now() END
+		WHERE id = $5 AND status = $6`,
+		status, encoded, message, CallbackPending, id, StatusQueued,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to finish submission: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	select {
+	case s.due <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// submissionColumns are scanned by scanSubmission.
+const submissionColumns = `id, coalesce(batch_id, ''), status, result, error, created_at, finished_at,
+	callback_url, callback_state, next_callback_at, delivered_at`
+
+// Get returns a tenant's submission with its callback delivery attempts.
+func (s *Store) Get(ctx context.Context, id, tenant string) (*Submission, error) {
+	row := s.db.Pool.QueryRow(ctx, `
+		SELECT `+submissionColumns+`
+		FROM submissions
+		WHERE id = $1 AND tenant = $2`,
+		id, tenant,
+	)
+	sub, err := scanSubmission(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	if sub.Callback == nil {
+		return sub, nil
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT attempt, coalesce(status_code, 0), coalesce(error, ''), duration_ms, attempted_at
+		FROM webhook_deliveries
+		WHERE submission_id = $1
+		ORDER BY attempt`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get callback attempts: %w", err)
+	}
+	sub.Callback.Attempts, err = pgx.CollectRows(rows, pgx.RowToStructByPos[Attempt])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get callback attempts: %w", err)
+	}
+	return sub, nil
+}
+
+func scanSubmission(row pgx.Row) (*Submission, error) {
+	var (
+		sub           Submission
+		result        []byte
+		message       *string
+		url, state    *string
+		next, deliver *time.Time
+	)
+	err := row.Scan(&sub.ID, &sub.BatchID, &sub.Status, &result, &message, &sub.CreatedAt, &sub.FinishedAt,
+		&url, &state, &next, &deliver)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		if err := json.Unmarshal(result, &sub.Result); err != nil {
+			return nil, fmt.Errorf("failed to decode result: %w", err)
+		}
+	}
+	if message != nil {
+		sub.Error = *message
+	}
+	if url != nil {
+		sub.Callback = &Callback{URL: *url, State: *state, DeliveredAt: deliver}
+		if *state == CallbackPending {
+			sub.Callback.NextAttemptAt = next
+		}
+	}
+	return &sub, nil
+}
+
+// Expire fails submissions past their deadline whose job is no longer
+// queued or running: it was lost in a crash or dropped at shutdown, so no
+// worker will finish it. It returns the number of submissions failed.
+func (s *Store) Expire(ctx context.Context) (int, error) {
+	tag, err := s.db.Pool.Exec(ctx, `
+		UPDATE submissions s
+		SET status = $1, error = 'job was lost before it finished', finished_at = now(),
+			callback_state = CASE WHEN callback_url IS NULL THEN NULL ELSE $2::text END,
+			next_callback_at = CASE WHEN callback_url IS NULL THEN NULL ELSE now() END
+		WHERE status = $3 AND deadline < now() - $4 * interval '1 millisecond'
+			AND NOT EXISTS (SELECT 1 FROM jobs j WHERE j.id = s.id AND j.state IN ('queued', 'running'))`,
+		StatusFailed, CallbackPending, StatusQueued, expiryGrace.Milliseconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire submissions: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *Store) wake() <-chan struct{} {
+	return s.due
+}
+
+func (s *Store) claim(ctx context.Context, lease time.Duration, limit int) ([]delivery, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		UPDATE submissions
+		SET callback_attempts = callback_attempts + 1,
+			next_callback_at = now() + $1 * interval '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM submissions
+			WHERE callback_state = $2 AND next_callback_at <= now()
+			ORDER BY next_callback_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, callback_url, callback_attempts, status, result, error, finished_at`,
+		lease.Milliseconds(), CallbackPending, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim callbacks: %w", err)
+	}
+	due, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (delivery, error) {
+		var d delivery
+		err := row.Scan(&d.id, &d.url, &d.attempt, &d.status, &d.result, &d.message, &d.finishedAt)
+		return d, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim callbacks: %w", err)
+	}
+	return due, nil
+}
+
+func (s *Store) record(ctx context.Context, d delivery, code *int, message *string, elapsed time.Duration) error {
+	_, err := s.db.Pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries (submission_id, attempt, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)`,
+		d.id, d.attempt, code, message, elapsed.Milliseconds(),
+	)
+	return err
+}
+
+// settle is fenced by the attempt count, in case the claim lapsed and
+// another process has made a later attempt.
+func (s *Store) settle(ctx context.Context, d delivery, state string, retryIn time.Duration) error {
+	var (
+		update string
+		args   []any
+	)
+	switch state {
+	case CallbackDelivered:
+		update = `SET callback_state = $1, delivered_at = now(), next_callback_at = NULL`
+		args = []any{state}
+	case CallbackFailed:
+		update = `SET callback_state = $1, next_callback_at = NULL`
+		args = []any{state}
+	default:
+		update = `SET next_callback_at = now() + $1 * interval '1 millisecond'`
+		args = []any{retryIn.Milliseconds()}
+	}
+	args = append(args, d.id, d.attempt, CallbackPending)
+	_, err := s.db.Pool.Exec(ctx, `UPDATE submissions `+update+`
+		WHERE id = $2 AND callback_attempts = $3 AND callback_state = $4`,
+		args...,
+	)
+	return err
+}
+
+// StartCleanup deletes finished submissions, and their delivery attempts,
+// once they are older than retention and have no callback left to send.
+// Batches are deleted as a whole, once all their submissions qualify.
+func (s *Store) StartCleanup(interval, retention time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_, err := s.db.Pool.Exec(ctx, `
+				DELETE FROM submissions
+				WHERE batch_id IS NULL AND status <> $1 AND finished_at < now() - $2 * interval '1 millisecond'
+					AND callback_state IS DISTINCT FROM $3`,
+				StatusQueued, retention.Milliseconds(), CallbackPending,
+			)
+			if err == nil {
+				_, err = s.db.Pool.Exec(ctx, `
+					DELETE FROM batches b
+					WHERE created_at < now() - $2 * interval '1 millisecond'
+						AND NOT EXISTS (
+							SELECT 1 FROM submissions s
+							WHERE s.batch_id = b.id AND (s.status = $1
+								OR s.finished_at >= now() - $2 * interval '1 millisecond'
+								OR s.callback_state = $3)
+						)`,
+					StatusQueued, retention.Milliseconds(), CallbackPending,
+				)
+			}
+			cancel()
+			if err != nil {
+				s.logger.Error().Err(err).Msg("failed to clean up finished submissions")
+			}
+		}
+	}()
+}
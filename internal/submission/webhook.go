@@ -0,0 +1,345 @@
+package submission
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/rs/zerolog"
+)
+
+// Callback request headers. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of the timestamp, a '.', and the request body, keyed with the
+// shared secret.
+const (
+	EventHeader     = "X-Executioner-Event"
+	TimestampHeader = "X-Executioner-Timestamp"
+	SignatureHeader = "X-Executioner-Signature"
+)
+
+// EventFinished is the event sent when a submission finishes.
+const EventFinished = "submission.finished"
+
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultPollInterval = time.Second
+	DefaultConcurrency  = 8
+
+	// claimGrace is added to the request timeout to bound how long a claimed
+	// callback waits if the process sending it dies
+	claimGrace = 30 * time.Second
+	// maxErrorBody bounds how much of a failed response is kept
+	maxErrorBody = 512
+)
+
+// DefaultRetry makes eight attempts over about ten minutes.
+var DefaultRetry = queue.RetryPolicy{
+	MaxRetries: 7,
+	BaseDelay:  5 * time.Second,
+	MaxDelay:   5 * time.Minute,
+}
+
+var errPrivateAddress = errors.New("callback URL resolves to a private address")
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which
+// net.IP.IsPrivate leaves out but cloud providers use internally.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Event is the body of a callback request.
+type Event struct {
+	Event        string          `json:"event"`
+	SubmissionID string          `json:"submission_id"`
+	Status       string          `json:"status"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	FinishedAt   time.Time       `json:"finished_at"`
+}
+
+// SenderOptions configures a Sender.
+type SenderOptions struct {
+	// Secret signs every callback request. Requests are sent unsigned if it
+	// is removed while callbacks are pending.
+	Secret string
+	// Retry spaces out the attempts at a callback; once MaxRetries retries
+	// have failed it is given up on.
+	Retry queue.RetryPolicy
+	// Timeout bounds each request.
+	Timeout time.Duration
+	// PollInterval is how often due callbacks are looked for, besides
+	// whenever a submission finishes in this process.
+	PollInterval time.Duration
+	// Concurrency is the number of callbacks sent at once.
+	Concurrency int
+	// AllowPrivateNetworks permits callbacks to loopback, private,
+	// link-local and carrier-grade NAT addresses, which are refused by default so that
+	// submitters cannot reach internal services.
+	AllowPrivateNetworks bool
+}
+
+// callbackStore keeps the state of callbacks for a Sender. *Store keeps
+// it in Postgres.
+type callbackStore interface {
+	// Expire fails submissions whose jobs were lost.
+	Expire(ctx context.Context) (int, error)
+	// wake signals that a callback may have become due.
+	wake() <-chan struct{}
+	// claim takes up to limit due callbacks, keeping other senders away
+	// from them for lease.
+	claim(ctx context.Context, lease time.Duration, limit int) ([]delivery, error)
+	// record logs an attempt. code is nil if no response arrived, and
+	// message is nil if the attempt succeeded.
+	record(ctx context.Context, d delivery, code *int, message *string, elapsed time.Duration) error
+	// settle leaves a callback in state: delivered, failed, or pending
+	// again after retryIn.
+	settle(ctx context.Context, d delivery, state string, retryIn time.Duration) error
+}
+
+// Sender delivers the callbacks of finished submissions. Callbacks are
+// claimed from Postgres, so any number of processes can send them.
+type Sender struct {
+	store  callbackStore
+	opts   SenderOptions
+	client *http.Client
+	logger *zerolog.Logger
+}
+
+func NewSender(store *Store, opts SenderOptions, logger *zerolog.Logger) *Sender {
+	return newSender(store, opts, logger)
+}
+
+func newSender(store callbackStore, opts SenderOptions, logger *zerolog.Logger) *Sender {
+	if opts.Retry == (queue.RetryPolicy{}) {
+		opts.Retry = DefaultRetry
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the dialer's address check meaningless
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		store: store,
+		opts:  opts,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+			// A redirect counts as a failed delivery rather than being
+			// followed somewhere the submitter did not name
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}
+}
+
+// Sign returns the signature header value for a callback body sent at
+// timestamp, a Unix time in seconds.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run sends due callbacks until ctx is done. It also fails submissions
+// whose jobs were lost, so that their callbacks are sent.
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.store.Expire(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error().Err(err).Msg("failed to expire lost submissions")
+		} else if n > 0 {
+			s.logger.Warn().Int("submissions", n).Msg("failed submissions whose jobs were lost")
+		}
+
+		// Keep going while there is a backlog
+		for {
+			n, err := s.sendDue(ctx)
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("failed to claim due callbacks")
+			}
+			if err != nil || n < s.opts.Concurrency {
+				break
+			}
+		}
+
+		select {
+		case <-s.store.wake():
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// delivery is a claimed callback.
+type delivery struct {
+	id, url    string
+	attempt    int
+	status     string
+	result     []byte
+	message    *string
+	finishedAt time.Time
+}
+
+// sendDue claims up to Concurrency due callbacks and sends them, returning
+// the number claimed. Claiming pushes their next attempt past the request
+// timeout, so other processes leave them alone meanwhile.
+func (s *Sender) sendDue(ctx context.Context) (int, error) {
+	due, err := s.store.claim(ctx, s.opts.Timeout+claimGrace, s.opts.Concurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.send(d)
+		}()
+	}
+	wg.Wait()
+	return len(due), nil
+}
+
+// send makes one attempt at a callback and records its outcome. It uses
+// its own context, so an attempt under way when Run stops is recorded.
+func (s *Sender) send(d delivery) {
+	event := Event{
+		Event:        EventFinished,
+		SubmissionID: d.id,
+		Status:       d.status,
+		Result:       d.result,
+		FinishedAt:   d.finishedAt,
+	}
+	if d.message != nil {
+		event.Error = *d.message
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error().Err(err).Str("submission_id", d.id).Msg("failed to encode callback")
+		return
+	}
+
+	started := time.Now()
+	code, sendErr := s.post(d.url, body)
+	elapsed := time.Since(started)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var statusCode *int
+	if code != 0 {
+		statusCode = &code
+	}
+	var message *string
+	if sendErr != nil {
+		msg := sendErr.Error()
+		message = &msg
+	}
+	if err := s.store.record(ctx, d, statusCode, message, elapsed); err != nil {
+		s.logger.Error().Err(err).Str("submission_id", d.id).Msg("failed to record callback attempt")
+	}
+
+	state, outcome := CallbackPending, "retry"
+	var retryIn time.Duration
+	switch {
+	case sendErr == nil:
+		state, outcome = CallbackDelivered, CallbackDelivered
+	case d.attempt > s.opts.Retry.MaxRetries:
+		state, outcome = CallbackFailed, CallbackFailed
+		s.logger.Error().Err(sendErr).Str("submission_id", d.id).
+			Int("attempts", d.attempt).Msg("giving up on callback")
+	default:
+		retryIn = s.opts.Retry.Delay(d.attempt)
+		s.logger.Warn().Err(sendErr).Str("submission_id", d.id).
+			Int("attempt", d.attempt).Dur("retry_in", retryIn).Msg("callback failed")
+	}
+	metrics.WebhookDeliveries.WithLabelValues(outcome).Inc()
+
+	if err := s.store.settle(ctx, d, state, retryIn); err != nil {
+		s.logger.Error().Err(err).Str("submission_id", d.id).Msg("failed to record callback outcome")
+	}
+}
+
+// post sends a signed callback. Any response other than a 2xx is an error;
+// code is the response's status, or 0 if none arrived.
+func (s *Sender) post(url string, body []byte) (code int, err error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "executioner-webhook")
+	req.Header.Set(EventHeader, EventFinished)
+	req.Header.Set(TimestampHeader, timestamp)
+	if s.opts.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(s.opts.Secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if len(snippet) == 0 {
+		return resp.StatusCode, fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return resp.StatusCode, fmt.Errorf("callback returned %s: %s", resp.Status, bytes.TrimSpace(snippet))
+}
+
+// refusePrivate is a dialer Control function rejecting connections to
+// addresses that are not publicly routable. It runs after name resolution,
+// so hostnames cannot be used to get around it.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package submission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/rs/zerolog"
+)
+
+// fakeCallbacks hands out the deliveries it holds and remembers what the
+// Sender recorded about them.
+type fakeCallbacks struct {
+	mu       sync.Mutex
+	due      []delivery
+	attempts []recordedAttempt
+	settled  []settledCallback
+}
+
+type recordedAttempt struct {
+	id      string
+	attempt int
+	code    *int
+	message *string
+}
+
+type settledCallback struct {
+	id      string
+	attempt int
+	state   string
+	retryIn time.Duration
+}
+
+func (f *fakeCallbacks) Expire(context.Context) (int, error) { return 0, nil }
+
+func (f *fakeCallbacks) wake() <-chan struct{} { return nil }
+
+func (f *fakeCallbacks) claim(_ context.Context, _ time.Duration, limit int) ([]delivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := min(limit, len(f.due))
+	due := f.due[:n]
+	f.due = f.due[n:]
+	return due, nil
+}
+
+func (f *fakeCallbacks) record(_ context.Context, d delivery, code *int, message *string, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, recordedAttempt{d.id, d.attempt, code, message})
+	return nil
+}
+
+func (f *fakeCallbacks) settle(_ context.Context, d delivery, state string, retryIn time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.settled = append(f.settled, settledCallback{d.id, d.attempt, state, retryIn})
+	return nil
+}
+
+var testRetry = queue.RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Second,
+	MaxDelay:   3 * time.Second,
+}
+
+func newTestSender(t *testing.T, store callbackStore, secret string) *Sender {
+	t.Helper()
+	logger := zerolog.Nop()
+	return newSender(store, SenderOptions{
+		Secret:               secret,
+		Retry:                testRetry,
+		Timeout:              time.Second,
+		AllowPrivateNetworks: true,
+	}, &logger)
+}
+
+func TestSendSignsCallback(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Header.Clone(), body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	finished := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := &fakeCallbacks{due: []delivery{{
+		id:         "job-1",
+		url:        server.URL,
+		attempt:    1,
+		status:     StatusCompleted,
+		result:     []byte(`{"Status":"success"}`),
+		finishedAt: finished,
+	}}}
+	s := newTestSender(t, store, "s3cret")
+
+	n, err := s.sendDue(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("sendDue() = %d, %v, want 1, nil", n, err)
+	}
+
+	req := <-requests
+	timestamp := req.header.Get(TimestampHeader)
+	if timestamp == "" {
+		t.Fatal("no timestamp header")
+	}
+	if got, want := req.header.Get(SignatureHeader), Sign("s3cret", timestamp, req.body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if got := req.header.Get(EventHeader); got != EventFinished {
+		t.Errorf("event header = %q, want %q", got, EventFinished)
+	}
+
+	var event Event
+	if err := json.Unmarshal(req.body, &event); err != nil {
+		t.Fatalf("body is not an event: %v", err)
+	}
+	if event.SubmissionID != "job-1" || event.Status != StatusCompleted ||
+		string(event.Result) != `{"Status":"success"}` || !event.FinishedAt.Equal(finished) {
+		t.Errorf("event = %+v", event)
+	}
+
+	if len(store.attempts) != 1 || store.attempts[0].code == nil || *store.attempts[0].code != http.StatusNoContent ||
+		store.attempts[0].message != nil {
+		t.Errorf("recorded attempts = %+v, want one 204 without an error", store.attempts)
+	}
+	want := []settledCallback{{"job-1", 1, CallbackDelivered, 0}}
+	if !slices.Equal(store.settled, want) {
+		t.Errorf("settled = %+v, want %+v", store.settled, want)
+	}
+}
+
+func TestSendUnsignedWithoutSecret(t *testing.T) {
+	signatures := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatures <- r.Header.Get(SignatureHeader)
+	}))
+	defer server.Close()
+
+	store := &fakeCallbacks{due: []delivery{{id: "job-1", url: server.URL, attempt: 1}}}
+	if _, err := newTestSender(t, store, "").sendDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-signatures; got != "" {
+		t.Errorf("signature = %q, want none", got)
+	}
+}
+
+func TestSendRetriesFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		attempt int
+		want    settledCallback
+		message string
+	}{
+		{
+			name:    "first failure",
+			status:  http.StatusInternalServerError,
+			attempt: 1,
+			want:    settledCallback{"job-1", 1, CallbackPending, time.Second},
+			message: "500 Internal Server Error: boom",
+		},
+		{
+			name:    "backoff doubles",
+			status:  http.StatusBadGateway,
+			attempt: 2,
+			want:    settledCallback{"job-1", 2, CallbackPending, 2 * time.Second},
+			message: "502 Bad Gateway: boom",
+		},
+		{
+			name:    "backoff is capped",
+			status:  http.StatusServiceUnavailable,
+			attempt: 3,
+			want:    settledCallback{"job-1", 3, CallbackPending, 3 * time.Second},
+			message: "503 Service Unavailable: boom",
+		},
+		{
+			name:    "out of retries",
+			status:  http.StatusInternalServerError,
+			attempt: 4,
+			want:    settledCallback{"job-1", 4, CallbackFailed, 0},
+			message: "500 Internal Server Error: boom",
+		},
+		{
+			name:    "redirects are not followed",
+			status:  http.StatusFound,
+			attempt: 1,
+			want:    settledCallback{"job-1", 1, CallbackPending, time.Second},
+			message: "302 Found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusFound {
+					_, _ = w.Write([]byte("boom\n"))
+				}
+			}))
+			defer server.Close()
+
+			store := &fakeCallbacks{due: []delivery{{id: "job-1", url: server.URL, attempt: tt.attempt}}}
+			if _, err := newTestSender(t, store, "s3cret").sendDue(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(store.attempts) != 1 {
+				t.Fatalf("recorded %d attempts, want 1", len(store.attempts))
+			}
+			got := store.attempts[0]
+			if got.attempt != tt.attempt || got.code == nil || *got.code != tt.status {
+				t.Errorf("recorded attempt %d with code %v, want attempt %d with %d", got.attempt, got.code, tt.attempt, tt.status)
+			}
+			if got.message == nil || !strings.Contains(*got.message, tt.message) {
+				t.Errorf("recorded error %v, want it to contain %q", got.message, tt.message)
+			}
+			if !slices.Equal(store.settled, []settledCallback{tt.want}) {
+				t.Errorf("settled = %+v, want %+v", store.settled, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendRecordsConnectionErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	store := &fakeCallbacks{due: []delivery{{id: "job-1", url: url, attempt: 2}}}
+	if _, err := newTestSender(t, store, "s3cret").sendDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.attempts) != 1 || store.attempts[0].code != nil || store.attempts[0].message == nil {
+		t.Errorf("recorded attempts = %+v, want one without a status code and with an error", store.attempts)
+	}
+	want := []settledCallback{{"job-1", 2, CallbackPending, 2 * time.Second}}
+	if !slices.Equal(store.settled, want) {
+		t.Errorf("settled = %+v, want %+v", store.settled, want)
+	}
+}
+
+func TestSendRefusesPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("callback reached a loopback server")
+	}))
+	defer server.Close()
+
+	logger := zerolog.Nop()
+	store := &fakeCallbacks{due: []delivery{{id: "job-1", url: server.URL, attempt: 1}}}
+	s := newSender(store, SenderOptions{Retry: testRetry, Timeout: time.Second}, &logger)
+	if _, err := s.sendDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.attempts) != 1 || store.attempts[0].message == nil ||
+		!strings.Contains(*store.attempts[0].message, errPrivateAddress.Error()) {
+		t.Errorf("recorded attempts = %+v, want one refused as private", store.attempts)
+	}
+}
+
+func TestRefusePrivate(t *testing.T) {
+	tests := []struct {
+		address string
+		refused bool
+	}{
+		{"8.8.8.8:443", false},
+		{"[2001:4860:4860::8888]:443", false},
+		{"100.63.255.255:80", false},
+		{"100.128.0.0:80", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"[fd00::1]:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"224.0.0.1:80", true},
+		{"100.64.0.1:80", true},
+		{"100.127.255.254:80", true},
+		{"[::ffff:100.100.100.200]:80", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := refusePrivate("tcp", tt.address, nil)
+			if refused := errors.Is(err, errPrivateAddress); refused != tt.refused {
+				t.Errorf("refusePrivate(%q) = %v, want refused %v", tt.address, err, tt.refused)
+			}
+		})
+	}
+}
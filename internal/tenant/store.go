@@ -0,0 +1,383 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// authCacheTTL is how long an authenticated key is trusted without asking
+// the database again. Changes made through another instance take up to
+// this long to apply here.
+const authCacheTTL = 30 * time.Second
+
+type cachedTenant struct {
+	tenant  *Tenant
+	expires time.Time
+}
+
+type Store struct {
+	db *database.Database
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant // by key hash
+}
+
+func NewStore(db *database.Database) *Store {
+	return &Store{
+		db:    db,
+		cache: make(map[string]cachedTenant),
+	}
+}
+
+// forget drops cached keys after a change to tenants or keys.
+func (s *Store) forget() {
+	s.mu.Lock()
+	clear(s.cache)
+	s.mu.Unlock()
+}
+
+const tenantColumns = `id, name, executions_per_day, cpu_seconds_per_month,
+	max_time_limit_ms, max_memory_limit_kb, allowed_languages, created_at`
+
+func scanTenant(row pgx.Row) (*Tenant, error) {
+	var t Tenant
+	err := row.Scan(&t.ID, &t.Name, &t.Quotas.ExecutionsPerDay, &t.Quotas.CPUSecondsPerMonth,
+		&t.Quotas.MaxTimeLimitMs, &t.Quotas.MaxMemoryLimitKb, &t.Quotas.AllowedLanguages, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Create adds a tenant.
+func (s *Store) Create(ctx context.Context, id, name string, quotas Quotas) (*Tenant, error) {
+	if quotas.AllowedLanguages == nil {
+		quotas.AllowedLanguages = []string{}
+	}
+	row := s.db.Pool.QueryRow(ctx, `
+		INSERT INTO tenants (id, name, executions_per_day, cpu_seconds_per_month,
+			max_time_limit_ms, max_memory_limit_kb, allowed_languages)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+tenantColumns,
+		id, name, quotas.ExecutionsPerDay, quotas.CPUSecondsPerMonth,
+		quotas.MaxTimeLimitMs, quotas.MaxMemoryLimitKb, quotas.AllowedLanguages,
+	)
+	t, err := scanTenant(row)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+	return t, nil
+}
+
+// Update replaces a tenant's name and quotas.
+func (s *Store) Update(ctx context.Context, id, name string, quotas Quotas) (*Tenant, error) {
+	if quotas.AllowedLanguages == nil {
+		quotas.AllowedLanguages = []string{}
+	}
+	row := s.db.Pool.QueryRow(ctx, `
+		UPDATE tenants
+		SET name = $2, executions_per_day = $3, cpu_seconds_per_month = $4,
+			max_time_limit_ms = $5, max_memory_limit_kb = $6, allowed_languages = $7
+		WHERE id = $1
+		RETURNING `+tenantColumns,
+		id, name, quotas.ExecutionsPerDay, quotas.CPUSecondsPerMonth,
+		quotas.MaxTimeLimitMs, quotas.MaxMemoryLimitKb, quotas.AllowedLanguages,
+	)
+	t, err := scanTenant(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tenant: %w", err)
+	}
+	s.forget()
+	return t, nil
+}
+
+// Get returns a tenant.
+func (s *Store) Get(ctx context.Context, id string) (*Tenant, error) {
+	row := s.db.Pool.QueryRow(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id)
+	t, err := scanTenant(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return t, nil
+}
+
+// List returns every tenant, by ID.
+func (s *Store) List(ctx context.Context) ([]*Tenant, error) {
+	rows, err := s.db.Pool.Query(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []*Tenant{}
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tenants: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+// Delete removes a tenant with its keys and usage.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM tenants WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	s.forget()
+	return nil
+}
+
+// CreateKey issues an API key for a tenant. The key is returned only
+// here; the store keeps its hash.
+func (s *Store) CreateKey(ctx context.Context, tenantID string) (*Key, string, error) {
+	id, key := newKey()
+	k := Key{ID: id, TenantID: tenantID}
+	err := s.db.Pool.QueryRow(ctx, `
+		INSERT INTO api_keys (id, tenant_id, hash)
+		SELECT $1, id, $3 FROM tenants WHERE id = $2
+		RETURNING created_at`,
+		id, tenantID, hashKey(key),
+	).Scan(&k.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+	return &k, key, nil
+}
+
+// ListKeys returns a tenant's keys, oldest first.
+func (s *Store) ListKeys(ctx context.Context, tenantID string) ([]Key, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, tenant_id, created_at, last_used_at
+		FROM api_keys
+		WHERE tenant_id = $1
+		ORDER BY created_at`,
+		tenantID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[Key])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// DeleteKey revokes one of a tenant's keys.
+func (s *Store) DeleteKey(ctx context.Context, tenantID, id string) error {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM api_keys WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete API key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrKeyNotFound
+	}
+	s.forget()
+	return nil
+}
+
+// Authenticate returns the tenant an API key belongs to, or ErrInvalidKey.
+func (s *Store) Authenticate(ctx context.Context, key string) (*Tenant, error) {
+	hash := hashKey(key)
+	s.mu.Lock()
+	cached, ok := s.cache[string(hash)]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.tenant, nil
+	}
+
+	row := s.db.Pool.QueryRow(ctx, `
+		WITH used AS (
+			UPDATE api_keys SET last_used_at = now()
+			WHERE hash = $1
+			RETURNING tenant_id
+		)
+		SELECT `+tenantColumns+`
+		FROM tenants
+		WHERE id = (SELECT tenant_id FROM used)`,
+		hash,
+	)
+	t, err := scanTenant(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate API key: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[string(hash)] = cachedTenant{tenant: t, expires: time.Now().Add(authCacheTTL)}
+	s.mu.Unlock()
+	return t, nil
+}
+
+// Reservation is a number of executions counted against a tenant's daily
+// quota.
+type Reservation struct {
+	tenantID string
+	day      time.Time
+	n        int
+}
+
+// Reserve counts n executions against a tenant's quotas before they are
+// queued. It returns a *QuotaError if the tenant has run out of
+// executions for the day or of CPU time for the month. CPU time is
+// counted as jobs finish, so jobs running when it runs out still finish.
+func (s *Store) Reserve(ctx context.Context, t *Tenant, n int) (*Reservation, error) {
+	day, nextDay, month, nextMonth := period(time.Now())
+
+	if limit := t.Quotas.CPUSecondsPerMonth; limit > 0 {
+		var usedMs int64
+		err := s.db.Pool.QueryRow(ctx, `
+			SELECT coalesce(sum(cpu_ms), 0)::bigint FROM tenant_usage
+			WHERE tenant_id = $1 AND day >= $2`,
+			t.ID, month,
+		).Scan(&usedMs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check usage: %w", err)
+		}
+		if usedMs >= limit*1000 {
+			return nil, &QuotaError{Quota: "cpu_seconds_per_month", Limit: limit, ResetsAt: nextMonth}
+		}
+	}
+
+	limit := t.Quotas.ExecutionsPerDay
+	exceeded := &QuotaError{Quota: "executions_per_day", Limit: int64(limit), ResetsAt: nextDay}
+	if limit > 0 && n > limit {
+		return nil, exceeded
+	}
+	var count int
+	err := s.db.Pool.QueryRow(ctx, `
+		INSERT INTO tenant_usage (tenant_id, day, executions)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id, day) DO UPDATE
+		SET executions = tenant_usage.executions + EXCLUDED.executions
+		WHERE $4::integer = 0 OR tenant_usage.executions + EXCLUDED.executions <= $4::integer
+		RETURNING executions`,
+		t.ID, day, n, limit,
+	).Scan(&count)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, exceeded
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to count executions: %w", err)
+	}
+	return &Reservation{tenantID: t.ID, day: day, n: n}, nil
+}
+
+// Release gives back the executions of a reservation whose jobs could not
+// be queued.
+func (s *Store) Release(ctx context.Context, r *Reservation) error {
+	_, err := s.db.Pool.Exec(ctx, `
+		UPDATE tenant_usage SET executions = greatest(executions - $3, 0)
+		WHERE tenant_id = $1 AND day = $2`,
+		r.tenantID, r.day, r.n,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release executions: %w", err)
+	}
+	return nil
+}
+
+// Record adds the CPU time of a finished job to its tenant's usage. Jobs
+// of anonymous clients, whose tenant is their address, are ignored.
+func (s *Store) Record(ctx context.Context, tenantID string, cpu time.Duration) error {
+	day, _, _, _ := period(time.Now())
+	_, err := s.db.Pool.Exec(ctx, `
+		INSERT INTO tenant_usage (tenant_id, day, cpu_ms)
+		SELECT id, $2, $3 FROM tenants WHERE id = $1
+		ON CONFLICT (tenant_id, day) DO UPDATE
+		SET cpu_ms = tenant_usage.cpu_ms + EXCLUDED.cpu_ms`,
+		tenantID, day, cpu.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// Usage returns what a tenant has used this day and month.
+func (s *Store) Usage(ctx context.Context, tenantID string) (*Usage, error) {
+	day, nextDay, month, nextMonth := period(time.Now())
+	u := Usage{DayResetsAt: nextDay, MonthResetsAt: nextMonth}
+	var cpuMs int64
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT coalesce(sum(executions) FILTER (WHERE day = $2), 0)::integer,
+			coalesce(sum(cpu_ms), 0)::bigint
+		FROM tenant_usage
+		WHERE tenant_id = $1 AND day >= $3`,
+		tenantID, day, month,
+	).Scan(&u.ExecutionsToday, &cpuMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	u.CPUSecondsThisMonth = float64(cpuMs) / 1000
+	return &u, nil
+}
+
+// AllUsage returns what every tenant has used this day and month, by
+// tenant ID.
+func (s *Store) AllUsage(ctx context.Context) (map[string]*Usage, error) {
+	day, nextDay, month, nextMonth := period(time.Now())
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT t.id,
+			coalesce(sum(u.executions) FILTER (WHERE u.day = $1), 0)::integer,
+			coalesce(sum(u.cpu_ms), 0)::bigint
+		FROM tenants t
+		LEFT JOIN tenant_usage u ON u.tenant_id = t.id AND u.day >= $2
+		GROUP BY t.id`,
+		day, month,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	var (
+		id         string
+		executions int
+		cpuMs      int64
+	)
+	usage := make(map[string]*Usage)
+	_, err = pgx.ForEachRow(rows, []any{&id, &executions, &cpuMs}, func() error {
+		usage[id] = &Usage{
+			ExecutionsToday:     executions,
+			CPUSecondsThisMonth: float64(cpuMs) / 1000,
+			DayResetsAt:         nextDay,
+			MonthResetsAt:       nextMonth,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,178 @@
+// Package tenant authenticates API keys and enforces the quotas of the
+// tenants they belong to. Keys are stored hashed; a key is shown once,
+// when it is created.
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/executor"
+)
+
+// KeyPrefix starts every API key, so that leaked keys are easy to spot.
+const KeyPrefix = "exk_"
+
+var (
+	// ErrNotFound is returned for unknown tenants and keys.
+	ErrNotFound = errors.New("tenant not found")
+	// ErrKeyNotFound is returned for unknown API keys of a tenant.
+	ErrKeyNotFound = errors.New("API key not found")
+	// ErrExists is returned by Create when the ID is taken.
+	ErrExists = errors.New("a tenant with this ID already exists")
+	// ErrInvalidKey is returned by Authenticate for keys that do not exist
+	// or have been deleted.
+	ErrInvalidKey = errors.New("invalid API key")
+)
+
+// IDPattern is what tenant IDs must match. IDs start with a letter and
+// have no dots or colons, so they can never be mistaken for the client
+// addresses that identify anonymous clients.
+var IDPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+
+// Tenant is a client of the API, with its quotas.
+type Tenant struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Quotas    Quotas    `json:"quotas"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Quotas limit what a tenant can run. Zero values and an empty language
+// list are unlimited.
+type Quotas struct {
+	ExecutionsPerDay   int   `json:"executions_per_day"`
+	CPUSecondsPerMonth int64 `json:"cpu_seconds_per_month"`
+
+	// The ceilings of each request's limits, for the compile phase as
+	// well as the run. Language defaults above them are lowered to them.
+	MaxTimeLimitMs   int `json:"max_time_limit_ms"`
+	MaxMemoryLimitKb int `json:"max_memory_limit_kb"`
+
+	AllowedLanguages []string `json:"allowed_languages"`
+}
+
+// Key is an API key, without the key itself.
+type Key struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Usage is what a tenant has used of its quotas. Days and months are UTC.
+type Usage struct {
+	ExecutionsToday     int       `json:"executions_today"`
+	CPUSecondsThisMonth float64   `json:"cpu_seconds_this_month"`
+	DayResetsAt         time.Time `json:"day_resets_at"`
+	MonthResetsAt       time.Time `json:"month_resets_at"`
+}
+
+// QuotaError is returned when a tenant has used up a quota.
+type QuotaError struct {
+	Quota    string
+	Limit    int64
+	ResetsAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded", e.Quota, e.Limit)
+}
+
+// DeniedError reports a request outside its tenant's per-request quotas.
+// Field is the executor's name for the limit, or "language".
+type DeniedError struct {
+	Field   string
+	Message string
+}
+
+func (e *DeniedError) Error() string {
+	return e.Message
+}
+
+// Permit checks the options of a request against the per-request quotas,
+// before its limits are resolved: zero limits are left to Clamp.
+func (q Quotas) Permit(opts executor.ExecuteOptions) error {
+	if len(q.AllowedLanguages) > 0 && !slices.Contains(q.AllowedLanguages, opts.LanguageID) {
+		return &DeniedError{Field: "language", Message: fmt.Sprintf("language %q is not allowed for this API key", opts.LanguageID)}
+	}
+	limits := []struct {
+		field string
+		value int
+		max   int
+	}{
+		{"time_limit_ms", opts.TimeLimitMs, q.MaxTimeLimitMs},
+		{"memory_limit_kb", opts.MemoryLimitKb, q.MaxMemoryLimitKb},
+		{"compile_time_limit_ms", opts.CompileTimeLimitMs, q.MaxTimeLimitMs},
+		{"compile_memory_limit_kb", opts.CompileMemoryLimitKb, q.MaxMemoryLimitKb},
+	}
+	for _, l := range limits {
+		if l.max > 0 && l.value > l.max {
+			return &DeniedError{Field: l.field, Message: fmt.Sprintf("%s %d exceeds this API key's maximum of %d", l.field, l.value, l.max)}
+		}
+	}
+	return nil
+}
+
+// Clamp lowers resolved limits, which may be language defaults, to the
+// per-request ceilings.
+func (q Quotas) Clamp(opts *executor.ExecuteOptions) {
+	for _, l := range []struct {
+		value *int
+		max   int
+	}{
+		{&opts.TimeLimitMs, q.MaxTimeLimitMs},
+		{&opts.MemoryLimitKb, q.MaxMemoryLimitKb},
+		{&opts.CompileTimeLimitMs, q.MaxTimeLimitMs},
+		{&opts.CompileMemoryLimitKb, q.MaxMemoryLimitKb},
+	} {
+		if l.max > 0 && *l.value > l.max {
+			*l.value = l.max
+		}
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying an authenticated tenant.
+func NewContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant a request was authenticated as, if any.
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(*Tenant)
+	return t, ok
+}
+
+// newKey generates an API key and its ID.
+func newKey() (id, key string) {
+	var idBytes [8]byte
+	var secret [32]byte
+	_, _ = rand.Read(idBytes[:])
+	_, _ = rand.Read(secret[:])
+	return "key-" + hex.EncodeToString(idBytes[:]), KeyPrefix + base64.RawURLEncoding.EncodeToString(secret[:])
+}
+
+// hashKey is how keys are stored. Keys are random, so a fast hash is
+// enough to make the stored hashes useless to whoever reads them.
+func hashKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// period returns the start of day and month t is in, and when they end.
+func period(t time.Time) (day, nextDay, month, nextMonth time.Time) {
+	t = t.UTC()
+	day = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, day.AddDate(0, 0, 1), month, month.AddDate(0, 1, 0)
+}
@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/rs/zerolog"
+)
+
+// AutoscalerOptions bounds the pool and sets the host limits above which
+// it stops growing.
+type AutoscalerOptions struct {
+	Min int
+	Max int
+	// Interval is how often the pool is resized. Shrinking happens one
+	// worker per interval, so idle capacity drains gradually.
+	Interval time.Duration
+	// MaxCPULoad is the load average per core, and MaxMemoryUsage the
+	// fraction of memory in use, beyond which the pool sheds workers
+	// instead of growing.
+	MaxCPULoad     float64
+	MaxMemoryUsage float64
+}
+
+// Autoscaler grows the pool while jobs are waiting and the host has
+// headroom, and shrinks it when workers sit idle or the host is overloaded.
+type Autoscaler struct {
+	pool   *Pool
+	queue  queue.Queue
+	opts   AutoscalerOptions
+	logger *zerolog.Logger
+}
+
+func NewAutoscaler(pool *Pool, q queue.Queue, opts AutoscalerOptions, logger *zerolog.Logger) *Autoscaler {
+	if opts.Min <= 0 {
+		opts.Min = 1
+	}
+	if opts.Max < opts.Min {
+		opts.Max = opts.Min
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.MaxCPULoad <= 0 {
+		opts.MaxCPULoad = 0.9
+	}
+	if opts.MaxMemoryUsage <= 0 {
+		opts.MaxMemoryUsage = 0.9
+	}
+	return &Autoscaler{
+		pool:   pool,
+		queue:  q,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Run resizes the pool every interval until ctx is cancelled.
+func (a *Autoscaler) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.scale()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *Autoscaler) scale() {
+	size := a.pool.Size()
+	depth := a.queue.Depth()
+	busy := a.pool.Busy()
+	usage, ok := readHostUsage()
+	overloaded := ok && (usage.CPU > a.opts.MaxCPULoad || usage.Memory > a.opts.MaxMemoryUsage)
+
+	target, reason := size, ""
+	switch {
+	case size < a.opts.Min:
+		target, reason = a.opts.Min, "min"
+	case size > a.opts.Max:
+		target, reason = a.opts.Max, "max"
+	case overloaded && size > a.opts.Min:
+		target, reason = size-1, "host_overloaded"
+	case depth > 0 && !overloaded && size < a.opts.Max:
+		// Grow by the backlog, at most doubling per step so a burst does
+		// not overshoot before the host usage catches up
+		step := min(depth, max(size, 1))
+		target, reason = min(size+step, a.opts.Max), "queue_backlog"
+	case depth == 0 && busy < size && size > a.opts.Min:
+		target, reason = size-1, "idle"
+	}
+	if target == size {
+		return
+	}
+
+	direction := "up"
+	if target < size {
+		direction = "down"
+	}
+	a.pool.Resize(target)
+	metrics.AutoscalerDecisions.WithLabelValues(direction, reason).Inc()
+	a.logger.Info().
+		Int("from", size).
+		Int("to", target).
+		Str("reason", reason).
+		Int("queue_depth", depth).
+		Int("busy", busy).
+		Float64("cpu_load", usage.CPU).
+		Float64("memory_usage", usage.Memory).
+		Msg("resized worker pool")
+}
@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/itstheanurag/executioner/internal/deadletter"
+	"github.com/itstheanurag/executioner/internal/executor"
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/itstheanurag/executioner/internal/queue"
+	"github.com/itstheanurag/executioner/internal/submission"
+	"github.com/itstheanurag/executioner/internal/tenant"
+	"github.com/rs/zerolog"
+)
+
+// Finisher reports the outcome of an attempt at a job: it records metrics
+// and the tenant's usage, retries infrastructure errors with backoff,
+// dead-letters jobs that run out of retries and records the outcome of
+// asynchronous submissions. Local workers and remote nodes share it.
+type Finisher struct {
+	queue       queue.Queue
+	retry       queue.RetryPolicy
+	deadLetters *deadletter.Store
+	submissions *submission.Store
+	tenants     *tenant.Store
+	logger      *zerolog.Logger
+}
+
+func NewFinisher(q queue.Queue, retry queue.RetryPolicy, deadLetters *deadletter.Store, submissions *submission.Store, tenants *tenant.Store, logger *zerolog.Logger) *Finisher {
+	return &Finisher{
+		queue:       q,
+		retry:       retry,
+		deadLetters: deadLetters,
+		submissions: submissions,
+		tenants:     tenants,
+		logger:      logger,
+	}
+}
+
+// Finish handles the result of an attempt that took duration. Exactly one
+// of result and err is set.
+func (f *Finisher) Finish(job *queue.Job, result *executor.ExecutionResult, duration time.Duration, err error) {
+	// Record metrics
+	status := "success"
+	if err != nil {
+		status = "error"
+		if f.retryOrBury(job, err) {
+			return
+		}
+		f.complete(job, nil, err)
+		metrics.ExecutionsTotal.WithLabelValues(job.Options.LanguageID, status).Inc()
+		return
+	}
+
+	if result.Status != "success" {
+		status = result.Status
+	}
+
+	metrics.ExecutionsTotal.WithLabelValues(job.Options.LanguageID, status).Inc()
+	metrics.ExecutionDuration.WithLabelValues(job.Options.LanguageID, "total").Observe(float64(duration.Milliseconds()))
+
+	if result.MemoryKb > 0 {
+		metrics.MemoryUsage.WithLabelValues(job.Options.LanguageID).Observe(float64(result.MemoryKb))
+	}
+
+	f.complete(job, result, nil)
+	f.recordUsage(job, result)
+}
+
+// recordUsage counts the time the sandbox reports for an attempt against
+// the quota of the job's tenant: the program's run, or the compiler's when
+// compilation fails. Queueing and container setup are not charged, and
+// attempts that failed for infrastructure reasons are not the tenant's
+// doing and are not counted.
+func (f *Finisher) recordUsage(job *queue.Job, result *executor.ExecutionResult) {
+	if f.tenants == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := f.tenants.Record(ctx, job.Tenant, time.Duration(result.TimeMs)*time.Millisecond); err != nil {
+		f.logger.Error().Err(err).Str("job_id", job.ID).Msg("failed to record tenant usage")
+	}
+}
+
+// complete hands the outcome to the queue and, unless the queue discarded
+// it, to the job's submission.
+func (f *Finisher) complete(job *queue.Job, result *executor.ExecutionResult, err error) {
+	if !f.queue.Complete(job, result, err) || !job.Tracked || f.submissions == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, dbErr := f.submissions.Finish(ctx, job.ID, result, err); dbErr != nil {
+		f.logger.Error().Err(dbErr).Str("job_id", job.ID).Msg("failed to record submission outcome")
+	}
+}
+
+// retryOrBury handles a failed attempt. Infrastructure errors are retried
+// with backoff, returning true; once the job is out of retries it is moved
+// to the dead-letter store and the error is reported to the submitter.
+func (f *Finisher) retryOrBury(job *queue.Job, err error) bool {
+	var infraErr *executor.InfrastructureError
+	if !errors.As(err, &infraErr) || job.Ctx.Err() != nil {
+		return false
+	}
+
+	job.Failures = append(job.Failures, queue.Failure{
+		Attempt: len(job.Failures) + 1,
+		Error:   err.Error(),
+		At:      time.Now(),
+	})
+
+	if retry := len(job.Failures); retry <= f.retry.MaxRetries {
+		delay := f.retry.Delay(retry)
+		f.logger.Warn().Err(err).Str("job_id", job.ID).
+			Int("retry", retry).Dur("delay", delay).Msg("retrying job after infrastructure error")
+		metrics.JobRetries.WithLabelValues(job.Options.LanguageID).Inc()
+		f.queue.Retry(job, delay)
+		return true
+	}
+
+	f.logger.Error().Err(err).Str("job_id", job.ID).
+		Int("attempts", len(job.Failures)).Msg("job failed every retry, moving to dead letters")
+	if f.deadLetters != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := f.deadLetters.Add(ctx, job); err != nil {
+			f.logger.Error().Err(err).Str("job_id", job.ID).Msg("failed to store dead letter")
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// hostUsage is the host's load relative to its capacity.
+type hostUsage struct {
+	// CPU is the one-minute load average per core.
+	CPU float64
+	// Memory is the fraction of memory not available to new processes.
+	Memory float64
+}
+
+// readHostUsage reads CPU and memory usage from /proc. ok is false where
+// /proc is unavailable, in which case the autoscaler assumes headroom.
+func readHostUsage() (usage hostUsage, ok bool) {
+	loadavg, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return usage, false
+	}
+	fields := strings.Fields(string(loadavg))
+	if len(fields) == 0 {
+		return usage, false
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return usage, false
+	}
+	usage.CPU = load / float64(runtime.NumCPU())
+
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return usage, false
+	}
+	defer f.Close()
+
+	var total, available float64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total, _ = strconv.ParseFloat(fields[1], 64)
+		case "MemAvailable:":
+			available, _ = strconv.ParseFloat(fields[1], 64)
+		}
+	}
+	if total == 0 {
+		return usage, false
+	}
+	usage.Memory = 1 - available/total
+	return usage, true
+}
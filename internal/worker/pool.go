@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/rs/zerolog"
+)
+
+// Pool runs a resizable set of workers. Removing a worker stops it from
+// taking new jobs; a job it is already processing runs to completion.
+type Pool struct {
+	newWorker func(id int) *Worker
+	logger    *zerolog.Logger
+
+	mu      sync.Mutex
+	ctx     context.Context
+	workers []*poolWorker
+	nextID  int
+	wg      sync.WaitGroup
+}
+
+type poolWorker struct {
+	*Worker
+	cancel context.CancelFunc
+}
+
+// NewPool creates an empty pool. newWorker builds the worker for each new
+// slot; IDs are never reused.
+func NewPool(newWorker func(id int) *Worker, logger *zerolog.Logger) *Pool {
+	return &Pool{
+		newWorker: newWorker,
+		logger:    logger,
+	}
+}
+
+// Start launches size workers. They stop when ctx is cancelled.
+func (p *Pool) Start(ctx context.Context, size int) {
+	p.mu.Lock()
+	p.ctx = ctx
+	p.mu.Unlock()
+	p.Resize(size)
+}
+
+// Resize grows or shrinks the pool to size workers, removing the most
+// recently added ones first.
+func (p *Pool) Resize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.workers) < size {
+		ctx, cancel := context.WithCancel(p.ctx)
+		w := &poolWorker{Worker: p.newWorker(p.nextID), cancel: cancel}
+		p.nextID++
+		p.workers = append(p.workers, w)
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			w.Start(ctx)
+		}()
+	}
+	for len(p.workers) > size {
+		last := len(p.workers) - 1
+		p.workers[last].cancel()
+		p.workers[last] = nil
+		p.workers = p.workers[:last]
+	}
+
+	metrics.WorkerPoolSize.Set(float64(len(p.workers)))
+}
+
+// Size returns the number of workers in the pool.
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.workers)
+}
+
+// Busy returns the number of workers processing a job.
+func (p *Pool) Busy() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	busy := 0
+	for _, w := range p.workers {
+		if w.Busy() {
+			busy++
+		}
+	}
+	return busy
+}
+
+// Workers returns the status of every worker in the pool, oldest first.
+func (p *Pool) Workers() []Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	statuses := make([]Status, len(p.workers))
+	for i, w := range p.workers {
+		statuses[i] = w.Status()
+	}
+	return statuses
+}
+
+// Wait blocks until every worker started by the pool has returned.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
@@ -2,26 +2,63 @@ package worker
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/itstheanurag/executioner/internal/executor"
 	"github.com/itstheanurag/executioner/internal/metrics"
+	"github.com/itstheanurag/executioner/internal/output"
 	"github.com/itstheanurag/executioner/internal/queue"
 	"github.com/rs/zerolog"
 )
 
+// nextJobBackoff is how long a worker waits after the queue fails to hand
+// out a job, e.g. because the database is unreachable.
+const nextJobBackoff = time.Second
+
 type Worker struct {
 	id       int
 	executor *executor.Executor
-	manager  *queue.Manager
+	queue    queue.Queue
+	finisher *Finisher
+	outputs  *output.Hub
 	logger   *zerolog.Logger
+
+	// current is the job being processed, or nil
+	current atomic.Pointer[RunningJob]
+}
+
+// RunningJob is a job a worker is processing.
+type RunningJob struct {
+	ID        string    `json:"id"`
+	Language  string    `json:"language"`
+	Tenant    string    `json:"tenant"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// NewRunningJob describes job, started now.
+func NewRunningJob(job *queue.Job) *RunningJob {
+	return &RunningJob{
+		ID:        job.ID,
+		Language:  job.Options.LanguageID,
+		Tenant:    job.Tenant,
+		StartedAt: time.Now(),
+	}
 }
 
-func NewWorker(id int, exec *executor.Executor, manager *queue.Manager, logger *zerolog.Logger) *Worker {
+// Status describes a worker and the job it is processing, if any.
+type Status struct {
+	ID  int         `json:"id"`
+	Job *RunningJob `json:"job"`
+}
+
+func NewWorker(id int, exec *executor.Executor, q queue.Queue, finisher *Finisher, outputs *output.Hub, logger *zerolog.Logger) *Worker {
 	return &Worker{
 		id:       id,
 		executor: exec,
-		manager:  manager,
+		queue:    q,
+		finisher: finisher,
+		outputs:  outputs,
 		logger:   logger,
 	}
 }
@@ -29,44 +66,56 @@ func NewWorker(id int, exec *executor.Executor, manager *queue.Manager, logger *
 func (w *Worker) Start(ctx context.Context) {
 	w.logger.Info().Int("worker_id", w.id).Msg("worker started")
 	for {
-		select {
-		case job := <-w.manager.NextJob():
-			metrics.ActiveWorkers.Inc()
-			w.processJob(job)
-			metrics.ActiveWorkers.Dec()
-		case <-ctx.Done():
-			w.logger.Info().Int("worker_id", w.id).Msg("worker stopping")
-			return
+		job, err := w.queue.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				w.logger.Info().Int("worker_id", w.id).Msg("worker stopping")
+				return
+			}
+			w.logger.Error().Err(err).Int("worker_id", w.id).Msg("failed to fetch job")
+			select {
+			case <-time.After(nextJobBackoff):
+			case <-ctx.Done():
+			}
+			continue
 		}
+
+		w.current.Store(NewRunningJob(job))
+		metrics.ActiveWorkers.Inc()
+		w.processJob(job)
+		metrics.ActiveWorkers.Dec()
+		w.current.Store(nil)
 	}
 }
 
+// Busy reports whether the worker is processing a job.
+func (w *Worker) Busy() bool {
+	return w.current.Load() != nil
+}
+
+// Status returns the worker's ID and current job.
+func (w *Worker) Status() Status {
+	return Status{ID: w.id, Job: w.current.Load()}
+}
+
 func (w *Worker) processJob(job *queue.Job) {
 	w.logger.Info().Int("worker_id", w.id).Str("job_id", job.ID).Msg("processing job")
 
-	startTime := time.Now()
-	result, err := w.executor.Execute(job.Ctx, job.Options)
-	duration := time.Since(startTime).Milliseconds()
-
-	// Record metrics
-	status := "success"
-	if err != nil {
-		status = "error"
-		job.Err <- err
-		metrics.ExecutionsTotal.WithLabelValues(job.Options.LanguageID, status).Inc()
-		return
-	}
-
-	if result.Status != "success" {
-		status = result.Status
+	opts := job.Options
+	opts.JobID = job.ID
+	if w.outputs != nil {
+		opts.Stdout, opts.Stderr = w.outputs.Start(job.ID)
+		// Followers look up the outcome once woken, so the finisher
+		// must have recorded it first
+		defer w.outputs.Finish(job.ID)
 	}
 
-	metrics.ExecutionsTotal.WithLabelValues(job.Options.LanguageID, status).Inc()
-	metrics.ExecutionDuration.WithLabelValues(job.Options.LanguageID, "total").Observe(float64(duration))
-
-	if result.MemoryKb > 0 {
-		metrics.MemoryUsage.WithLabelValues(job.Options.LanguageID).Observe(float64(result.MemoryKb))
-	}
+	// Each attempt gets the job's full timeout; job.Ctx bounds them all
+	ctx, cancel := context.WithTimeout(job.Ctx, opts.Timeout())
+	startTime := time.Now()
+	result, err := w.executor.Execute(ctx, opts)
+	duration := time.Since(startTime)
+	cancel()
 
-	job.Result <- result
+	w.finisher.Finish(job, result, duration, err)
 }
@@ -0,0 +1,5 @@
+// Package workerpb holds the protocol between the API server and remote
+// worker nodes.
+package workerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative worker.proto
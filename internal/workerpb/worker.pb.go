@@ -0,0 +1,1052 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: worker.proto
+
+package workerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type NodeMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*NodeMessage_Register
+	//	*NodeMessage_Heartbeat
+	//	*NodeMessage_Result
+	Message       isNodeMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeMessage) Reset() {
+	*x = NodeMessage{}
+	mi := &file_worker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeMessage) ProtoMessage() {}
+
+func (x *NodeMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeMessage.ProtoReflect.Descriptor instead.
+func (*NodeMessage) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NodeMessage) GetMessage() isNodeMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *NodeMessage) GetRegister() *Register {
+	if x != nil {
+		if x, ok := x.Message.(*NodeMessage_Register); ok {
+			return x.Register
+		}
+	}
+	return nil
+}
+
+func (x *NodeMessage) GetHeartbeat() *Heartbeat {
+	if x != nil {
+		if x, ok := x.Message.(*NodeMessage_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *NodeMessage) GetResult() *JobResult {
+	if x != nil {
+		if x, ok := x.Message.(*NodeMessage_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isNodeMessage_Message interface {
+	isNodeMessage_Message()
+}
+
+type NodeMessage_Register struct {
+	Register *Register `protobuf:"bytes,1,opt,name=register,proto3,oneof"`
+}
+
+type NodeMessage_Heartbeat struct {
+	Heartbeat *Heartbeat `protobuf:"bytes,2,opt,name=heartbeat,proto3,oneof"`
+}
+
+type NodeMessage_Result struct {
+	Result *JobResult `protobuf:"bytes,3,opt,name=result,proto3,oneof"`
+}
+
+func (*NodeMessage_Register) isNodeMessage_Message() {}
+
+func (*NodeMessage_Heartbeat) isNodeMessage_Message() {}
+
+func (*NodeMessage_Result) isNodeMessage_Message() {}
+
+type ServerMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*ServerMessage_Registered
+	//	*ServerMessage_Job
+	//	*ServerMessage_Cancel
+	Message       isServerMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerMessage) Reset() {
+	*x = ServerMessage{}
+	mi := &file_worker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerMessage) ProtoMessage() {}
+
+func (x *ServerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
+func (*ServerMessage) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ServerMessage) GetMessage() isServerMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetRegistered() *Registered {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Registered); ok {
+			return x.Registered
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetJob() *Job {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Job); ok {
+			return x.Job
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetCancel() *CancelJob {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Cancel); ok {
+			return x.Cancel
+		}
+	}
+	return nil
+}
+
+type isServerMessage_Message interface {
+	isServerMessage_Message()
+}
+
+type ServerMessage_Registered struct {
+	Registered *Registered `protobuf:"bytes,1,opt,name=registered,proto3,oneof"`
+}
+
+type ServerMessage_Job struct {
+	Job *Job `protobuf:"bytes,2,opt,name=job,proto3,oneof"`
+}
+
+type ServerMessage_Cancel struct {
+	Cancel *CancelJob `protobuf:"bytes,3,opt,name=cancel,proto3,oneof"`
+}
+
+func (*ServerMessage_Registered) isServerMessage_Message() {}
+
+func (*ServerMessage_Job) isServerMessage_Message() {}
+
+func (*ServerMessage_Cancel) isServerMessage_Message() {}
+
+// Register describes what a node can run.
+type Register struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Language IDs the node's registry and images support.
+	Languages []string `protobuf:"bytes,2,rep,name=languages,proto3" json:"languages,omitempty"`
+	// Number of jobs the node runs concurrently.
+	Capacity      int32  `protobuf:"varint,3,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Version       string `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Register) Reset() {
+	*x = Register{}
+	mi := &file_worker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Register) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Register) ProtoMessage() {}
+
+func (x *Register) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Register.ProtoReflect.Descriptor instead.
+func (*Register) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Register) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Register) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+func (x *Register) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *Register) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type Registered struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	NodeId string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	// The node must send a heartbeat at least this often.
+	HeartbeatIntervalMs int64 `protobuf:"varint,2,opt,name=heartbeat_interval_ms,json=heartbeatIntervalMs,proto3" json:"heartbeat_interval_ms,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Registered) Reset() {
+	*x = Registered{}
+	mi := &file_worker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Registered) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Registered) ProtoMessage() {}
+
+func (x *Registered) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Registered.ProtoReflect.Descriptor instead.
+func (*Registered) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Registered) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *Registered) GetHeartbeatIntervalMs() int64 {
+	if x != nil {
+		return x.HeartbeatIntervalMs
+	}
+	return 0
+}
+
+type Heartbeat struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// IDs of the jobs the node is running.
+	RunningJobIds []string `protobuf:"bytes,1,rep,name=running_job_ids,json=runningJobIds,proto3" json:"running_job_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_worker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Heartbeat) GetRunningJobIds() []string {
+	if x != nil {
+		return x.RunningJobIds
+	}
+	return nil
+}
+
+type Job struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Options       *ExecuteOptions        `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_worker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetOptions() *ExecuteOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type CancelJob struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelJob) Reset() {
+	*x = CancelJob{}
+	mi := &file_worker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJob) ProtoMessage() {}
+
+func (x *CancelJob) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJob.ProtoReflect.Descriptor instead.
+func (*CancelJob) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CancelJob) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type JobResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are valid to be assigned to Outcome:
+	//
+	//	*JobResult_Result
+	//	*JobResult_InfrastructureError
+	Outcome       isJobResult_Outcome `protobuf_oneof:"outcome"`
+	DurationMs    int64               `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobResult) Reset() {
+	*x = JobResult{}
+	mi := &file_worker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobResult) ProtoMessage() {}
+
+func (x *JobResult) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobResult.ProtoReflect.Descriptor instead.
+func (*JobResult) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *JobResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *JobResult) GetOutcome() isJobResult_Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+func (x *JobResult) GetResult() *ExecutionResult {
+	if x != nil {
+		if x, ok := x.Outcome.(*JobResult_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *JobResult) GetInfrastructureError() string {
+	if x != nil {
+		if x, ok := x.Outcome.(*JobResult_InfrastructureError); ok {
+			return x.InfrastructureError
+		}
+	}
+	return ""
+}
+
+func (x *JobResult) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type isJobResult_Outcome interface {
+	isJobResult_Outcome()
+}
+
+type JobResult_Result struct {
+	Result *ExecutionResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+type JobResult_InfrastructureError struct {
+	// Set when the node's sandbox failed; the job is retried elsewhere.
+	InfrastructureError string `protobuf:"bytes,3,opt,name=infrastructure_error,json=infrastructureError,proto3,oneof"`
+}
+
+func (*JobResult_Result) isJobResult_Outcome() {}
+
+func (*JobResult_InfrastructureError) isJobResult_Outcome() {}
+
+type ExecuteOptions struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	LanguageId string                 `protobuf:"bytes,1,opt,name=language_id,json=languageId,proto3" json:"language_id,omitempty"`
+	// Source, stdin and output are bytes: they need not be UTF-8.
+	SourceCode           []byte   `protobuf:"bytes,2,opt,name=source_code,json=sourceCode,proto3" json:"source_code,omitempty"`
+	Stdin                []byte   `protobuf:"bytes,3,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	TimeLimitMs          int32    `protobuf:"varint,4,opt,name=time_limit_ms,json=timeLimitMs,proto3" json:"time_limit_ms,omitempty"`
+	MemoryLimitKb        int32    `protobuf:"varint,5,opt,name=memory_limit_kb,json=memoryLimitKb,proto3" json:"memory_limit_kb,omitempty"`
+	CompileTimeLimitMs   int32    `protobuf:"varint,6,opt,name=compile_time_limit_ms,json=compileTimeLimitMs,proto3" json:"compile_time_limit_ms,omitempty"`
+	CompileMemoryLimitKb int32    `protobuf:"varint,7,opt,name=compile_memory_limit_kb,json=compileMemoryLimitKb,proto3" json:"compile_memory_limit_kb,omitempty"`
+	CompilerOptions      []string `protobuf:"bytes,8,rep,name=compiler_options,json=compilerOptions,proto3" json:"compiler_options,omitempty"`
+	Args                 []string `protobuf:"bytes,9,rep,name=args,proto3" json:"args,omitempty"`
+	Mode                 string   `protobuf:"bytes,10,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ExecuteOptions) Reset() {
+	*x = ExecuteOptions{}
+	mi := &file_worker_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteOptions) ProtoMessage() {}
+
+func (x *ExecuteOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteOptions.ProtoReflect.Descriptor instead.
+func (*ExecuteOptions) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExecuteOptions) GetLanguageId() string {
+	if x != nil {
+		return x.LanguageId
+	}
+	return ""
+}
+
+func (x *ExecuteOptions) GetSourceCode() []byte {
+	if x != nil {
+		return x.SourceCode
+	}
+	return nil
+}
+
+func (x *ExecuteOptions) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *ExecuteOptions) GetTimeLimitMs() int32 {
+	if x != nil {
+		return x.TimeLimitMs
+	}
+	return 0
+}
+
+func (x *ExecuteOptions) GetMemoryLimitKb() int32 {
+	if x != nil {
+		return x.MemoryLimitKb
+	}
+	return 0
+}
+
+func (x *ExecuteOptions) GetCompileTimeLimitMs() int32 {
+	if x != nil {
+		return x.CompileTimeLimitMs
+	}
+	return 0
+}
+
+func (x *ExecuteOptions) GetCompileMemoryLimitKb() int32 {
+	if x != nil {
+		return x.CompileMemoryLimitKb
+	}
+	return 0
+}
+
+func (x *ExecuteOptions) GetCompilerOptions() []string {
+	if x != nil {
+		return x.CompilerOptions
+	}
+	return nil
+}
+
+func (x *ExecuteOptions) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *ExecuteOptions) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type ExecutionResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Status         string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Stdout         []byte                 `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr         []byte                 `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	ExitCode       int32                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	TimeMs         int64                  `protobuf:"varint,5,opt,name=time_ms,json=timeMs,proto3" json:"time_ms,omitempty"`
+	MemoryKb       int64                  `protobuf:"varint,6,opt,name=memory_kb,json=memoryKb,proto3" json:"memory_kb,omitempty"`
+	ErrorType      string                 `protobuf:"bytes,7,opt,name=error_type,json=errorType,proto3" json:"error_type,omitempty"`
+	Diagnostics    []*Diagnostic          `protobuf:"bytes,8,rep,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	CompileCommand []string               `protobuf:"bytes,9,rep,name=compile_command,json=compileCommand,proto3" json:"compile_command,omitempty"`
+	RunCommand     []string               `protobuf:"bytes,10,rep,name=run_command,json=runCommand,proto3" json:"run_command,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ExecutionResult) Reset() {
+	*x = ExecutionResult{}
+	mi := &file_worker_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionResult) ProtoMessage() {}
+
+func (x *ExecutionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionResult.ProtoReflect.Descriptor instead.
+func (*ExecutionResult) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExecutionResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetStderr() []byte {
+	if x != nil {
+		return x.Stderr
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetTimeMs() int64 {
+	if x != nil {
+		return x.TimeMs
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetMemoryKb() int64 {
+	if x != nil {
+		return x.MemoryKb
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetErrorType() string {
+	if x != nil {
+		return x.ErrorType
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetDiagnostics() []*Diagnostic {
+	if x != nil {
+		return x.Diagnostics
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetCompileCommand() []string {
+	if x != nil {
+		return x.CompileCommand
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetRunCommand() []string {
+	if x != nil {
+		return x.RunCommand
+	}
+	return nil
+}
+
+type Diagnostic struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Line          int32                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Column        int32                  `protobuf:"varint,3,opt,name=column,proto3" json:"column,omitempty"`
+	Severity      string                 `protobuf:"bytes,4,opt,name=severity,proto3" json:"severity,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Diagnostic) Reset() {
+	*x = Diagnostic{}
+	mi := &file_worker_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Diagnostic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Diagnostic) ProtoMessage() {}
+
+func (x *Diagnostic) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Diagnostic.ProtoReflect.Descriptor instead.
+func (*Diagnostic) Descriptor() ([]byte, []int) {
+	return file_worker_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Diagnostic) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *Diagnostic) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
+func (x *Diagnostic) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_worker_proto protoreflect.FileDescriptor
+
+const file_worker_proto_rawDesc = "" +
+	"\n" +
+	"\fworker.proto\x12\x15executioner.worker.v1\"\xd5\x01\n" +
+	"\vNodeMessage\x12=\n" +
+	"\bregister\x18\x01 \x01(\v2\x1f.executioner.worker.v1.RegisterH\x00R\bregister\x12@\n" +
+	"\theartbeat\x18\x02 \x01(\v2 .executioner.worker.v1.HeartbeatH\x00R\theartbeat\x12:\n" +
+	"\x06result\x18\x03 \x01(\v2 .executioner.worker.v1.JobResultH\x00R\x06resultB\t\n" +
+	"\amessage\"\xcb\x01\n" +
+	"\rServerMessage\x12C\n" +
+	"\n" +
+	"registered\x18\x01 \x01(\v2!.executioner.worker.v1.RegisteredH\x00R\n" +
+	"registered\x12.\n" +
+	"\x03job\x18\x02 \x01(\v2\x1a.executioner.worker.v1.JobH\x00R\x03job\x12:\n" +
+	"\x06cancel\x18\x03 \x01(\v2 .executioner.worker.v1.CancelJobH\x00R\x06cancelB\t\n" +
+	"\amessage\"r\n" +
+	"\bRegister\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tlanguages\x18\x02 \x03(\tR\tlanguages\x12\x1a\n" +
+	"\bcapacity\x18\x03 \x01(\x05R\bcapacity\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\"Y\n" +
+	"\n" +
+	"Registered\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x122\n" +
+	"\x15heartbeat_interval_ms\x18\x02 \x01(\x03R\x13heartbeatIntervalMs\"3\n" +
+	"\tHeartbeat\x12&\n" +
+	"\x0frunning_job_ids\x18\x01 \x03(\tR\rrunningJobIds\"V\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12?\n" +
+	"\aoptions\x18\x02 \x01(\v2%.executioner.worker.v1.ExecuteOptionsR\aoptions\"\x1b\n" +
+	"\tCancelJob\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xbe\x01\n" +
+	"\tJobResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12@\n" +
+	"\x06result\x18\x02 \x01(\v2&.executioner.worker.v1.ExecutionResultH\x00R\x06result\x123\n" +
+	"\x14infrastructure_error\x18\x03 \x01(\tH\x00R\x13infrastructureError\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMsB\t\n" +
+	"\aoutcome\"\xf1\x02\n" +
+	"\x0eExecuteOptions\x12\x1f\n" +
+	"\vlanguage_id\x18\x01 \x01(\tR\n" +
+	"languageId\x12\x1f\n" +
+	"\vsource_code\x18\x02 \x01(\fR\n" +
+	"sourceCode\x12\x14\n" +
+	"\x05stdin\x18\x03 \x01(\fR\x05stdin\x12\"\n" +
+	"\rtime_limit_ms\x18\x04 \x01(\x05R\vtimeLimitMs\x12&\n" +
+	"\x0fmemory_limit_kb\x18\x05 \x01(\x05R\rmemoryLimitKb\x121\n" +
+	"\x15compile_time_limit_ms\x18\x06 \x01(\x05R\x12compileTimeLimitMs\x125\n" +
+	"\x17compile_memory_limit_kb\x18\a \x01(\x05R\x14compileMemoryLimitKb\x12)\n" +
+	"\x10compiler_options\x18\b \x03(\tR\x0fcompilerOptions\x12\x12\n" +
+	"\x04args\x18\t \x03(\tR\x04args\x12\x12\n" +
+	"\x04mode\x18\n" +
+	" \x01(\tR\x04mode\"\xda\x02\n" +
+	"\x0fExecutionResult\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
+	"\x06stdout\x18\x02 \x01(\fR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x03 \x01(\fR\x06stderr\x12\x1b\n" +
+	"\texit_code\x18\x04 \x01(\x05R\bexitCode\x12\x17\n" +
+	"\atime_ms\x18\x05 \x01(\x03R\x06timeMs\x12\x1b\n" +
+	"\tmemory_kb\x18\x06 \x01(\x03R\bmemoryKb\x12\x1d\n" +
+	"\n" +
+	"error_type\x18\a \x01(\tR\terrorType\x12C\n" +
+	"\vdiagnostics\x18\b \x03(\v2!.executioner.worker.v1.DiagnosticR\vdiagnostics\x12'\n" +
+	"\x0fcompile_command\x18\t \x03(\tR\x0ecompileCommand\x12\x1f\n" +
+	"\vrun_command\x18\n" +
+	" \x03(\tR\n" +
+	"runCommand\"\x82\x01\n" +
+	"\n" +
+	"Diagnostic\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\x03 \x01(\x05R\x06column\x12\x1a\n" +
+	"\bseverity\x18\x04 \x01(\tR\bseverity\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage2h\n" +
+	"\rWorkerService\x12W\n" +
+	"\aConnect\x12\".executioner.worker.v1.NodeMessage\x1a$.executioner.worker.v1.ServerMessage(\x010\x01B7Z5github.com/itstheanurag/executioner/internal/workerpbb\x06proto3"
+
+var (
+	file_worker_proto_rawDescOnce sync.Once
+	file_worker_proto_rawDescData []byte
+)
+
+func file_worker_proto_rawDescGZIP() []byte {
+	file_worker_proto_rawDescOnce.Do(func() {
+		file_worker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_worker_proto_rawDesc), len(file_worker_proto_rawDesc)))
+	})
+	return file_worker_proto_rawDescData
+}
+
+var file_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_worker_proto_goTypes = []any{
+	(*NodeMessage)(nil),     // 0: executioner.worker.v1.NodeMessage
+	(*ServerMessage)(nil),   // 1: executioner.worker.v1.ServerMessage
+	(*Register)(nil),        // 2: executioner.worker.v1.Register
+	(*Registered)(nil),      // 3: executioner.worker.v1.Registered
+	(*Heartbeat)(nil),       // 4: executioner.worker.v1.Heartbeat
+	(*Job)(nil),             // 5: executioner.worker.v1.Job
+	(*CancelJob)(nil),       // 6: executioner.worker.v1.CancelJob
+	(*JobResult)(nil),       // 7: executioner.worker.v1.JobResult
+	(*ExecuteOptions)(nil),  // 8: executioner.worker.v1.ExecuteOptions
+	(*ExecutionResult)(nil), // 9: executioner.worker.v1.ExecutionResult
+	(*Diagnostic)(nil),      // 10: executioner.worker.v1.Diagnostic
+}
+var file_worker_proto_depIdxs = []int32{
+	2,  // 0: executioner.worker.v1.NodeMessage.register:type_name -> executioner.worker.v1.Register
+	4,  // 1: executioner.worker.v1.NodeMessage.heartbeat:type_name -> executioner.worker.v1.Heartbeat
+	7,  // 2: executioner.worker.v1.NodeMessage.result:type_name -> executioner.worker.v1.JobResult
+	3,  // 3: executioner.worker.v1.ServerMessage.registered:type_name -> executioner.worker.v1.Registered
+	5,  // 4: executioner.worker.v1.ServerMessage.job:type_name -> executioner.worker.v1.Job
+	6,  // 5: executioner.worker.v1.ServerMessage.cancel:type_name -> executioner.worker.v1.CancelJob
+	8,  // 6: executioner.worker.v1.Job.options:type_name -> executioner.worker.v1.ExecuteOptions
+	9,  // 7: executioner.worker.v1.JobResult.result:type_name -> executioner.worker.v1.ExecutionResult
+	10, // 8: executioner.worker.v1.ExecutionResult.diagnostics:type_name -> executioner.worker.v1.Diagnostic
+	0,  // 9: executioner.worker.v1.WorkerService.Connect:input_type -> executioner.worker.v1.NodeMessage
+	1,  // 10: executioner.worker.v1.WorkerService.Connect:output_type -> executioner.worker.v1.ServerMessage
+	10, // [10:11] is the sub-list for method output_type
+	9,  // [9:10] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_worker_proto_init() }
+func file_worker_proto_init() {
+	if File_worker_proto != nil {
+		return
+	}
+	file_worker_proto_msgTypes[0].OneofWrappers = []any{
+		(*NodeMessage_Register)(nil),
+		(*NodeMessage_Heartbeat)(nil),
+		(*NodeMessage_Result)(nil),
+	}
+	file_worker_proto_msgTypes[1].OneofWrappers = []any{
+		(*ServerMessage_Registered)(nil),
+		(*ServerMessage_Job)(nil),
+		(*ServerMessage_Cancel)(nil),
+	}
+	file_worker_proto_msgTypes[7].OneofWrappers = []any{
+		(*JobResult_Result)(nil),
+		(*JobResult_InfrastructureError)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_worker_proto_rawDesc), len(file_worker_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_worker_proto_goTypes,
+		DependencyIndexes: file_worker_proto_depIdxs,
+		MessageInfos:      file_worker_proto_msgTypes,
+	}.Build()
+	File_worker_proto = out.File
+	file_worker_proto_goTypes = nil
+	file_worker_proto_depIdxs = nil
+}